@@ -0,0 +1,204 @@
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	ginzap "github.com/gin-contrib/zap"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// SinkType defines where access logs are written
+type SinkType string
+
+// Sink type constants
+const (
+	StdoutSink SinkType = "stdout"
+	StderrSink SinkType = "stderr"
+	FileSink   SinkType = "file"
+	SyslogSink SinkType = "syslog"
+)
+
+// Config configures the access log sink
+type Config struct {
+	// Sink selects the destination, defaults to StdoutSink
+	Sink SinkType
+
+	// Path is the log file path, required for FileSink
+	Path string
+
+	// MaxSizeMB is the size a log file reaches before it is rotated, defaults to 100
+	MaxSizeMB int
+
+	// MaxBackups is the number of rotated files to keep, 0 keeps all of them
+	MaxBackups int
+
+	// MaxAgeDays is the number of days to keep rotated files, 0 keeps them forever
+	MaxAgeDays int
+
+	// Compress gzips rotated files
+	Compress bool
+
+	// SyslogNetwork and SyslogAddr select a remote syslog daemon, both empty uses the local one
+	SyslogNetwork string
+	SyslogAddr    string
+
+	// SyslogTag is the syslog tag, defaults to the process name
+	SyslogTag string
+
+	// SkipPaths are request paths that are not logged, e.g. health checks
+	SkipPaths []string
+}
+
+// Logger is a zap logger dedicated to access logs, its own type keeps it apart from the
+// application *zap.Logger when both are passed as dependencies
+type Logger struct {
+	*zap.Logger
+
+	skipPaths []string
+	closer    io.Closer
+}
+
+// New creates an access logger writing JSON entries to the configured sink
+func New(cfg Config) (*Logger, error) {
+	writer, closer, err := newSink(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(writer),
+		zap.InfoLevel,
+	)
+
+	return &Logger{
+		Logger:    zap.New(core),
+		skipPaths: cfg.SkipPaths,
+		closer:    closer,
+	}, nil
+}
+
+// Middleware returns gin middleware writing one entry per request to the access logger
+func (l *Logger) Middleware() gin.HandlerFunc {
+	return ginzap.GinzapWithConfig(l.Logger, &ginzap.Config{
+		TimeFormat:   time.RFC3339,
+		UTC:          true,
+		SkipPaths:    l.skipPaths,
+		DefaultLevel: zapcore.InfoLevel,
+	})
+}
+
+// Handler wraps the handler with middleware writing one entry per request to the access logger,
+// with the same fields as Middleware, for routers over the standard library
+func (l *Logger) Handler(next http.Handler) http.Handler {
+	skip := make(map[string]bool, len(l.skipPaths))
+	for _, path := range l.skipPaths {
+		skip[path] = true
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		path := req.URL.Path
+		query := req.URL.RawQuery
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, req)
+		if skip[path] {
+			return
+		}
+
+		end := time.Now().UTC()
+		ip, _, err := net.SplitHostPort(req.RemoteAddr)
+		if err != nil {
+			ip = req.RemoteAddr
+		}
+		l.Info(path,
+			zap.Int("status", recorder.status),
+			zap.String("method", req.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.String("ip", ip),
+			zap.String("user-agent", req.UserAgent()),
+			zap.Duration("latency", end.Sub(start)),
+			zap.String("time", end.Format(time.RFC3339)),
+		)
+	})
+}
+
+// statusRecorder captures the status code written by a handler
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController, for flushing and hijacking
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Close flushes the logger and releases the underlying sink
+func (l *Logger) Close() error {
+	_ = l.Logger.Sync()
+	if l.closer != nil {
+		return l.closer.Close()
+	}
+	return nil
+}
+
+// newSink opens the writer for the configured sink
+func newSink(cfg Config) (io.Writer, io.Closer, error) {
+	switch cfg.Sink {
+	case "", StdoutSink:
+		return os.Stdout, nil, nil
+
+	case StderrSink:
+		return os.Stderr, nil, nil
+
+	case FileSink:
+		if cfg.Path == "" {
+			return nil, nil, fmt.Errorf("access log path is required for %s sink", FileSink)
+		}
+
+		maxSize := cfg.MaxSizeMB
+		if maxSize <= 0 {
+			maxSize = 100
+		}
+
+		rotator := &lumberjack.Logger{
+			Filename:   cfg.Path,
+			MaxSize:    maxSize,
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAgeDays,
+			Compress:   cfg.Compress,
+		}
+		return rotator, rotator, nil
+
+	case SyslogSink:
+		return newSyslogSink(cfg)
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported access log sink: %s", cfg.Sink)
+	}
+}
@@ -0,0 +1,118 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         Config
+		wantErr     bool
+		expectedErr string
+	}{
+		{name: "default sink is stdout", cfg: Config{}},
+		{name: "stderr sink", cfg: Config{Sink: StderrSink}},
+		{name: "file sink", cfg: Config{Sink: FileSink, Path: filepath.Join(t.TempDir(), "access.log")}},
+		{
+			name:        "file sink without path",
+			cfg:         Config{Sink: FileSink},
+			wantErr:     true,
+			expectedErr: "access log path is required for file sink",
+		},
+		{
+			name:        "unsupported sink",
+			cfg:         Config{Sink: SinkType("kafka")},
+			wantErr:     true,
+			expectedErr: "unsupported access log sink: kafka",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			logger, err := New(tt.cfg)
+
+			if tt.wantErr {
+				assert.EqualError(t, err, tt.expectedErr)
+				assert.Nil(t, logger)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, logger)
+		})
+	}
+}
+
+func TestLogger_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := New(Config{Sink: FileSink, Path: path, SkipPaths: []string{"/health"}})
+	require.NoError(t, err)
+
+	engine := gin.New()
+	engine.Use(logger.Middleware())
+	engine.GET("/hello", func(c *gin.Context) { c.String(http.StatusOK, "hi") })
+	engine.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	for _, target := range []string{"/hello?name=x", "/health"} {
+		engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Only the non-skipped request is logged
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "/hello", entry["path"])
+	assert.Equal(t, "name=x", entry["query"])
+	assert.Equal(t, float64(http.StatusOK), entry["status"])
+}
+
+func TestLogger_Handler(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "access.log")
+	logger, err := New(Config{Sink: FileSink, Path: path, SkipPaths: []string{"/health"}})
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/missing", func(w http.ResponseWriter, r *http.Request) { http.NotFound(w, r) })
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {})
+	handler := logger.Handler(mux)
+
+	for _, target := range []string{"/missing?name=x", "/health"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+	}
+	require.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	// Only the non-skipped request is logged
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 1)
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	assert.Equal(t, "/missing", entry["path"])
+	assert.Equal(t, "name=x", entry["query"])
+	assert.Equal(t, http.MethodGet, entry["method"])
+	assert.Equal(t, "192.0.2.1", entry["ip"])
+	assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+}
@@ -0,0 +1,18 @@
+//go:build !windows && !plan9
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"log/syslog"
+)
+
+// newSyslogSink connects to the configured syslog daemon
+func newSyslogSink(cfg Config) (io.Writer, io.Closer, error) {
+	writer, err := syslog.Dial(cfg.SyslogNetwork, cfg.SyslogAddr, syslog.LOG_INFO|syslog.LOG_LOCAL0, cfg.SyslogTag)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return writer, writer, nil
+}
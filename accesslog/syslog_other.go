@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package accesslog
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// newSyslogSink is not supported on this operating system
+func newSyslogSink(cfg Config) (io.Writer, io.Closer, error) {
+	return nil, nil, fmt.Errorf("%s sink is not supported on %s", SyslogSink, runtime.GOOS)
+}
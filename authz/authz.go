@@ -0,0 +1,262 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"go.uber.org/zap"
+)
+
+// subjectKey is the gin context key holding the authenticated subject
+const subjectKey = "authz.subject"
+
+// subjectContextKey is the request context key holding the authenticated subject
+type subjectContextKey struct{}
+
+// scopesKey is the gin context key holding the scopes granted to the access token
+const scopesKey = "authz.scopes"
+
+// Wildcard grants every action on a resource when used as the action ("invoices:*") or every
+// permission when used alone ("*")
+const Wildcard = "*"
+
+// BindingStore resolves the roles bound to a subject, for bindings kept outside the process
+type BindingStore interface {
+	RolesFor(ctx context.Context, subject string) ([]string, error)
+}
+
+// Config declares roles and static bindings, typically loaded from the service configuration
+type Config struct {
+	// Roles maps a role name to the permissions it grants
+	Roles map[string][]string `json:"roles" yaml:"roles"`
+
+	// Bindings maps a subject to the roles it holds
+	Bindings map[string][]string `json:"bindings" yaml:"bindings"`
+}
+
+// Authorizer checks subjects' permissions against role definitions and bindings
+type Authorizer struct {
+	// mu protects roles and bindings
+	mu       sync.RWMutex
+	roles    map[string][]string
+	bindings map[string][]string
+
+	store  BindingStore
+	logger *zap.Logger
+}
+
+// NewAuthorizer creates an authorizer from static configuration and an optional binding store,
+// roles from the store are added to the static bindings of a subject
+func NewAuthorizer(cfg Config, store BindingStore, logger *zap.Logger) *Authorizer {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	a := &Authorizer{
+		roles:    make(map[string][]string),
+		bindings: make(map[string][]string),
+		store:    store,
+		logger:   logger,
+	}
+	for role, permissions := range cfg.Roles {
+		a.DefineRole(role, permissions...)
+	}
+	for subject, roles := range cfg.Bindings {
+		a.Bind(subject, roles...)
+	}
+
+	return a
+}
+
+// DefineRole sets the permissions granted by a role
+func (a *Authorizer) DefineRole(role string, permissions ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.roles[role] = append([]string(nil), permissions...)
+}
+
+// Bind grants roles to a subject
+func (a *Authorizer) Bind(subject string, roles ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.bindings[subject] = append(a.bindings[subject], roles...)
+}
+
+// Allowed reports whether the subject holds the permission through any of its roles
+func (a *Authorizer) Allowed(ctx context.Context, subject, permission string) (bool, error) {
+	a.mu.RLock()
+	roles := append([]string(nil), a.bindings[subject]...)
+	a.mu.RUnlock()
+
+	if a.store != nil {
+		stored, err := a.store.RolesFor(ctx, subject)
+		if err != nil {
+			return false, fmt.Errorf("failed to resolve roles for %s: %w", subject, err)
+		}
+		roles = append(roles, stored...)
+	}
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	for _, role := range roles {
+		for _, granted := range a.roles[role] {
+			if matches(granted, permission) {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// RequirePermission returns middleware rejecting requests whose subject lacks the permission.
+// Requests without a subject get a 401 problem response, denied requests a 403 and requests whose
+// permissions cannot be resolved a 503.
+func (a *Authorizer) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, ok := Subject(c)
+		if !ok {
+			AbortWithProblem(c, Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		// A failing role store says nothing about the subject's permissions, the client may retry
+		allowed, err := a.Allowed(c.Request.Context(), subject, permission)
+		if err != nil {
+			a.logger.Error("Failed to authorize request", zap.String("subject", subject), zap.Error(err))
+			problem.Abort(c, problem.Unavailable("permissions could not be resolved"))
+			return
+		}
+
+		if !allowed {
+			a.logger.Info("Permission denied",
+				zap.String("subject", subject),
+				zap.String("permission", permission))
+			AbortWithProblem(c, Forbidden(permission))
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermissionHandler is the net/http variant of RequirePermission, for services on a
+// platform Router. The subject is read with SubjectFromContext.
+func (a *Authorizer) RequirePermissionHandler(permission string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject, ok := SubjectFromContext(r.Context())
+		if !ok {
+			WriteProblem(w, Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		allowed, err := a.Allowed(r.Context(), subject, permission)
+		if err != nil {
+			a.logger.Error("Failed to authorize request", zap.String("subject", subject), zap.Error(err))
+			problem.Write(w, problem.Unavailable("permissions could not be resolved"))
+			return
+		}
+
+		if !allowed {
+			a.logger.Info("Permission denied",
+				zap.String("subject", subject),
+				zap.String("permission", permission))
+			WriteProblem(w, Forbidden(permission))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetSubject records the authenticated subject on the request, authentication middleware calls
+// it before any RequirePermission check runs. The subject is also set on the request context so
+// net/http handlers behind the gin engine see it.
+func SetSubject(c *gin.Context, subject string) {
+	c.Set(subjectKey, subject)
+	c.Request = c.Request.WithContext(WithSubject(c.Request.Context(), subject))
+}
+
+// Subject returns the authenticated subject of the request
+func Subject(c *gin.Context) (string, bool) {
+	if subject := c.GetString(subjectKey); subject != "" {
+		return subject, true
+	}
+	return SubjectFromContext(c.Request.Context())
+}
+
+// WithSubject returns a context carrying the authenticated subject, net/http authentication
+// middleware sets it on the request before any RequirePermissionHandler check runs
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectContextKey{}, subject)
+}
+
+// SubjectFromContext returns the authenticated subject carried by the context
+func SubjectFromContext(ctx context.Context) (string, bool) {
+	subject, _ := ctx.Value(subjectContextKey{}).(string)
+	return subject, subject != ""
+}
+
+// SetScopes records the scopes granted to the access token of the request, authentication
+// middleware calls it before any RequireScope check runs
+func SetScopes(c *gin.Context, scopes ...string) {
+	c.Set(scopesKey, scopes)
+}
+
+// Scopes returns the scopes granted to the access token of the request
+func Scopes(c *gin.Context) []string {
+	return c.GetStringSlice(scopesKey)
+}
+
+// RequireScope returns middleware rejecting requests whose access token lacks one of the
+// scopes. Requests without a subject get a 401 problem response and the others a 403
+// insufficient scope problem.
+func RequireScope(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := Subject(c); !ok {
+			AbortWithProblem(c, Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		granted := make(map[string]bool)
+		for _, scope := range Scopes(c) {
+			granted[scope] = true
+		}
+		for _, scope := range scopes {
+			if !granted[scope] {
+				AbortWithProblem(c, InsufficientScope(scopes...))
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// matches reports whether a granted permission covers the requested one
+func matches(granted, requested string) bool {
+	if granted == Wildcard || granted == requested {
+		return true
+	}
+
+	resource, action, ok := strings.Cut(granted, ":")
+	if !ok || action != Wildcard {
+		return false
+	}
+
+	requestedResource, _, ok := strings.Cut(requested, ":")
+	return ok && requestedResource == resource
+}
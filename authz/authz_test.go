@@ -0,0 +1,311 @@
+package authz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// staticStore is a BindingStore backed by a map
+type staticStore struct {
+	roles map[string][]string
+	err   error
+}
+
+func (s *staticStore) RolesFor(ctx context.Context, subject string) ([]string, error) {
+	return s.roles[subject], s.err
+}
+
+func TestAuthorizer_Allowed(t *testing.T) {
+	ctx := context.Background()
+
+	a := NewAuthorizer(Config{
+		Roles: map[string][]string{
+			"viewer":  {"invoices:read"},
+			"billing": {"invoices:*"},
+			"admin":   {"*"},
+		},
+		Bindings: map[string][]string{
+			"alice": {"viewer"},
+			"bob":   {"billing"},
+			"root":  {"admin"},
+		},
+	}, &staticStore{roles: map[string][]string{"carol": {"viewer"}}}, zaptest.NewLogger(t))
+
+	tests := []struct {
+		subject    string
+		permission string
+		want       bool
+	}{
+		{"alice", "invoices:read", true},
+		{"alice", "invoices:write", false},
+		{"bob", "invoices:write", true},
+		{"bob", "customers:read", false},
+		{"root", "customers:delete", true},
+		{"carol", "invoices:read", true},
+		{"mallory", "invoices:read", false},
+	}
+
+	for _, tt := range tests {
+		got, err := a.Allowed(ctx, tt.subject, tt.permission)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.want, got, "%s %s", tt.subject, tt.permission)
+	}
+}
+
+func TestAuthorizer_AllowedStoreError(t *testing.T) {
+	a := NewAuthorizer(Config{}, &staticStore{err: errors.New("store down")}, nil)
+
+	allowed, err := a.Allowed(context.Background(), "alice", "invoices:read")
+
+	assert.False(t, allowed)
+	assert.EqualError(t, err, "failed to resolve roles for alice: store down")
+}
+
+func TestAuthorizer_RequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a := NewAuthorizer(Config{}, nil, zaptest.NewLogger(t))
+	a.DefineRole("viewer", "invoices:read")
+	a.Bind("alice", "viewer")
+
+	tests := []struct {
+		name        string
+		subject     string
+		permission  string
+		wantStatus  int
+		wantProblem string
+	}{
+		{name: "allowed", subject: "alice", permission: "invoices:read", wantStatus: http.StatusOK},
+		{name: "denied", subject: "alice", permission: "invoices:write", wantStatus: http.StatusForbidden, wantProblem: ForbiddenProblemType},
+		{name: "no subject", permission: "invoices:read", wantStatus: http.StatusUnauthorized, wantProblem: UnauthenticatedProblemType},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(func(c *gin.Context) {
+				if tt.subject != "" {
+					SetSubject(c, tt.subject)
+				}
+			})
+			engine.GET("/invoices", a.RequirePermission(tt.permission), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantProblem == "" {
+				return
+			}
+
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantProblem, problem.Type)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+		})
+	}
+}
+
+func TestAuthorizer_RequirePermissionStoreError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a := NewAuthorizer(Config{}, &staticStore{err: errors.New("store down")}, zaptest.NewLogger(t))
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { SetSubject(c, "alice") })
+	engine.GET("/invoices", a.RequirePermission("invoices:read"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+
+	// An outage is not reported as a missing permission
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var p Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.UnavailableProblemType, p.Type)
+	assert.Empty(t, p.Permission)
+}
+
+func TestAuthorizer_RequirePermissionHandler(t *testing.T) {
+	a := NewAuthorizer(Config{}, nil, zaptest.NewLogger(t))
+	a.DefineRole("viewer", "invoices:read")
+	a.Bind("alice", "viewer")
+
+	tests := []struct {
+		name        string
+		subject     string
+		permission  string
+		wantStatus  int
+		wantProblem string
+	}{
+		{name: "allowed", subject: "alice", permission: "invoices:read", wantStatus: http.StatusOK},
+		{name: "denied", subject: "alice", permission: "invoices:write", wantStatus: http.StatusForbidden, wantProblem: ForbiddenProblemType},
+		{name: "no subject", permission: "invoices:read", wantStatus: http.StatusUnauthorized, wantProblem: UnauthenticatedProblemType},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			handler := a.RequirePermissionHandler(tt.permission, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/invoices", nil)
+			if tt.subject != "" {
+				req = req.WithContext(WithSubject(req.Context(), tt.subject))
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantProblem == "" {
+				return
+			}
+
+			assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+			var p Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+			assert.Equal(t, tt.wantProblem, p.Type)
+		})
+	}
+}
+
+func TestSetSubject_RequestContext(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got string
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { SetSubject(c, "alice") })
+	engine.GET("/invoices", gin.WrapF(func(w http.ResponseWriter, r *http.Request) {
+		got, _ = SubjectFromContext(r.Context())
+	}))
+
+	engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/invoices", nil))
+
+	assert.Equal(t, "alice", got)
+}
+
+func TestRequireScope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		subject       string
+		scopes        []string
+		wantStatus    int
+		wantProblem   string
+		wantChallenge string
+	}{
+		{name: "granted", subject: "alice", scopes: []string{"invoices.read", "invoices.write"}, wantStatus: http.StatusOK},
+		{
+			name:          "missing scope",
+			subject:       "alice",
+			scopes:        []string{"invoices.read"},
+			wantStatus:    http.StatusForbidden,
+			wantProblem:   InsufficientScopeProblemType,
+			wantChallenge: `Bearer error="insufficient_scope", scope="invoices.read invoices.write"`,
+		},
+		{
+			name:          "no subject",
+			wantStatus:    http.StatusUnauthorized,
+			wantProblem:   UnauthenticatedProblemType,
+			wantChallenge: "Bearer",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(func(c *gin.Context) {
+				if tt.subject != "" {
+					SetSubject(c, tt.subject)
+					SetScopes(c, tt.scopes...)
+				}
+			})
+			engine.POST("/invoices", RequireScope("invoices.read", "invoices.write"), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/invoices", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantChallenge, rec.Header().Get("WWW-Authenticate"))
+			if tt.wantProblem == "" {
+				return
+			}
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantProblem, problem.Type)
+		})
+	}
+}
+
+func TestTokenProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		err           error
+		wantProblem   string
+		wantChallenge string
+	}{
+		{
+			name:          "expired",
+			err:           fmt.Errorf("validate token: %w", ErrTokenExpired),
+			wantProblem:   TokenExpiredProblemType,
+			wantChallenge: `Bearer error="invalid_token", error_description="the access token expired"`,
+		},
+		{
+			name:          "invalid signature",
+			err:           fmt.Errorf("validate token: %w", ErrInvalidSignature),
+			wantProblem:   InvalidSignatureProblemType,
+			wantChallenge: `Bearer error="invalid_token", error_description="the access token signature is invalid"`,
+		},
+		{
+			name:          "other",
+			err:           errors.New("malformed token"),
+			wantProblem:   UnauthenticatedProblemType,
+			wantChallenge: "Bearer",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.GET("/invoices", func(c *gin.Context) {
+				AbortWithProblem(c, TokenProblem(tt.err))
+			})
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/invoices", nil))
+
+			assert.Equal(t, http.StatusUnauthorized, rec.Code)
+			assert.Equal(t, tt.wantChallenge, rec.Header().Get("WWW-Authenticate"))
+			assert.Equal(t, "application/problem+json", rec.Header().Get("Content-Type"))
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantProblem, problem.Type)
+		})
+	}
+}
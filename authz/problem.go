@@ -0,0 +1,154 @@
+package authz
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+)
+
+// Problem types returned by the authz middleware
+const (
+	// UnauthenticatedProblemType is returned when a protected route is called without a subject
+	UnauthenticatedProblemType = "urn:bootstrapper:problem:unauthenticated"
+
+	// ForbiddenProblemType is returned when the subject lacks the required permission
+	ForbiddenProblemType = "urn:bootstrapper:problem:forbidden"
+
+	// TokenExpiredProblemType is returned when the bearer token has expired
+	TokenExpiredProblemType = "urn:bootstrapper:problem:token-expired"
+
+	// InvalidSignatureProblemType is returned when the bearer token signature does not verify
+	InvalidSignatureProblemType = "urn:bootstrapper:problem:invalid-signature"
+
+	// InsufficientScopeProblemType is returned when the token lacks a required scope
+	InsufficientScopeProblemType = "urn:bootstrapper:problem:insufficient-scope"
+)
+
+// Token validation errors, authentication middleware wraps them so TokenProblem picks the
+// matching problem type
+var (
+	ErrTokenExpired     = errors.New("token expired")
+	ErrInvalidSignature = errors.New("invalid token signature")
+)
+
+// Problem is an RFC 7807 problem details body of the authz middleware
+type Problem struct {
+	problem.Problem
+
+	// Permission is the permission that was required, set on forbidden problems
+	Permission string `json:"permission,omitempty"`
+
+	// Scope lists the scopes that were required, set on insufficient scope problems
+	Scope string `json:"scope,omitempty"`
+}
+
+// Challenge returns the RFC 6750 WWW-Authenticate challenge of the problem, empty for problems
+// that do not challenge the client
+func (p Problem) Challenge() string {
+	switch p.Type {
+	case UnauthenticatedProblemType:
+		return "Bearer"
+	case TokenExpiredProblemType, InvalidSignatureProblemType:
+		return fmt.Sprintf("Bearer error=\"invalid_token\", error_description=%q", p.Detail)
+	case InsufficientScopeProblemType:
+		return fmt.Sprintf("Bearer error=\"insufficient_scope\", scope=%q", p.Scope)
+	default:
+		return ""
+	}
+}
+
+// Unauthenticated creates the problem returned when there is no authenticated subject
+func Unauthenticated(detail string) Problem {
+	return Problem{Problem: problem.Problem{
+		Type:   UnauthenticatedProblemType,
+		Title:  "Unauthenticated",
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+	}}
+}
+
+// Forbidden creates the problem returned when the subject lacks a permission
+func Forbidden(permission string) Problem {
+	return Problem{
+		Problem: problem.Problem{
+			Type:   ForbiddenProblemType,
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: fmt.Sprintf("missing permission %s", permission),
+		},
+		Permission: permission,
+	}
+}
+
+// TokenExpired creates the problem returned when the bearer token has expired
+func TokenExpired() Problem {
+	return Problem{Problem: problem.Problem{
+		Type:   TokenExpiredProblemType,
+		Title:  "Token expired",
+		Status: http.StatusUnauthorized,
+		Detail: "the access token expired",
+	}}
+}
+
+// InvalidSignature creates the problem returned when the bearer token signature does not verify
+func InvalidSignature() Problem {
+	return Problem{Problem: problem.Problem{
+		Type:   InvalidSignatureProblemType,
+		Title:  "Invalid token signature",
+		Status: http.StatusUnauthorized,
+		Detail: "the access token signature is invalid",
+	}}
+}
+
+// InsufficientScope creates the problem returned when the token lacks one of the scopes
+func InsufficientScope(scopes ...string) Problem {
+	scope := strings.Join(scopes, " ")
+	return Problem{
+		Problem: problem.Problem{
+			Type:   InsufficientScopeProblemType,
+			Title:  "Insufficient scope",
+			Status: http.StatusForbidden,
+			Detail: fmt.Sprintf("missing scope %s", scope),
+		},
+		Scope: scope,
+	}
+}
+
+// TokenProblem returns the problem for a token validation error, errors wrapping
+// ErrTokenExpired or ErrInvalidSignature get their own type and others are unauthenticated
+func TokenProblem(err error) Problem {
+	switch {
+	case errors.Is(err, ErrTokenExpired):
+		return TokenExpired()
+	case errors.Is(err, ErrInvalidSignature):
+		return InvalidSignature()
+	default:
+		return Unauthenticated("invalid access token")
+	}
+}
+
+// AbortWithProblem writes the problem as the response with its WWW-Authenticate challenge and
+// stops the handler chain, authentication middleware uses it to reject requests consistently:
+//
+//	if err := verify(token); err != nil {
+//		authz.AbortWithProblem(c, authz.TokenProblem(err))
+//		return
+//	}
+func AbortWithProblem(c *gin.Context, p Problem) {
+	if challenge := p.Challenge(); challenge != "" {
+		c.Header("WWW-Authenticate", challenge)
+	}
+	problem.Abort(c, p)
+}
+
+// WriteProblem is the net/http variant of AbortWithProblem
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	if challenge := p.Challenge(); challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	problem.Write(w, p)
+}
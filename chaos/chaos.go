@@ -0,0 +1,159 @@
+package chaos
+
+import (
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// EnabledEnv must be set to a true value ("1", "true") for faults to be injected, so chaos rules
+// left in the configuration are inert in environments that do not opt in
+const EnabledEnv = "BOOTSTRAPPER_CHAOS"
+
+// Rule describes a fault injected into the requests it matches. A rule may combine latency with
+// an error or a dropped connection, the latency is applied first.
+type Rule struct {
+	// Name identifies the rule in logs
+	Name string `json:"name" yaml:"name"`
+
+	// Method restricts the rule to an HTTP method, empty matches every method
+	Method string `json:"method" yaml:"method"`
+
+	// PathPrefix restricts the rule to request paths starting with it, empty matches every path
+	PathPrefix string `json:"pathPrefix" yaml:"pathPrefix"`
+
+	// Percent is the share of matching requests the fault is injected into, from 0 to 100
+	Percent float64 `json:"percent" yaml:"percent"`
+
+	// Latency delays matching requests before they are handled
+	Latency time.Duration `json:"latency" yaml:"latency"`
+
+	// ErrorStatus aborts matching requests with this status code when non-zero
+	ErrorStatus int `json:"errorStatus" yaml:"errorStatus"`
+
+	// DropConnection closes the client connection without a response
+	DropConnection bool `json:"dropConnection" yaml:"dropConnection"`
+}
+
+// Config lists the chaos rules, the first matching rule selected for a request is applied
+type Config struct {
+	Rules []Rule `json:"rules" yaml:"rules"`
+}
+
+// Injector injects faults into HTTP requests for resilience testing
+type Injector struct {
+	rules   []Rule
+	enabled bool
+	logger  *zap.Logger
+
+	// roll returns a number in [0, 100), it is replaced in tests
+	roll func() float64
+}
+
+// New creates an injector, it is enabled only when EnabledEnv is set to a true value
+func New(cfg Config, logger *zap.Logger) *Injector {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	enabled, _ := strconv.ParseBool(os.Getenv(EnabledEnv))
+	if enabled && len(cfg.Rules) > 0 {
+		logger.Warn("Chaos fault injection is enabled", zap.Int("rules", len(cfg.Rules)))
+	}
+
+	return &Injector{
+		rules:   append([]Rule(nil), cfg.Rules...),
+		enabled: enabled,
+		logger:  logger,
+		roll:    func() float64 { return rand.Float64() * 100 },
+	}
+}
+
+// Enabled reports whether faults are injected
+func (i *Injector) Enabled() bool {
+	return i.enabled
+}
+
+// Middleware returns middleware injecting the configured faults, it passes every request through
+// untouched when the injector is disabled
+func (i *Injector) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !i.enabled {
+			c.Next()
+			return
+		}
+
+		rule, ok := i.match(c.Request)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		i.logger.Info("Injecting fault",
+			zap.String("rule", rule.Name),
+			zap.String("method", c.Request.Method),
+			zap.String("path", c.Request.URL.Path))
+
+		if rule.Latency > 0 {
+			select {
+			case <-time.After(rule.Latency):
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			}
+		}
+
+		switch {
+		case rule.DropConnection:
+			i.drop(c)
+		case rule.ErrorStatus != 0:
+			c.AbortWithStatus(rule.ErrorStatus)
+		default:
+			c.Next()
+		}
+	}
+}
+
+// match returns the first rule matching the request and selected by its percentage
+func (i *Injector) match(r *http.Request) (Rule, bool) {
+	for _, rule := range i.rules {
+		if rule.Method != "" && !strings.EqualFold(rule.Method, r.Method) {
+			continue
+		}
+		if !strings.HasPrefix(r.URL.Path, rule.PathPrefix) {
+			continue
+		}
+		if i.roll() < rule.Percent {
+			return rule, true
+		}
+	}
+
+	return Rule{}, false
+}
+
+// drop closes the client connection without writing a response
+func (i *Injector) drop(c *gin.Context) {
+	c.Abort()
+
+	conn, _, err := c.Writer.Hijack()
+	if err != nil {
+		// Connections that cannot be hijacked (e.g. HTTP/2) are reset by aborting the handler
+		i.logger.Debug("Failed to hijack connection, aborting handler", zap.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+
+	if err := conn.Close(); err != nil {
+		i.logger.Debug("Failed to close dropped connection", zap.Error(err))
+	}
+}
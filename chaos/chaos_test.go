@@ -0,0 +1,131 @@
+package chaos
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newTestEngine(injector *Injector) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(injector.Middleware())
+	engine.Any("/*path", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return engine
+}
+
+func TestInjector_Middleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		enabled    string
+		rules      []Rule
+		roll       float64
+		method     string
+		path       string
+		wantStatus int
+		wantDelay  time.Duration
+	}{
+		{
+			name:       "disabled without env flag",
+			enabled:    "",
+			rules:      []Rule{{Name: "errors", Percent: 100, ErrorStatus: http.StatusServiceUnavailable}},
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "injects error",
+			enabled:    "true",
+			rules:      []Rule{{Name: "errors", Percent: 100, ErrorStatus: http.StatusServiceUnavailable}},
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "injects latency",
+			enabled:    "1",
+			rules:      []Rule{{Name: "slow", Percent: 100, Latency: 20 * time.Millisecond}},
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+			wantDelay:  20 * time.Millisecond,
+		},
+		{
+			name:       "route does not match",
+			enabled:    "true",
+			rules:      []Rule{{Name: "errors", PathPrefix: "/payments", Percent: 100, ErrorStatus: http.StatusInternalServerError}},
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "method does not match",
+			enabled:    "true",
+			rules:      []Rule{{Name: "errors", Method: http.MethodPost, Percent: 100, ErrorStatus: http.StatusInternalServerError}},
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "outside percentage",
+			enabled:    "true",
+			rules:      []Rule{{Name: "errors", Percent: 10, ErrorStatus: http.StatusInternalServerError}},
+			roll:       50,
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:    "first selected rule wins",
+			enabled: "true",
+			rules: []Rule{
+				{Name: "rare", Percent: 10, ErrorStatus: http.StatusInternalServerError},
+				{Name: "common", Percent: 60, ErrorStatus: http.StatusBadGateway},
+			},
+			roll:       50,
+			method:     http.MethodGet,
+			path:       "/orders",
+			wantStatus: http.StatusBadGateway,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnabledEnv, tt.enabled)
+
+			injector := New(Config{Rules: tt.rules}, zaptest.NewLogger(t))
+			injector.roll = func() float64 { return tt.roll }
+
+			rec := httptest.NewRecorder()
+			start := time.Now()
+			newTestEngine(injector).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.GreaterOrEqual(t, time.Since(start), tt.wantDelay)
+		})
+	}
+}
+
+func TestInjector_DropConnection(t *testing.T) {
+	t.Setenv(EnabledEnv, "true")
+
+	injector := New(Config{Rules: []Rule{{Name: "drop", Percent: 100, DropConnection: true}}}, zaptest.NewLogger(t))
+
+	server := httptest.NewServer(newTestEngine(injector))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/orders")
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	require.Error(t, err)
+}
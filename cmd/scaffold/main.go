@@ -0,0 +1,40 @@
+// Command scaffold generates the skeleton of a new bootstrapper service:
+//
+//	go run github.com/jjmaturino/bootstrapper/cmd/scaffold -module github.com/acme/orders -name orders -dir ./orders
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/jjmaturino/bootstrapper/scaffold"
+)
+
+func main() {
+	module := flag.String("module", "", "Go module path of the new service")
+	name := flag.String("name", "", "service name in lower kebab case")
+	dir := flag.String("dir", "", "output directory, defaults to the service name")
+	version := flag.String("version", "", "bootstrapper version to require, defaults to the version of this command")
+	replace := flag.String("replace", "", "local bootstrapper checkout replacing the required version")
+	flag.Parse()
+
+	if *dir == "" {
+		*dir = *name
+	}
+
+	files, err := scaffold.Generate(*dir, scaffold.Options{
+		Module:  *module,
+		Name:    *name,
+		Version: *version,
+		Replace: *replace,
+	})
+	if err != nil {
+		log.Fatalf("Failed to generate service: %v", err)
+	}
+
+	for _, file := range files {
+		fmt.Println(filepath.Join(*dir, file))
+	}
+}
@@ -0,0 +1,446 @@
+// Package config loads struct-tagged configuration from defaults, an optional YAML or JSON
+// file, environment variables and command line flags, in increasing order of precedence:
+//
+//	type Config struct {
+//		Addr    string        `yaml:"addr" env:"HTTP_ADDR" flag:"addr" default:":8080"`
+//		DSN     string        `yaml:"dsn" env:"DATABASE_URL" required:"true"`
+//		Timeout time.Duration `yaml:"timeout" env:"TIMEOUT" default:"5s"`
+//	}
+//
+//	var cfg Config
+//	err := config.Load(&cfg, config.WithFile("config.yaml"), config.WithArgs(os.Args[1:]))
+//
+// Pass the loaded struct to the launcher so services receive it in Initialize.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Validator is implemented by configuration structs checking their values once loaded
+type Validator interface {
+	// Validate returns an error describing the invalid values
+	Validate() error
+}
+
+// Option configures a Loader
+type Option func(*Loader)
+
+// WithFile loads the YAML (.yaml, .yml) or JSON (.json) file, it is an error when it is missing
+func WithFile(path string) Option {
+	return func(l *Loader) {
+		l.file = path
+	}
+}
+
+// WithEnvPrefix prefixes the environment variable names of the env tags
+func WithEnvPrefix(prefix string) Option {
+	return func(l *Loader) {
+		l.envPrefix = prefix
+	}
+}
+
+// WithArgs parses the command line arguments for the flag tags, usually os.Args[1:]. Flags
+// without a flag tag are skipped along with their value, so the arguments can also carry flags
+// of other flag sets, like the testing flags.
+func WithArgs(args []string) Option {
+	return func(l *Loader) {
+		l.args = args
+	}
+}
+
+// Loader loads configuration from its sources, it can load again to pick up changes
+type Loader struct {
+	file      string
+	envPrefix string
+	args      []string
+
+	// lookupEnv reads environment variables, it is replaced in tests
+	lookupEnv func(key string) (string, bool)
+}
+
+// New creates a loader with the options
+func New(opts ...Option) *Loader {
+	l := &Loader{lookupEnv: os.LookupEnv}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Load populates dst, a pointer to a struct, with a new loader
+func Load(dst interface{}, opts ...Option) error {
+	return New(opts...).Load(dst)
+}
+
+// File returns the path of the configuration file, empty when there is none
+func (l *Loader) File() string {
+	return l.file
+}
+
+// Load populates dst, a pointer to a struct, from the defaults, the file, the environment and
+// the flags, then checks required fields and calls Validate when dst is a Validator
+func (l *Loader) Load(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("config destination must be a pointer to a struct, got %T", dst)
+	}
+
+	// Load into a zero value so fields removed from a source do not keep their previous value
+	loaded := reflect.New(v.Elem().Type())
+	fields := collectFields(loaded.Elem(), "")
+
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok {
+			if err := setValue(f.value, def); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", f.path, err)
+			}
+		}
+	}
+
+	if l.file != "" {
+		if err := l.loadFile(loaded.Interface()); err != nil {
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		name, ok := f.tag.Lookup("env")
+		if !ok {
+			continue
+		}
+		raw, ok := l.lookupEnv(l.envPrefix + name)
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, raw); err != nil {
+			return fmt.Errorf("invalid value for %s%s: %w", l.envPrefix, name, err)
+		}
+	}
+
+	if err := l.parseFlags(fields); err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, f := range fields {
+		if f.tag.Get("required") == "true" && f.value.IsZero() {
+			errs = append(errs, fmt.Errorf("missing required config %s", f.describe(l.envPrefix)))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	if validator, ok := loaded.Interface().(Validator); ok {
+		if err := validator.Validate(); err != nil {
+			return fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	v.Elem().Set(loaded.Elem())
+	return nil
+}
+
+// loadFile decodes the configuration file into dst according to its extension
+func (l *Loader) loadFile(dst interface{}) error {
+	f, err := os.Open(l.file)
+	if err != nil {
+		return fmt.Errorf("failed to open config file: %w", err)
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(l.file)); ext {
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(f).Decode(dst)
+	case ".json":
+		err = decodeJSON(f, dst)
+	default:
+		return fmt.Errorf("unsupported config file extension %q", ext)
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to decode config file %s: %w", l.file, err)
+	}
+
+	return nil
+}
+
+// decodeJSON decodes the JSON document into dst, duration fields accept strings like "5s" as
+// they do in YAML files, numbers are still read as nanoseconds
+func decodeJSON(r io.Reader, dst interface{}) error {
+	var doc interface{}
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return err
+	}
+
+	if err := parseDurations(reflect.TypeOf(dst), doc); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, dst)
+}
+
+// parseDurations replaces, in place, the duration strings of the decoded JSON value by their
+// nanoseconds according to the type it is decoded into
+func parseDurations(t reflect.Type, doc interface{}) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Struct:
+			for key, value := range doc {
+				sf, ok := jsonField(t, key)
+				if !ok {
+					continue
+				}
+				replaced, err := parseDuration(sf.Type, value)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", key, err)
+				}
+				doc[key] = replaced
+			}
+		case reflect.Map:
+			for key, value := range doc {
+				replaced, err := parseDuration(t.Elem(), value)
+				if err != nil {
+					return fmt.Errorf("invalid %s: %w", key, err)
+				}
+				doc[key] = replaced
+			}
+		}
+	case []interface{}:
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return nil
+		}
+		for i, value := range doc {
+			replaced, err := parseDuration(t.Elem(), value)
+			if err != nil {
+				return err
+			}
+			doc[i] = replaced
+		}
+	}
+
+	return nil
+}
+
+// parseDuration returns the nanoseconds of the value when it is a duration string, the value
+// with its nested durations parsed otherwise
+func parseDuration(t reflect.Type, value interface{}) (interface{}, error) {
+	raw, ok := value.(string)
+	if !ok || t != reflect.TypeOf(time.Duration(0)) {
+		return value, parseDurations(t, value)
+	}
+
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return nil, err
+	}
+	return int64(d), nil
+}
+
+// jsonField returns the field the JSON key decodes into, matched like encoding/json does: by
+// its json tag name or its name, preferring an exact match over a case-insensitive one
+func jsonField(t reflect.Type, key string) (reflect.StructField, bool) {
+	var fold reflect.StructField
+	var folded bool
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+
+		if name == key {
+			return sf, true
+		}
+		if !folded && strings.EqualFold(name, key) {
+			fold, folded = sf, true
+		}
+	}
+
+	return fold, folded
+}
+
+// parseFlags sets the fields of the flags present in the arguments
+func (l *Loader) parseFlags(fields []field) error {
+	if l.args == nil {
+		return nil
+	}
+
+	fs := flag.NewFlagSet("config", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	for _, f := range fields {
+		name, ok := f.tag.Lookup("flag")
+		if !ok {
+			continue
+		}
+
+		value := f.value
+		set := func(raw string) error { return setValue(value, raw) }
+		if value.Kind() == reflect.Bool {
+			fs.BoolFunc(name, f.tag.Get("usage"), set)
+		} else {
+			fs.Func(name, f.tag.Get("usage"), set)
+		}
+	}
+
+	if err := fs.Parse(knownArgs(fs, l.args)); err != nil {
+		return fmt.Errorf("invalid flags: %w", err)
+	}
+
+	return nil
+}
+
+// knownArgs returns the flags of args defined in fs with their values. The value of an unknown
+// flag cannot be told apart from an argument, so a non-flag argument following it is skipped too.
+func knownArgs(fs *flag.FlagSet, args []string) []string {
+	var known []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			continue
+		}
+
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			if !hasValue && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+				i++
+			}
+			continue
+		}
+
+		known = append(known, arg)
+		boolFlag, ok := f.Value.(interface{ IsBoolFlag() bool })
+		if hasValue || (ok && boolFlag.IsBoolFlag()) || i+1 == len(args) {
+			continue
+		}
+		i++
+		known = append(known, args[i])
+	}
+	return known
+}
+
+// field is a settable leaf field of the configuration struct
+type field struct {
+	path  string
+	tag   reflect.StructTag
+	value reflect.Value
+}
+
+// describe names the field for error messages, by its environment variable when it has one
+func (f field) describe(envPrefix string) string {
+	if name, ok := f.tag.Lookup("env"); ok {
+		return envPrefix + name
+	}
+	return f.path
+}
+
+// collectFields returns the exported leaf fields of the struct, nested structs are walked
+func collectFields(v reflect.Value, prefix string) []field {
+	var fields []field
+	for i := 0; i < v.NumField(); i++ {
+		sf := v.Type().Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		path := prefix + sf.Name
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			fields = append(fields, collectFields(fv, path+".")...)
+			continue
+		}
+
+		fields = append(fields, field{path: path, tag: sf.Tag, value: fv})
+	}
+
+	return fields
+}
+
+// setValue parses raw into the value according to its type, slices are comma-separated
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(n)
+	case reflect.Slice:
+		var parts []string
+		if raw != "" {
+			parts = strings.Split(raw, ",")
+		}
+		slice := reflect.MakeSlice(v.Type(), len(parts), len(parts))
+		for i, part := range parts {
+			if err := setValue(slice.Index(i), strings.TrimSpace(part)); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		return fmt.Errorf("unsupported config field type %s", v.Type())
+	}
+
+	return nil
+}
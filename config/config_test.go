@@ -0,0 +1,189 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type databaseConfig struct {
+	DSN      string `yaml:"dsn" json:"dsn" env:"DATABASE_URL" required:"true"`
+	MaxConns int    `yaml:"maxConns" json:"maxConns" env:"DATABASE_MAX_CONNS" flag:"db-max-conns" default:"10"`
+}
+
+type testConfig struct {
+	Addr     string         `yaml:"addr" json:"addr" env:"HTTP_ADDR" flag:"addr" default:":8080"`
+	Timeout  time.Duration  `yaml:"timeout" json:"timeout" env:"TIMEOUT" default:"5s"`
+	Debug    bool           `yaml:"debug" json:"debug" env:"DEBUG" flag:"debug"`
+	Origins  []string       `yaml:"origins" json:"origins" env:"ORIGINS"`
+	Ratio    float64        `yaml:"ratio" json:"ratio" default:"0.5"`
+	Database databaseConfig `yaml:"database" json:"database"`
+}
+
+func (c *testConfig) Validate() error {
+	if c.Ratio > 1 {
+		return errors.New("ratio must be at most 1")
+	}
+	return nil
+}
+
+// newLoader returns a loader reading the environment from the map
+func newLoader(env map[string]string, opts ...Option) *Loader {
+	l := New(opts...)
+	l.lookupEnv = func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+	return l
+}
+
+// writeFile writes the content to a file named name in a temporary directory
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoader_Load(t *testing.T) {
+	yamlFile := writeFile(t, "config.yaml", `
+addr: ":7000"
+timeout: 10s
+ratio: 0.25
+database:
+  dsn: postgres://file
+  maxConns: 20
+`)
+
+	t.Run("defaults", func(t *testing.T) {
+		var cfg testConfig
+		err := newLoader(map[string]string{"DATABASE_URL": "postgres://env"}).Load(&cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, testConfig{
+			Addr:     ":8080",
+			Timeout:  5 * time.Second,
+			Ratio:    0.5,
+			Database: databaseConfig{DSN: "postgres://env", MaxConns: 10},
+		}, cfg)
+	})
+
+	t.Run("precedence", func(t *testing.T) {
+		var cfg testConfig
+		err := newLoader(map[string]string{
+			"APP_HTTP_ADDR":          ":7001",
+			"APP_DATABASE_MAX_CONNS": "30",
+			"APP_ORIGINS":            "https://a.example, https://b.example",
+		}, WithFile(yamlFile), WithEnvPrefix("APP_"), WithArgs([]string{"-addr", ":7002", "-debug"})).Load(&cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, testConfig{
+			Addr:     ":7002",
+			Timeout:  10 * time.Second,
+			Debug:    true,
+			Origins:  []string{"https://a.example", "https://b.example"},
+			Ratio:    0.25,
+			Database: databaseConfig{DSN: "postgres://file", MaxConns: 30},
+		}, cfg)
+	})
+
+	t.Run("unknown flags", func(t *testing.T) {
+		var cfg testConfig
+		err := newLoader(map[string]string{"DATABASE_URL": "postgres://env"}, WithArgs([]string{
+			"-test.v", "-test.run", "TestLoad", "-addr", ":7004", "--region=eu", "serve", "-debug",
+		})).Load(&cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, ":7004", cfg.Addr)
+		assert.True(t, cfg.Debug)
+	})
+
+	t.Run("json file", func(t *testing.T) {
+		jsonFile := writeFile(t, "config.json", `{"addr": ":7003", "database": {"dsn": "postgres://json"}}`)
+
+		var cfg testConfig
+		err := newLoader(nil, WithFile(jsonFile)).Load(&cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, ":7003", cfg.Addr)
+		assert.Equal(t, "postgres://json", cfg.Database.DSN)
+		assert.Equal(t, 10, cfg.Database.MaxConns)
+	})
+
+	t.Run("json durations", func(t *testing.T) {
+		for content, expected := range map[string]time.Duration{
+			`{"timeout": "1m30s", "database": {"dsn": "postgres://json"}}`:    90 * time.Second,
+			`{"Timeout": 2000000000, "database": {"dsn": "postgres://json"}}`: 2 * time.Second,
+		} {
+			var cfg testConfig
+			err := newLoader(nil, WithFile(writeFile(t, "config.json", content))).Load(&cfg)
+
+			require.NoError(t, err)
+			assert.Equal(t, expected, cfg.Timeout, content)
+		}
+
+		var cfg testConfig
+		err := newLoader(nil, WithFile(writeFile(t, "config.json", `{"timeout": "soon"}`))).Load(&cfg)
+		assert.ErrorContains(t, err, "invalid timeout")
+	})
+
+	tests := []struct {
+		name        string
+		env         map[string]string
+		opts        []Option
+		expectedErr string
+	}{
+		{
+			name:        "missing required",
+			expectedErr: "missing required config DATABASE_URL",
+		},
+		{
+			name:        "invalid env value",
+			env:         map[string]string{"DATABASE_URL": "postgres://env", "TIMEOUT": "soon"},
+			expectedErr: `invalid value for TIMEOUT: time: invalid duration "soon"`,
+		},
+		{
+			name:        "invalid flag",
+			env:         map[string]string{"DATABASE_URL": "postgres://env"},
+			opts:        []Option{WithArgs([]string{"-db-max-conns", "many"})},
+			expectedErr: `invalid flags: invalid value "many" for flag -db-max-conns: strconv.ParseInt: parsing "many": invalid syntax`,
+		},
+		{
+			name:        "validation",
+			env:         map[string]string{"DATABASE_URL": "postgres://env"},
+			opts:        []Option{WithFile(writeFile(t, "ratio.yml", "ratio: 2"))},
+			expectedErr: "invalid config: ratio must be at most 1",
+		},
+		{
+			name:        "missing file",
+			opts:        []Option{WithFile(filepath.Join(t.TempDir(), "missing.yaml"))},
+			expectedErr: "failed to open config file",
+		},
+		{
+			name:        "unsupported file",
+			opts:        []Option{WithFile(writeFile(t, "config.toml", ""))},
+			expectedErr: `unsupported config file extension ".toml"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := testConfig{Addr: "unchanged"}
+			err := newLoader(tt.env, tt.opts...).Load(&cfg)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+			assert.Equal(t, "unchanged", cfg.Addr)
+		})
+	}
+
+	t.Run("destination must be a struct pointer", func(t *testing.T) {
+		var cfg testConfig
+		assert.EqualError(t, Load(cfg), "config destination must be a pointer to a struct, got config.testConfig")
+	})
+}
@@ -0,0 +1,261 @@
+// Package credentials hashes and verifies passwords with argon2id or bcrypt. Hashes are stored in
+// their standard encodings, so verification picks the algorithm from the hash itself and hashes
+// made with another algorithm or weaker parameters are upgraded on the next successful login:
+//
+//	hasher, err := credentials.New(credentials.Config{Pepper: pepper})
+//	...
+//	upgraded, err := hasher.VerifyAndUpgrade(password, user.PasswordHash)
+//	if err == nil && upgraded != "" {
+//		user.PasswordHash = upgraded
+//	}
+package credentials
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Algorithms of the hashes
+const (
+	Argon2id = "argon2id"
+	Bcrypt   = "bcrypt"
+)
+
+// Default argon2id parameters, the second recommended option of RFC 9106
+const (
+	DefaultArgon2Time    uint32 = 3
+	DefaultArgon2Memory  uint32 = 64 * 1024
+	DefaultArgon2Threads uint8  = 4
+	DefaultKeyLength     uint32 = 32
+	DefaultSaltLength    uint32 = 16
+)
+
+// DefaultBcryptCost is the bcrypt cost when Config has none
+const DefaultBcryptCost = 12
+
+// Verification errors
+var (
+	ErrMismatch         = errors.New("password does not match")
+	ErrUnknownAlgorithm = errors.New("unknown password hash algorithm")
+	ErrInvalidHash      = errors.New("invalid password hash")
+)
+
+// Argon2Params are the argon2id cost parameters, Memory is in KiB
+type Argon2Params struct {
+	Time       uint32
+	Memory     uint32
+	Threads    uint8
+	KeyLength  uint32
+	SaltLength uint32
+}
+
+// Config configures a Hasher, zero values take the defaults
+type Config struct {
+	// Algorithm of new hashes, Argon2id by default
+	Algorithm string
+
+	// Argon2 parameters of new argon2id hashes
+	Argon2 Argon2Params
+
+	// BcryptCost of new bcrypt hashes
+	BcryptCost int
+
+	// Pepper is a secret kept out of the database and mixed into every password with HMAC-SHA256.
+	// Changing it invalidates every stored hash.
+	Pepper []byte
+}
+
+// Hasher hashes and verifies passwords
+type Hasher struct {
+	cfg Config
+}
+
+// New creates a hasher, it fails when the algorithm is unknown
+func New(cfg Config) (*Hasher, error) {
+	if cfg.Algorithm == "" {
+		cfg.Algorithm = Argon2id
+	}
+	if cfg.Algorithm != Argon2id && cfg.Algorithm != Bcrypt {
+		return nil, fmt.Errorf("%w %q", ErrUnknownAlgorithm, cfg.Algorithm)
+	}
+
+	if cfg.Argon2.Time == 0 {
+		cfg.Argon2.Time = DefaultArgon2Time
+	}
+	if cfg.Argon2.Memory == 0 {
+		cfg.Argon2.Memory = DefaultArgon2Memory
+	}
+	if cfg.Argon2.Threads == 0 {
+		cfg.Argon2.Threads = DefaultArgon2Threads
+	}
+	if cfg.Argon2.KeyLength == 0 {
+		cfg.Argon2.KeyLength = DefaultKeyLength
+	}
+	if cfg.Argon2.SaltLength == 0 {
+		cfg.Argon2.SaltLength = DefaultSaltLength
+	}
+	if cfg.BcryptCost == 0 {
+		cfg.BcryptCost = DefaultBcryptCost
+	}
+
+	return &Hasher{cfg: cfg}, nil
+}
+
+// Hash returns the encoded hash of the password with the configured algorithm
+func (h *Hasher) Hash(password string) (string, error) {
+	secret := h.pepper(password)
+
+	if h.cfg.Algorithm == Bcrypt {
+		hash, err := bcrypt.GenerateFromPassword(secret, h.cfg.BcryptCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hash), nil
+	}
+
+	salt := make([]byte, h.cfg.Argon2.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	p := h.cfg.Argon2
+	key := argon2.IDKey(secret, salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.Memory, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify checks the password against an encoded hash of either algorithm in constant time, it
+// returns ErrMismatch when the password is wrong
+func (h *Hasher) Verify(password, encoded string) error {
+	secret := h.pepper(password)
+
+	switch algorithmOf(encoded) {
+	case Bcrypt:
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), secret)
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return ErrMismatch
+		}
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrInvalidHash, err)
+		}
+		return nil
+	case Argon2id:
+		p, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return err
+		}
+		actual := argon2.IDKey(secret, salt, p.Time, p.Memory, p.Threads, p.KeyLength)
+		if subtle.ConstantTimeCompare(actual, key) != 1 {
+			return ErrMismatch
+		}
+		return nil
+	default:
+		return ErrUnknownAlgorithm
+	}
+}
+
+// NeedsRehash reports whether the encoded hash uses another algorithm or other parameters than
+// new hashes
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	switch algorithmOf(encoded) {
+	case Bcrypt:
+		if h.cfg.Algorithm != Bcrypt {
+			return true
+		}
+		cost, err := bcrypt.Cost([]byte(encoded))
+		return err != nil || cost != h.cfg.BcryptCost
+	case Argon2id:
+		if h.cfg.Algorithm != Argon2id {
+			return true
+		}
+		p, _, _, err := decodeArgon2(encoded)
+		return err != nil || p != h.cfg.Argon2
+	default:
+		return true
+	}
+}
+
+// VerifyAndUpgrade verifies the password and, when the hash needs a rehash, returns a new hash
+// to store in its place. The returned hash is empty when the stored one is up to date.
+func (h *Hasher) VerifyAndUpgrade(password, encoded string) (string, error) {
+	if err := h.Verify(password, encoded); err != nil {
+		return "", err
+	}
+	if !h.NeedsRehash(encoded) {
+		return "", nil
+	}
+
+	return h.Hash(password)
+}
+
+// pepper mixes the pepper into the password. The HMAC is base64 encoded so bcrypt, which stops at
+// NUL bytes and 72 bytes, sees all of it.
+func (h *Hasher) pepper(password string) []byte {
+	if len(h.cfg.Pepper) == 0 {
+		return []byte(password)
+	}
+
+	mac := hmac.New(sha256.New, h.cfg.Pepper)
+	mac.Write([]byte(password))
+	return []byte(base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+// algorithmOf returns the algorithm of an encoded hash
+func algorithmOf(encoded string) string {
+	switch {
+	case strings.HasPrefix(encoded, "$argon2id$"):
+		return Argon2id
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		return Bcrypt
+	default:
+		return ""
+	}
+}
+
+// decodeArgon2 parses "$argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<key>"
+func decodeArgon2(encoded string) (Argon2Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	if version != argon2.Version {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: unsupported argon2 version %d", ErrInvalidHash, version)
+	}
+
+	var p Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Time, &p.Threads); err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2Params{}, nil, nil, fmt.Errorf("%w: %w", ErrInvalidHash, err)
+	}
+	// An empty key would match any password and argon2 panics without threads
+	if len(salt) == 0 || len(key) == 0 || p.Time == 0 || p.Threads == 0 {
+		return Argon2Params{}, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength, p.KeyLength = uint32(len(salt)), uint32(len(key))
+
+	return p, salt, key, nil
+}
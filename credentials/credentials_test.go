@@ -0,0 +1,118 @@
+package credentials
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fastArgon2 keeps the tests quick
+var fastArgon2 = Argon2Params{Time: 1, Memory: 1024, Threads: 1}
+
+func newHasher(t *testing.T, cfg Config) *Hasher {
+	h, err := New(cfg)
+	require.NoError(t, err)
+	return h
+}
+
+func TestHasher_HashAndVerify(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{name: "argon2id", cfg: Config{Argon2: fastArgon2}},
+		{name: "argon2id with pepper", cfg: Config{Argon2: fastArgon2, Pepper: []byte("pepper")}},
+		{name: "bcrypt", cfg: Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost}},
+		{name: "bcrypt with pepper", cfg: Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost, Pepper: []byte("pepper")}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			h := newHasher(t, tt.cfg)
+
+			hash, err := h.Hash("correct horse")
+			require.NoError(t, err)
+			assert.NotContains(t, hash, "correct horse")
+
+			assert.NoError(t, h.Verify("correct horse", hash))
+			assert.ErrorIs(t, h.Verify("wrong horse", hash), ErrMismatch)
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestHasher_HashIsSalted(t *testing.T) {
+	h := newHasher(t, Config{Argon2: fastArgon2})
+
+	first, err := h.Hash("secret")
+	require.NoError(t, err)
+	second, err := h.Hash("secret")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, first, second)
+	assert.True(t, strings.HasPrefix(first, "$argon2id$v=19$m=1024,t=1,p=1$"), first)
+}
+
+func TestHasher_Pepper(t *testing.T) {
+	peppered := newHasher(t, Config{Argon2: fastArgon2, Pepper: []byte("pepper")})
+	other := newHasher(t, Config{Argon2: fastArgon2, Pepper: []byte("other")})
+
+	hash, err := peppered.Hash("secret")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, other.Verify("secret", hash), ErrMismatch)
+}
+
+func TestHasher_BcryptLongPasswordWithPepper(t *testing.T) {
+	h := newHasher(t, Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost, Pepper: []byte("pepper")})
+	long := strings.Repeat("a", 100)
+
+	hash, err := h.Hash(long)
+	require.NoError(t, err)
+
+	// Passwords differing after 72 bytes still differ once peppered
+	assert.ErrorIs(t, h.Verify(long+"b", hash), ErrMismatch)
+}
+
+func TestHasher_VerifyAndUpgrade(t *testing.T) {
+	legacy := newHasher(t, Config{Algorithm: Bcrypt, BcryptCost: bcrypt.MinCost})
+	current := newHasher(t, Config{Argon2: fastArgon2})
+
+	hash, err := legacy.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, current.NeedsRehash(hash))
+
+	_, err = current.VerifyAndUpgrade("wrong", hash)
+	assert.ErrorIs(t, err, ErrMismatch)
+
+	upgraded, err := current.VerifyAndUpgrade("secret", hash)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(upgraded, "$argon2id$"), upgraded)
+	assert.NoError(t, current.Verify("secret", upgraded))
+
+	unchanged, err := current.VerifyAndUpgrade("secret", upgraded)
+	require.NoError(t, err)
+	assert.Empty(t, unchanged)
+
+	stronger := newHasher(t, Config{Argon2: Argon2Params{Time: 2, Memory: 1024, Threads: 1}})
+	assert.True(t, stronger.NeedsRehash(upgraded))
+}
+
+func TestHasher_InvalidHashes(t *testing.T) {
+	h := newHasher(t, Config{Argon2: fastArgon2})
+
+	assert.ErrorIs(t, h.Verify("secret", "plaintext"), ErrUnknownAlgorithm)
+	assert.ErrorIs(t, h.Verify("secret", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA"), ErrInvalidHash)
+	assert.ErrorIs(t, h.Verify("", "$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$"), ErrInvalidHash)
+	assert.ErrorIs(t, h.Verify("secret", "$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5"), ErrInvalidHash)
+	assert.True(t, h.NeedsRehash("plaintext"))
+}
+
+func TestNew_UnknownAlgorithm(t *testing.T) {
+	_, err := New(Config{Algorithm: "md5"})
+	assert.ErrorIs(t, err, ErrUnknownAlgorithm)
+}
@@ -0,0 +1,335 @@
+// Package envelope encrypts sensitive values at rest with envelope encryption: values are
+// encrypted with AES-256-GCM data keys, and data keys are wrapped by a master key held in a KMS
+// or managed locally. Rotating the master key only rewraps the data keys, values are not
+// re-encrypted.
+//
+//	master, err := envelope.NewLocalKey("2024-06", key)
+//	enc := envelope.New(master)
+//	column, err := enc.EncryptField(ctx, ssn, "users.ssn", userID)
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// DefaultDataKeyUses is how many values are encrypted with a data key before a new one is
+// generated, it bounds the master key calls while keeping random nonces far from collisions
+const DefaultDataKeyUses = 4096
+
+// dataKeyCacheSize bounds the unwrapped data keys kept for decryption
+const dataKeyCacheSize = 1024
+
+// dataKeySize is the size of AES-256 keys
+const dataKeySize = 32
+
+// version is the first byte of the ciphertext format:
+//
+//	version | master ID length (1) | master ID | wrapped key length (2) | wrapped key | nonce | sealed
+const version byte = 1
+
+// Decryption errors
+var (
+	ErrInvalidCiphertext = errors.New("invalid ciphertext")
+	ErrUnknownMasterKey  = errors.New("unknown master key")
+)
+
+// MasterKey wraps data keys, implementations call a KMS or use a local key
+type MasterKey interface {
+	// ID identifies the key in ciphertexts, it must be stable and at most 255 bytes
+	ID() string
+
+	// Wrap encrypts a data key
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+
+	// Unwrap decrypts a data key wrapped by this key
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// dataKey is a data key with its wrapped form
+type dataKey struct {
+	aead    cipher.AEAD
+	wrapped []byte
+	uses    int
+}
+
+// Encrypter encrypts values with data keys wrapped by its current master key, and decrypts
+// values whose data keys were wrapped by any of its master keys
+type Encrypter struct {
+	// mu protects current, masters, data and cache
+	mu      sync.Mutex
+	current MasterKey
+	masters map[string]MasterKey
+	data    *dataKey
+	cache   map[string]cipher.AEAD
+
+	// maxUses is the number of values encrypted with a data key
+	maxUses int
+}
+
+// New creates an encrypter wrapping data keys with current, previous master keys only decrypt
+func New(current MasterKey, previous ...MasterKey) *Encrypter {
+	e := &Encrypter{
+		current: current,
+		masters: map[string]MasterKey{current.ID(): current},
+		cache:   make(map[string]cipher.AEAD),
+		maxUses: DefaultDataKeyUses,
+	}
+	for _, master := range previous {
+		e.masters[master.ID()] = master
+	}
+	return e
+}
+
+// Rotate makes the master key the current one, the previous current key still decrypts
+func (e *Encrypter) Rotate(master MasterKey) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.current = master
+	e.masters[master.ID()] = master
+	e.data = nil
+}
+
+// Encrypt encrypts the plaintext, the associated data is authenticated but not stored and must be
+// passed again to Decrypt
+func (e *Encrypter) Encrypt(ctx context.Context, plaintext, associatedData []byte) ([]byte, error) {
+	master, key, err := e.dataKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, key.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := header(master.ID(), key.wrapped)
+	out = append(out, nonce...)
+	return key.aead.Seal(out, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt decrypts a ciphertext of Encrypt with the same associated data
+func (e *Encrypter) Decrypt(ctx context.Context, ciphertext, associatedData []byte) ([]byte, error) {
+	masterID, wrapped, rest, err := parse(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := e.unwrap(ctx, masterID, wrapped)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rest) < aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := rest[:aead.NonceSize()], rest[aead.NonceSize():]
+
+	plaintext, err := aead.Open(nil, nonce, sealed, associatedData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+	return plaintext, nil
+}
+
+// NeedsRewrap reports whether the data key of the ciphertext is wrapped by another master key
+// than the current one
+func (e *Encrypter) NeedsRewrap(ciphertext []byte) bool {
+	masterID, _, _, err := parse(ciphertext)
+	if err != nil {
+		return false
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return masterID != e.current.ID()
+}
+
+// Rewrap wraps the data key of the ciphertext with the current master key, the encrypted value is
+// kept as is. Run it over stored values after Rotate, then retire the previous master key.
+func (e *Encrypter) Rewrap(ctx context.Context, ciphertext []byte) ([]byte, error) {
+	masterID, wrapped, rest, err := parse(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	previous, ok := e.masters[masterID]
+	current := e.current
+	e.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMasterKey, masterID)
+	}
+	if masterID == current.ID() {
+		return ciphertext, nil
+	}
+
+	plainKey, err := previous.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	rewrapped, err := current.Wrap(ctx, plainKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	return append(header(current.ID(), rewrapped), rest...), nil
+}
+
+// EncryptField encrypts a column value bound to its field and row, so a ciphertext copied to
+// another column or row does not decrypt. The result is base64 encoded for text columns.
+func (e *Encrypter) EncryptField(ctx context.Context, value, field, rowID string) (string, error) {
+	ciphertext, err := e.Encrypt(ctx, []byte(value), fieldData(field, rowID))
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ciphertext), nil
+}
+
+// DecryptField decrypts a column value of EncryptField
+func (e *Encrypter) DecryptField(ctx context.Context, encoded, field, rowID string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+
+	plaintext, err := e.Decrypt(ctx, ciphertext, fieldData(field, rowID))
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// RewrapField rewraps a column value of EncryptField with the current master key
+func (e *Encrypter) RewrapField(ctx context.Context, encoded string) (string, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrInvalidCiphertext, err)
+	}
+
+	rewrapped, err := e.Rewrap(ctx, ciphertext)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(rewrapped), nil
+}
+
+// dataKey returns the current master key and a data key wrapped by it, generating a new data key
+// once the current one was used maxUses times
+func (e *Encrypter) dataKey(ctx context.Context) (MasterKey, *dataKey, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.data == nil || e.data.uses >= e.maxUses {
+		if id := e.current.ID(); id == "" || len(id) > 255 {
+			return nil, nil, fmt.Errorf("master key ID must be between 1 and 255 bytes, got %q", id)
+		}
+
+		plainKey := make([]byte, dataKeySize)
+		if _, err := io.ReadFull(rand.Reader, plainKey); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate data key: %w", err)
+		}
+		wrapped, err := e.current.Wrap(ctx, plainKey)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to wrap data key: %w", err)
+		}
+		aead, err := newAEAD(plainKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		e.data = &dataKey{aead: aead, wrapped: wrapped}
+	}
+
+	e.data.uses++
+	return e.current, e.data, nil
+}
+
+// unwrap returns the cipher of a wrapped data key, unwrapped keys are cached
+func (e *Encrypter) unwrap(ctx context.Context, masterID string, wrapped []byte) (cipher.AEAD, error) {
+	cacheKey := masterID + "\x00" + string(wrapped)
+
+	e.mu.Lock()
+	aead, ok := e.cache[cacheKey]
+	master, known := e.masters[masterID]
+	e.mu.Unlock()
+	if ok {
+		return aead, nil
+	}
+	if !known {
+		return nil, fmt.Errorf("%w %q", ErrUnknownMasterKey, masterID)
+	}
+
+	plainKey, err := master.Unwrap(ctx, wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	aead, err = newAEAD(plainKey)
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	if len(e.cache) >= dataKeyCacheSize {
+		clear(e.cache)
+	}
+	e.cache[cacheKey] = aead
+	e.mu.Unlock()
+
+	return aead, nil
+}
+
+// header encodes the master key ID and the wrapped data key
+func header(masterID string, wrapped []byte) []byte {
+	out := make([]byte, 0, 4+len(masterID)+len(wrapped))
+	out = append(out, version, byte(len(masterID)))
+	out = append(out, masterID...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(wrapped)))
+	return append(out, wrapped...)
+}
+
+// parse splits a ciphertext into the master key ID, the wrapped data key and the nonce with the
+// sealed value
+func parse(ciphertext []byte) (string, []byte, []byte, error) {
+	if len(ciphertext) < 2 || ciphertext[0] != version {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+
+	idLen := int(ciphertext[1])
+	rest := ciphertext[2:]
+	if len(rest) < idLen+2 {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+	masterID, rest := string(rest[:idLen]), rest[idLen:]
+
+	wrappedLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < wrappedLen {
+		return "", nil, nil, ErrInvalidCiphertext
+	}
+
+	return masterID, rest[:wrappedLen], rest[wrappedLen:], nil
+}
+
+// fieldData returns the associated data binding a value to its field and row
+func fieldData(field, rowID string) []byte {
+	return []byte(strings.Join([]string{field, rowID}, "\x00"))
+}
+
+// newAEAD returns AES-GCM with the key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid data key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
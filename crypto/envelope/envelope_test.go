@@ -0,0 +1,176 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingKey counts the calls to a local key, like a KMS bill would
+type countingKey struct {
+	*LocalKey
+	wraps   atomic.Int32
+	unwraps atomic.Int32
+}
+
+func (k *countingKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	k.wraps.Add(1)
+	return k.LocalKey.Wrap(ctx, dataKey)
+}
+
+func (k *countingKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	k.unwraps.Add(1)
+	return k.LocalKey.Unwrap(ctx, wrapped)
+}
+
+func newLocalKey(t *testing.T, id string) *LocalKey {
+	key, err := NewLocalKey(id, bytes.Repeat([]byte(id[:1]), 32))
+	require.NoError(t, err)
+	return key
+}
+
+func newCountingKey(t *testing.T, id string) *countingKey {
+	return &countingKey{LocalKey: newLocalKey(t, id)}
+}
+
+func TestEncrypter_EncryptDecrypt(t *testing.T) {
+	ctx := context.Background()
+	enc := New(newLocalKey(t, "k1"))
+
+	ciphertext, err := enc.Encrypt(ctx, []byte("4111 1111 1111 1111"), []byte("cards.number"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), "4111")
+
+	plaintext, err := enc.Decrypt(ctx, ciphertext, []byte("cards.number"))
+	require.NoError(t, err)
+	assert.Equal(t, "4111 1111 1111 1111", string(plaintext))
+
+	_, err = enc.Decrypt(ctx, ciphertext, []byte("cards.cvv"))
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = enc.Decrypt(ctx, tampered, []byte("cards.number"))
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	for _, invalid := range [][]byte{nil, {2}, {version, 10, 'k'}, ciphertext[:10]} {
+		_, err = enc.Decrypt(ctx, invalid, nil)
+		assert.ErrorIs(t, err, ErrInvalidCiphertext)
+	}
+}
+
+func TestEncrypter_DataKeyReuse(t *testing.T) {
+	ctx := context.Background()
+	master := newCountingKey(t, "k1")
+	enc := New(master)
+	enc.maxUses = 3
+
+	var ciphertexts [][]byte
+	for i := 0; i < 4; i++ {
+		ciphertext, err := enc.Encrypt(ctx, []byte("value"), nil)
+		require.NoError(t, err)
+		ciphertexts = append(ciphertexts, ciphertext)
+	}
+
+	// A new data key is wrapped after maxUses values
+	assert.Equal(t, int32(2), master.wraps.Load())
+
+	// Unwrapped data keys are cached across decryptions
+	decrypter := New(master)
+	for _, ciphertext := range ciphertexts {
+		_, err := decrypter.Decrypt(ctx, ciphertext, nil)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(2), master.unwraps.Load())
+}
+
+func TestEncrypter_Rotation(t *testing.T) {
+	ctx := context.Background()
+	old, current := newLocalKey(t, "k1"), newLocalKey(t, "k2")
+
+	enc := New(old)
+	ciphertext, err := enc.Encrypt(ctx, []byte("secret"), nil)
+	require.NoError(t, err)
+	assert.False(t, enc.NeedsRewrap(ciphertext))
+
+	enc.Rotate(current)
+	assert.True(t, enc.NeedsRewrap(ciphertext))
+
+	fresh, err := enc.Encrypt(ctx, []byte("fresh"), nil)
+	require.NoError(t, err)
+	assert.False(t, enc.NeedsRewrap(fresh))
+
+	rewrapped, err := enc.Rewrap(ctx, ciphertext)
+	require.NoError(t, err)
+	assert.False(t, enc.NeedsRewrap(rewrapped))
+
+	// Once rewrapped, values decrypt without the previous master key
+	plaintext, err := New(current).Decrypt(ctx, rewrapped, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	_, err = New(current).Decrypt(ctx, ciphertext, nil)
+	assert.ErrorIs(t, err, ErrUnknownMasterKey)
+
+	// Previous keys passed to New still decrypt
+	plaintext, err = New(current, old).Decrypt(ctx, ciphertext, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(plaintext))
+
+	same, err := enc.Rewrap(ctx, rewrapped)
+	require.NoError(t, err)
+	assert.Equal(t, rewrapped, same)
+}
+
+func TestEncrypter_Fields(t *testing.T) {
+	ctx := context.Background()
+	enc := New(newLocalKey(t, "k1"))
+
+	column, err := enc.EncryptField(ctx, "123-45-6789", "users.ssn", "42")
+	require.NoError(t, err)
+
+	value, err := enc.DecryptField(ctx, column, "users.ssn", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", value)
+
+	// A value copied to another row does not decrypt
+	_, err = enc.DecryptField(ctx, column, "users.ssn", "43")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	_, err = enc.DecryptField(ctx, "not base64!", "users.ssn", "42")
+	assert.ErrorIs(t, err, ErrInvalidCiphertext)
+
+	enc.Rotate(newLocalKey(t, "k2"))
+	rewrapped, err := enc.RewrapField(ctx, column)
+	require.NoError(t, err)
+	value, err = enc.DecryptField(ctx, rewrapped, "users.ssn", "42")
+	require.NoError(t, err)
+	assert.Equal(t, "123-45-6789", value)
+}
+
+// failingKey fails to wrap, like an unreachable KMS
+type failingKey struct{ *LocalKey }
+
+func (k failingKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	return nil, errors.New("kms unavailable")
+}
+
+func TestEncrypter_WrapError(t *testing.T) {
+	enc := New(failingKey{newLocalKey(t, "k1")})
+
+	_, err := enc.Encrypt(context.Background(), []byte("secret"), nil)
+	assert.EqualError(t, err, "failed to wrap data key: kms unavailable")
+}
+
+func TestNewLocalKey(t *testing.T) {
+	_, err := NewLocalKey("k1", []byte("short"))
+	assert.Error(t, err)
+
+	_, err = NewLocalKey("", make([]byte, 32))
+	assert.Error(t, err)
+}
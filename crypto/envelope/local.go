@@ -0,0 +1,58 @@
+package envelope
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LocalKey is a master key held by the service, from a secret manager or the environment, for
+// services without a KMS
+type LocalKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalKey creates a master key from a 32 byte AES-256 key
+func NewLocalKey(id string, key []byte) (*LocalKey, error) {
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("local master key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	if id == "" || len(id) > 255 {
+		return nil, errors.New("local master key ID must be between 1 and 255 bytes")
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalKey{id: id, aead: aead}, nil
+}
+
+// ID identifies the key in ciphertexts
+func (k *LocalKey) ID() string {
+	return k.id
+}
+
+// Wrap encrypts a data key with AES-GCM, the nonce is prepended
+func (k *LocalKey) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return k.aead.Seal(nonce, nonce, dataKey, []byte(k.id)), nil
+}
+
+// Unwrap decrypts a data key wrapped by Wrap
+func (k *LocalKey) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < k.aead.NonceSize() {
+		return nil, ErrInvalidCiphertext
+	}
+	nonce, sealed := wrapped[:k.aead.NonceSize()], wrapped[k.aead.NonceSize():]
+	return k.aead.Open(nil, nonce, sealed, []byte(k.id))
+}
+
+var _ MasterKey = (*LocalKey)(nil)
@@ -0,0 +1,173 @@
+package dbtx
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// txKey is the context key holding the request transaction
+type txKey struct{}
+
+// Beginner starts transactions, it is satisfied by *sql.DB and *sql.Conn
+type Beginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Middleware opens a transaction per request and exposes it through the request context.
+// The transaction is committed when the handler chain finishes with a 2xx status and no gin
+// errors, and rolled back otherwise, including when a handler panics. Apply it to the route
+// groups that need it rather than the whole engine.
+//
+// The response is buffered until the commit outcome is known, so a failed commit reaches the
+// client as a 500 instead of the response the handlers wrote. Streaming responses are therefore
+// not suited to routes using it.
+func Middleware(db Beginner, opts *sql.TxOptions, logger *zap.Logger) gin.HandlerFunc {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	return func(c *gin.Context) {
+		tx, err := db.BeginTx(c.Request.Context(), opts)
+		if err != nil {
+			logger.Error("Failed to begin transaction", zap.Error(err))
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		c.Request = c.Request.WithContext(WithTx(c.Request.Context(), tx))
+
+		writer := c.Writer
+		header := writer.Header().Clone()
+		buffered := &bufferedWriter{ResponseWriter: writer}
+		c.Writer = buffered
+
+		// Roll back and re-panic so recovery middleware still sees the panic and can respond
+		defer func() {
+			c.Writer = writer
+			if r := recover(); r != nil {
+				rollback(logger, tx)
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		status := buffered.Status()
+		if len(c.Errors) > 0 || status < http.StatusOK || status >= http.StatusMultipleChoices {
+			rollback(logger, tx)
+			buffered.flush()
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			logger.Error("Failed to commit transaction", zap.Error(err))
+
+			// Discard the response of the handlers, headers included
+			for key := range writer.Header() {
+				delete(writer.Header(), key)
+			}
+			for key, values := range header {
+				writer.Header()[key] = values
+			}
+			c.Writer = writer
+			c.AbortWithStatus(http.StatusInternalServerError)
+			return
+		}
+
+		buffered.flush()
+	}
+}
+
+// bufferedWriter holds the status and body written by the handlers until flush
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status  int
+	written bool
+	body    bytes.Buffer
+}
+
+// WriteHeader records the status, it is sent on flush
+func (w *bufferedWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+// WriteHeaderNow marks the headers as written, they are sent on flush
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.written = true
+}
+
+// Write buffers the body
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	w.written = true
+	return w.body.Write(data)
+}
+
+// WriteString buffers the body
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	w.written = true
+	return w.body.WriteString(s)
+}
+
+// Status returns the recorded status, 200 when none was set
+func (w *bufferedWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Size returns the number of buffered body bytes, -1 when nothing was written
+func (w *bufferedWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+// Written reports whether the handlers wrote a response
+func (w *bufferedWriter) Written() bool {
+	return w.written
+}
+
+// Flush is deferred to flush, the response is only sent once the transaction is finished
+func (w *bufferedWriter) Flush() {}
+
+// flush sends the buffered response
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.Status())
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	_, _ = w.ResponseWriter.Write(w.body.Bytes())
+}
+
+// WithTx returns a copy of the context carrying the transaction
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// FromContext returns the request transaction opened by the middleware
+func FromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return tx, ok
+}
+
+// rollback rolls back the transaction, logging failures other than an already finished transaction
+func rollback(logger *zap.Logger, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+		logger.Error("Failed to roll back transaction", zap.Error(err))
+	}
+}
@@ -0,0 +1,178 @@
+package dbtx
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// recordingDriver is a database/sql driver recording transaction outcomes
+type recordingDriver struct {
+	mu        sync.Mutex
+	commits   int
+	rollbacks int
+
+	// commitErr fails commits when set
+	commitErr error
+}
+
+func (d *recordingDriver) Open(name string) (driver.Conn, error) { return &recordingConn{d: d}, nil }
+
+type recordingConn struct{ d *recordingDriver }
+
+func (c *recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("not implemented")
+}
+func (c *recordingConn) Close() error              { return nil }
+func (c *recordingConn) Begin() (driver.Tx, error) { return &recordingTx{d: c.d}, nil }
+
+type recordingTx struct{ d *recordingDriver }
+
+func (t *recordingTx) Commit() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	t.d.commits++
+	return t.d.commitErr
+}
+
+func (t *recordingTx) Rollback() error {
+	t.d.mu.Lock()
+	defer t.d.mu.Unlock()
+	t.d.rollbacks++
+	return nil
+}
+
+var (
+	driverOnce sync.Once
+	testDriver = &recordingDriver{}
+)
+
+func openTestDB(t *testing.T) (*sql.DB, *recordingDriver) {
+	driverOnce.Do(func() { sql.Register("dbtx-recording", testDriver) })
+
+	db, err := sql.Open("dbtx-recording", "")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	testDriver.mu.Lock()
+	testDriver.commits, testDriver.rollbacks, testDriver.commitErr = 0, 0, nil
+	testDriver.mu.Unlock()
+	return db, testDriver
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		handler       gin.HandlerFunc
+		wantCommits   int
+		wantRollbacks int
+	}{
+		{
+			name:        "commits on 2xx",
+			handler:     func(c *gin.Context) { c.Status(http.StatusCreated) },
+			wantCommits: 1,
+		},
+		{
+			name:          "rolls back on error status",
+			handler:       func(c *gin.Context) { c.Status(http.StatusConflict) },
+			wantRollbacks: 1,
+		},
+		{
+			name: "rolls back on gin error",
+			handler: func(c *gin.Context) {
+				_ = c.Error(errors.New("boom"))
+				c.Status(http.StatusOK)
+			},
+			wantRollbacks: 1,
+		},
+		{
+			name:          "rolls back on panic",
+			handler:       func(c *gin.Context) { panic("boom") },
+			wantRollbacks: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := openTestDB(t)
+
+			var sawTx bool
+			engine := gin.New()
+			engine.Use(gin.Recovery())
+			engine.POST("/orders", Middleware(db, nil, zaptest.NewLogger(t)), func(c *gin.Context) {
+				_, sawTx = FromContext(c.Request.Context())
+				tt.handler(c)
+			})
+
+			engine.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+			assert.True(t, sawTx)
+			assert.Equal(t, tt.wantCommits, d.commits)
+			assert.Equal(t, tt.wantRollbacks, d.rollbacks)
+		})
+	}
+}
+
+func TestMiddlewareResponse(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name        string
+		commitErr   error
+		wantStatus  int
+		wantBody    string
+		wantHeaders http.Header
+	}{
+		{
+			name:        "sends the response once committed",
+			wantStatus:  http.StatusCreated,
+			wantBody:    `{"id":"42"}`,
+			wantHeaders: http.Header{"Content-Type": {"application/json; charset=utf-8"}, "X-Order": {"42"}},
+		},
+		{
+			name:        "replaces the response when the commit fails",
+			commitErr:   errors.New("serialization failure"),
+			wantStatus:  http.StatusInternalServerError,
+			wantHeaders: http.Header{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, d := openTestDB(t)
+			d.commitErr = tt.commitErr
+
+			engine := gin.New()
+			engine.POST("/orders", Middleware(db, nil, zaptest.NewLogger(t)), func(c *gin.Context) {
+				c.Header("X-Order", "42")
+				c.JSON(http.StatusCreated, gin.H{"id": "42"})
+			})
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantBody, rec.Body.String())
+			assert.Equal(t, tt.wantHeaders, rec.Header())
+			assert.Equal(t, 1, d.commits)
+		})
+	}
+}
+
+func TestFromContextWithoutTx(t *testing.T) {
+	tx, ok := FromContext(httptest.NewRequest(http.MethodGet, "/", nil).Context())
+
+	assert.False(t, ok)
+	assert.Nil(t, tx)
+}
@@ -0,0 +1,80 @@
+package deploy
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ServedByHeader identifies the deployment that served a response
+const ServedByHeader = "X-Served-By"
+
+// Environment variables read by IdentityFromEnv, typically set by the deployment pipeline
+const (
+	ColorEnv   = "DEPLOY_COLOR"
+	SlotEnv    = "DEPLOY_SLOT"
+	VersionEnv = "DEPLOY_VERSION"
+)
+
+// Identity describes the running deployment, it can be passed as a dependency so services report
+// which color or slot they run in during blue/green cutovers
+type Identity struct {
+	Service   string    `json:"service"`
+	Version   string    `json:"version,omitempty"`
+	Color     string    `json:"color,omitempty"`
+	Slot      string    `json:"slot,omitempty"`
+	Host      string    `json:"host,omitempty"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+// IdentityFromEnv builds the identity of the named service from the environment, StartedAt is
+// set to the current time
+func IdentityFromEnv(service string) Identity {
+	host, _ := os.Hostname()
+
+	return Identity{
+		Service:   service,
+		Version:   os.Getenv(VersionEnv),
+		Color:     os.Getenv(ColorEnv),
+		Slot:      os.Getenv(SlotEnv),
+		Host:      host,
+		StartedAt: time.Now().UTC(),
+	}
+}
+
+// ServedBy formats the identity as the X-Served-By header value, e.g. "orders/1.4.2/blue/a/host-1",
+// leaving out unset parts
+func (id Identity) ServedBy() string {
+	parts := make([]string, 0, 5)
+	for _, part := range []string{id.Service, id.Version, id.Color, id.Slot, id.Host} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "/")
+}
+
+// ServedBy returns middleware setting the X-Served-By header on every response, it passes
+// requests through untouched when disabled so the header can be toggled by configuration
+func ServedBy(id Identity, enabled bool) gin.HandlerFunc {
+	value := id.ServedBy()
+
+	return func(c *gin.Context) {
+		if enabled {
+			c.Header(ServedByHeader, value)
+		}
+		c.Next()
+	}
+}
+
+// Handler returns a handler reporting the deployment identity, meant for an admin route used to
+// verify which deployment is live
+func Handler(id Identity) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, id)
+	}
+}
@@ -0,0 +1,77 @@
+package deploy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentityFromEnv(t *testing.T) {
+	t.Setenv(ColorEnv, "blue")
+	t.Setenv(SlotEnv, "a")
+	t.Setenv(VersionEnv, "1.4.2")
+
+	id := IdentityFromEnv("orders")
+
+	assert.Equal(t, "orders", id.Service)
+	assert.Equal(t, "1.4.2", id.Version)
+	assert.Equal(t, "blue", id.Color)
+	assert.Equal(t, "a", id.Slot)
+	assert.WithinDuration(t, time.Now(), id.StartedAt, time.Minute)
+}
+
+func TestIdentity_ServedBy(t *testing.T) {
+	assert.Equal(t, "orders/1.4.2/blue/a/host-1", Identity{Service: "orders", Version: "1.4.2", Color: "blue", Slot: "a", Host: "host-1"}.ServedBy())
+	assert.Equal(t, "orders/green", Identity{Service: "orders", Color: "green"}.ServedBy())
+}
+
+func TestServedBy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := Identity{Service: "orders", Color: "blue"}
+
+	tests := []struct {
+		name    string
+		enabled bool
+		want    string
+	}{
+		{name: "enabled", enabled: true, want: "orders/blue"},
+		{name: "disabled", enabled: false, want: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(ServedBy(id, tt.enabled))
+			engine.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders", nil))
+
+			assert.Equal(t, tt.want, rec.Header().Get(ServedByHeader))
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := Identity{Service: "orders", Version: "1.4.2", Color: "blue", StartedAt: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	engine := gin.New()
+	engine.GET("/admin/deploy", Handler(id))
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/deploy", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var got Identity
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, id, got)
+}
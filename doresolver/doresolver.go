@@ -0,0 +1,50 @@
+package doresolver
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/samber/do"
+)
+
+// Resolver adapts a samber/do injector to the launcher. Each started service gets its own scope,
+// a clone of the injector holding the same providers but its own instances, passed to
+// Service.Initialize as a *do.Injector:
+//
+//	injector := do.New()
+//	do.Provide(injector, NewRepository)
+//	launcher.UseResolver(doresolver.New(injector))
+//
+//	func (s *MyService) Initialize(ctx context.Context, deps ...interface{}) error {
+//		injector := platform.MustDep[*do.Injector](deps...)
+//		s.repo = do.MustInvoke[*Repository](injector)
+//		...
+//	}
+//
+// The scope is shut down when the service stops, so instances implementing do.Shutdownable are
+// shut down in reverse invocation order.
+type Resolver struct {
+	injector *do.Injector
+}
+
+// New creates a resolver scoping services from the injector
+func New(injector *do.Injector) *Resolver {
+	return &Resolver{injector: injector}
+}
+
+// Resolve returns a new scope of the injector for the service, released by shutting it down
+func (r *Resolver) Resolve(ctx context.Context, service platform.Service) ([]interface{}, func(ctx context.Context) error, error) {
+	scope := r.injector.Clone()
+
+	release := func(ctx context.Context) error {
+		if err := scope.Shutdown(); err != nil {
+			return fmt.Errorf("failed to shut down injector scope: %w", err)
+		}
+		return nil
+	}
+
+	return []interface{}{scope}, release, nil
+}
+
+var _ platform.DependencyResolver = (*Resolver)(nil)
@@ -0,0 +1,91 @@
+package doresolver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/starter"
+	"github.com/samber/do"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type repository struct {
+	closed bool
+	err    error
+}
+
+func (r *repository) Shutdown() error {
+	r.closed = true
+	return r.err
+}
+
+type repoService struct {
+	repo *repository
+}
+
+func (s *repoService) Initialize(ctx context.Context, deps ...interface{}) error {
+	for _, dep := range deps {
+		if injector, ok := dep.(*do.Injector); ok {
+			repo, err := do.Invoke[*repository](injector)
+			if err != nil {
+				return err
+			}
+			s.repo = repo
+		}
+	}
+	return nil
+}
+
+func (s *repoService) Type() platform.ServiceType {
+	return platform.ServiceType("repo")
+}
+
+type initStarter struct{}
+
+func (initStarter) Start(ctx context.Context, service platform.Service, deps ...interface{}) error {
+	return service.Initialize(ctx, deps...)
+}
+
+func TestResolver(t *testing.T) {
+	tests := []struct {
+		name        string
+		shutdownErr error
+	}{
+		{name: "shuts down the scope"},
+		{name: "shutdown error is not returned to the caller", shutdownErr: errors.New("close failed")},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			injector := do.New()
+			do.Provide(injector, func(i *do.Injector) (*repository, error) {
+				return &repository{err: tt.shutdownErr}, nil
+			})
+
+			launcher := starter.NewServiceLauncher(ctx, zaptest.NewLogger(t))
+			launcher.RegisterPlatform(ctx, platform.VM, initStarter{})
+			launcher.UseResolver(New(injector))
+
+			first, second := &repoService{}, &repoService{}
+			require.NoError(t, launcher.Start(ctx, first, platform.VM))
+			require.NoError(t, launcher.Start(ctx, second, platform.VM))
+
+			// Each service gets its own instances, shut down when it stops
+			require.NotNil(t, first.repo)
+			require.NotNil(t, second.repo)
+			assert.NotSame(t, first.repo, second.repo)
+			assert.True(t, first.repo.closed)
+			assert.True(t, second.repo.closed)
+
+			// The root injector is left untouched
+			assert.Empty(t, injector.ListInvokedServices())
+		})
+	}
+}
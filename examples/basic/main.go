@@ -25,14 +25,9 @@ func NewService() *MyService {
 func (s *MyService) Initialize(ctx context.Context, deps ...interface{}) error {
 	s.logger.Info("Constructing service")
 
-	// Process any dependencies
-	for _, dep := range deps {
-		switch d := dep.(type) {
-		case *zap.Logger:
-			s.logger = d
-		default:
-			// Ignore unknown dependencies
-		}
+	// Use the launcher's logger when provided
+	if logger, ok := platform.DepOf[*zap.Logger](deps...); ok {
+		s.logger = logger
 	}
 
 	s.logger.Info("Service constructed successfully")
@@ -86,8 +81,8 @@ func main() {
 	launcher := starter.NewServiceLauncher(ctx, logger)
 	serviceType := service.Type()
 
-	// Start the service on VM platform
-	err := launcher.Start(ctx, service, platform.VM, engine, logger)
+	// Start the service on VM platform, the launcher passes its logger to the service
+	err := launcher.Start(ctx, service, platform.VM, engine)
 	if err != nil {
 		logger.Fatal("Failed to start service", zap.Error(err), zap.String("platform type", string(platform.VM)), zap.String("service type", serviceType.String()))
 	}
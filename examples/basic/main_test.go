@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/starter"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMyService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve an address: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	launcher := starter.NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- launcher.Start(ctx, NewService(), platform.VM, gin.New(), platform.HTTPConfig{Addr: addr})
+	}()
+
+	var resp *http.Response
+	for i := 0; i < 100; i++ {
+		if resp, err = http.Get("http://" + addr + "/api/data"); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Service did not start: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Data []string `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(body.Data) != 3 {
+		t.Errorf("Expected 3 items, got %v", body.Data)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got: %v", err)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/starter"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// InventoryService is an example gRPC service, register generated service implementations in
+// RegisterServices
+type InventoryService struct {
+	platform.BaseGRPCService
+}
+
+// NewService creates a new instance of InventoryService
+func NewService() *InventoryService {
+	return &InventoryService{}
+}
+
+// RegisterServices registers the health service and server reflection
+func (s *InventoryService) RegisterServices(ctx context.Context, server *grpc.Server) error {
+	if err := s.BaseGRPCService.RegisterServices(ctx, server); err != nil {
+		return err
+	}
+
+	// Register generated services here, e.g. inventorypb.RegisterInventoryServer(server, s)
+	reflection.Register(server)
+
+	s.Logger.Info("Registered gRPC services")
+	return nil
+}
+
+var _ platform.GRPCService = (*InventoryService)(nil)
+
+func main() {
+	ctx := context.Background()
+
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	launcher := starter.NewServiceLauncher(ctx, logger)
+
+	// Serve on the default gRPC address, pass a platform.GRPCConfig to change it
+	if err := launcher.Start(ctx, NewService(), platform.VM); err != nil {
+		logger.Fatal("Failed to start service", zap.Error(err))
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/starter"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestInventoryService(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve an address: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	launcher := starter.NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- launcher.Start(ctx, NewService(), platform.VM, platform.GRPCConfig{Addr: addr})
+	}()
+
+	conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	var resp *healthpb.HealthCheckResponse
+	for i := 0; i < 100; i++ {
+		if resp, err = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("Service did not start: %v", err)
+	}
+	if resp.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("Expected SERVING, got %s", resp.Status)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got: %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/starter"
+	"go.uber.org/zap"
+)
+
+// APIService is the public HTTP API
+type APIService struct {
+	platform.BaseHTTPService
+}
+
+// ConfigureRoutes sets up the HTTP routes
+func (s *APIService) ConfigureRoutes(ctx context.Context, engine platform.Engine) error {
+	if err := s.BaseHTTPService.ConfigureRoutes(ctx, engine); err != nil {
+		return err
+	}
+
+	engine.Handle(http.MethodGet, "/orders", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"orders": []string{}})
+	})
+
+	return nil
+}
+
+// InternalService is the internal gRPC API, it only serves the health service here
+type InternalService struct {
+	platform.BaseGRPCService
+}
+
+// run starts both services and returns once both have stopped, stopping the other service when
+// one fails
+func run(ctx context.Context, logger *zap.Logger, httpConfig platform.HTTPConfig, grpcConfig platform.GRPCConfig) error {
+	launcher := starter.NewServiceLauncher(ctx, logger)
+
+	return launcher.StartAll(ctx, []starter.ServiceSpec{
+		{Name: "api", Service: &APIService{}, Platform: platform.VM, Deps: []interface{}{gin.New(), httpConfig}},
+		{Name: "internal", Service: &InternalService{}, Platform: platform.VM, Deps: []interface{}{grpcConfig}},
+	})
+}
+
+func main() {
+	logger, _ := zap.NewProduction()
+	defer logger.Sync()
+
+	if err := run(context.Background(), logger, platform.HTTPConfig{}, platform.GRPCConfig{}); err != nil {
+		logger.Fatal("Service failed", zap.Error(err))
+	}
+}
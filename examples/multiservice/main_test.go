@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve an address: %v", err)
+	}
+	defer ln.Close()
+	return ln.Addr().String()
+}
+
+func TestRun(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	httpAddr, grpcAddr := freeAddr(t), freeAddr(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- run(ctx, zaptest.NewLogger(t), platform.HTTPConfig{Addr: httpAddr}, platform.GRPCConfig{Addr: grpcAddr})
+	}()
+
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer conn.Close()
+
+	var httpErr, grpcErr error
+	for i := 0; i < 100; i++ {
+		var resp *http.Response
+		if resp, httpErr = http.Get("http://" + httpAddr + "/orders"); httpErr == nil {
+			resp.Body.Close()
+		}
+		_, grpcErr = healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		if httpErr == nil && grpcErr == nil {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if httpErr != nil || grpcErr != nil {
+		t.Fatalf("Services did not start: http: %v, grpc: %v", httpErr, grpcErr)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected clean shutdown, got: %v", err)
+	}
+}
+
+func TestRun_StopsOtherServiceOnFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The gRPC address is already taken, so the HTTP service must be stopped too
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve an address: %v", err)
+	}
+	defer taken.Close()
+
+	err = run(context.Background(), zaptest.NewLogger(t), platform.HTTPConfig{Addr: freeAddr(t)}, platform.GRPCConfig{Addr: taken.Addr().String()})
+	if err == nil {
+		t.Errorf("Expected an error when the gRPC address is taken")
+	}
+}
@@ -0,0 +1,183 @@
+package experiments
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// assignerKey is the gin context key holding the request assignments
+const assignerKey = "experiments.assignments"
+
+// Variant is an arm of an experiment, units are spread across variants in proportion to their weight
+type Variant struct {
+	Name   string `json:"name" yaml:"name"`
+	Weight uint32 `json:"weight" yaml:"weight"`
+}
+
+// Experiment declares the variants units are bucketed into
+type Experiment struct {
+	Name     string    `json:"name" yaml:"name"`
+	Variants []Variant `json:"variants" yaml:"variants"`
+}
+
+// Exposure records that a unit was exposed to an experiment variant
+type Exposure struct {
+	Experiment string    `json:"experiment"`
+	Variant    string    `json:"variant"`
+	Unit       string    `json:"unit"`
+	Time       time.Time `json:"time"`
+}
+
+// ExposureSink receives exposures, e.g. to publish them for analysis
+type ExposureSink interface {
+	Expose(ctx context.Context, exposure Exposure)
+}
+
+// UnitFunc returns the bucketing unit of a request, typically the user or tenant id. Requests
+// without a unit are not assigned.
+type UnitFunc func(c *gin.Context) string
+
+// Bucket returns the variant assigned to a unit. The assignment is deterministic: the same
+// experiment and unit always get the same variant, and units are bucketed independently per
+// experiment. It returns an empty string when the experiment has no weighted variant.
+func Bucket(experiment Experiment, unit string) string {
+	var total uint64
+	for _, variant := range experiment.Variants {
+		total += uint64(variant.Weight)
+	}
+	if total == 0 {
+		return ""
+	}
+
+	sum := sha256.Sum256([]byte(experiment.Name + ":" + unit))
+	point := binary.BigEndian.Uint64(sum[:8]) % total
+
+	for _, variant := range experiment.Variants {
+		if point < uint64(variant.Weight) {
+			return variant.Name
+		}
+		point -= uint64(variant.Weight)
+	}
+
+	return ""
+}
+
+// Assigner assigns request units to experiments and reports exposures
+type Assigner struct {
+	// mu protects experiments
+	mu          sync.RWMutex
+	experiments map[string]Experiment
+
+	sink   ExposureSink
+	logger *zap.Logger
+}
+
+// NewAssigner creates an assigner for the given experiments, exposures are sent to sink when set
+func NewAssigner(experiments []Experiment, sink ExposureSink, logger *zap.Logger) *Assigner {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	a := &Assigner{
+		experiments: make(map[string]Experiment, len(experiments)),
+		sink:        sink,
+		logger:      logger,
+	}
+	for _, experiment := range experiments {
+		a.Define(experiment)
+	}
+
+	return a
+}
+
+// Define adds or replaces an experiment
+func (a *Assigner) Define(experiment Experiment) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	experiment.Variants = append([]Variant(nil), experiment.Variants...)
+	a.experiments[experiment.Name] = experiment
+}
+
+// Middleware returns middleware making assignments available to handlers through VariantOf
+func (a *Assigner) Middleware(unit UnitFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(assignerKey, &assignments{
+			assigner: a,
+			ctx:      c.Request.Context(),
+			unit:     unit(c),
+			variants: make(map[string]string),
+		})
+		c.Next()
+	}
+}
+
+// VariantOf returns the variant of the named experiment assigned to the request unit, reporting
+// the exposure the first time a request reads it. It returns false for unknown experiments,
+// requests without a unit, or when the middleware is not installed.
+func VariantOf(c *gin.Context, experiment string) (string, bool) {
+	value, ok := c.Get(assignerKey)
+	if !ok {
+		return "", false
+	}
+
+	return value.(*assignments).variant(experiment)
+}
+
+// assignments caches the variants read during a request so each exposure is reported once
+type assignments struct {
+	assigner *Assigner
+	ctx      context.Context
+	unit     string
+
+	// mu protects variants, handlers may read variants from several goroutines
+	mu       sync.Mutex
+	variants map[string]string
+}
+
+func (s *assignments) variant(name string) (string, bool) {
+	if s.unit == "" {
+		return "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if variant, ok := s.variants[name]; ok {
+		return variant, true
+	}
+
+	s.assigner.mu.RLock()
+	experiment, ok := s.assigner.experiments[name]
+	s.assigner.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+
+	variant := Bucket(experiment, s.unit)
+	if variant == "" {
+		return "", false
+	}
+	s.variants[name] = variant
+
+	s.assigner.logger.Debug("Experiment exposure",
+		zap.String("experiment", name),
+		zap.String("variant", variant),
+		zap.String("unit", s.unit))
+	if s.assigner.sink != nil {
+		s.assigner.sink.Expose(s.ctx, Exposure{Experiment: name, Variant: variant, Unit: s.unit, Time: time.Now().UTC()})
+	}
+
+	return variant, true
+}
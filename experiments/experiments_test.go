@@ -0,0 +1,102 @@
+package experiments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type recordingSink struct {
+	mu        sync.Mutex
+	exposures []Exposure
+}
+
+func (s *recordingSink) Expose(ctx context.Context, exposure Exposure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exposures = append(s.exposures, exposure)
+}
+
+var checkout = Experiment{
+	Name:     "checkout-button",
+	Variants: []Variant{{Name: "control", Weight: 50}, {Name: "green", Weight: 50}},
+}
+
+func TestBucket(t *testing.T) {
+	// Assignments are stable
+	assert.Equal(t, Bucket(checkout, "user-1"), Bucket(checkout, "user-1"))
+
+	// Units spread across variants in proportion to their weight
+	counts := map[string]int{}
+	for i := 0; i < 10000; i++ {
+		counts[Bucket(checkout, fmt.Sprintf("user-%d", i))]++
+	}
+	assert.InDelta(t, 5000, counts["control"], 300)
+	assert.InDelta(t, 5000, counts["green"], 300)
+
+	// Zero weight variants are never assigned
+	assert.Equal(t, "on", Bucket(Experiment{Name: "rollout", Variants: []Variant{{Name: "off"}, {Name: "on", Weight: 1}}}, "user-1"))
+	assert.Equal(t, "", Bucket(Experiment{Name: "empty"}, "user-1"))
+}
+
+func TestVariantOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		unit          string
+		experiment    string
+		wantOK        bool
+		wantExposures int
+	}{
+		{name: "assigned", unit: "user-1", experiment: "checkout-button", wantOK: true, wantExposures: 1},
+		{name: "unknown experiment", unit: "user-1", experiment: "unknown"},
+		{name: "no unit", unit: "", experiment: "checkout-button"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			sink := &recordingSink{}
+			assigner := NewAssigner([]Experiment{checkout}, sink, zaptest.NewLogger(t))
+
+			engine := gin.New()
+			engine.Use(assigner.Middleware(func(c *gin.Context) string { return c.GetHeader("X-User-ID") }))
+			engine.GET("/checkout", func(c *gin.Context) {
+				first, ok := VariantOf(c, tt.experiment)
+				again, _ := VariantOf(c, tt.experiment)
+				require.Equal(t, tt.wantOK, ok)
+				require.Equal(t, first, again)
+				c.String(http.StatusOK, first)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/checkout", nil)
+			req.Header.Set("X-User-ID", tt.unit)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			// Exposure is reported once per request however often the variant is read
+			require.Len(t, sink.exposures, tt.wantExposures)
+			if tt.wantOK {
+				assert.Equal(t, Bucket(checkout, tt.unit), rec.Body.String())
+				assert.Equal(t, rec.Body.String(), sink.exposures[0].Variant)
+				assert.Equal(t, tt.unit, sink.exposures[0].Unit)
+			}
+		})
+	}
+}
+
+func TestVariantOf_WithoutMiddleware(t *testing.T) {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+	_, ok := VariantOf(c, "checkout-button")
+	assert.False(t, ok)
+}
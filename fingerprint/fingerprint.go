@@ -0,0 +1,94 @@
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Header carries the fingerprint on 5xx responses when it is known before the response is written
+const Header = "X-Error-Fingerprint"
+
+// MaxFrames is the number of top stack frames included in a fingerprint
+const MaxFrames = 5
+
+// Stacker is implemented by errors recording the stack where they were created, Of uses its
+// frames when no program counters are given
+type Stacker interface {
+	Callers() []uintptr
+}
+
+// Of returns the fingerprint of an error: a short hash of the types along its wrap chain and the
+// top stack frames of pc, or of the first Stacker in the chain when pc is empty. Messages are left
+// out as they carry ids and values that vary between occurrences of the same failure, so errors
+// of the same types failing at the same place share a fingerprint.
+func Of(err error, pc []uintptr) string {
+	var b strings.Builder
+
+	var stack []uintptr
+	walk(err, func(e error) {
+		fmt.Fprintf(&b, "%T;", e)
+		if stacker, ok := e.(Stacker); ok && stack == nil {
+			stack = stacker.Callers()
+		}
+	})
+	if len(pc) == 0 {
+		pc = stack
+	}
+
+	for _, frame := range topFrames(pc) {
+		b.WriteString(";")
+		b.WriteString(frame)
+	}
+
+	sum := sha256.Sum256([]byte(b.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+// walk calls fn for the error and each error it wraps, depth first, following errors.Join
+func walk(err error, fn func(error)) {
+	if err == nil {
+		return
+	}
+	fn(err)
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		walk(e.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, wrapped := range e.Unwrap() {
+			walk(wrapped, fn)
+		}
+	}
+}
+
+// Callers returns the program counters of the caller's stack, skipping skip frames above the
+// caller, for use with Of
+func Callers(skip int) []uintptr {
+	pc := make([]uintptr, 32)
+	n := runtime.Callers(skip+2, pc)
+	return pc[:n]
+}
+
+// topFrames returns the function names of the first frames outside the runtime
+func topFrames(pc []uintptr) []string {
+	if len(pc) == 0 {
+		return nil
+	}
+
+	var names []string
+	frames := runtime.CallersFrames(pc)
+	for len(names) < MaxFrames {
+		frame, more := frames.Next()
+		if frame.Function != "" && !strings.HasPrefix(frame.Function, "runtime.") {
+			names = append(names, frame.Function)
+		}
+		if !more {
+			break
+		}
+	}
+
+	return names
+}
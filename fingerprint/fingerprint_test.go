@@ -0,0 +1,150 @@
+package fingerprint
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// stackError records the stack where it was created
+type stackError struct {
+	msg string
+	pc  []uintptr
+}
+
+func newStackError(msg string) error {
+	return &stackError{msg: msg, pc: Callers(1)}
+}
+
+func (e *stackError) Error() string      { return e.msg }
+func (e *stackError) Callers() []uintptr { return e.pc }
+
+// declinedError is a typed failure
+type declinedError struct{ reason string }
+
+func (e *declinedError) Error() string { return "payment declined: " + e.reason }
+
+func TestOf(t *testing.T) {
+	// Messages do not change the fingerprint
+	assert.Equal(t,
+		Of(fmt.Errorf("load order 123: %w", fs.ErrNotExist), nil),
+		Of(fmt.Errorf("load customer alice: %w", fs.ErrNotExist), nil))
+	assert.Equal(t,
+		Of(&declinedError{reason: "insufficient funds"}, nil),
+		Of(&declinedError{reason: "card expired"}, nil))
+
+	// Different types along the chain get different fingerprints
+	assert.NotEqual(t,
+		Of(errors.New("timeout"), nil),
+		Of(fmt.Errorf("timeout: %w", errors.New("dial")), nil))
+	assert.NotEqual(t,
+		Of(errors.New("declined"), nil),
+		Of(&declinedError{reason: "declined"}, nil))
+	assert.NotEqual(t,
+		Of(errors.Join(errors.New("a")), nil),
+		Of(errors.Join(errors.New("a"), &declinedError{}), nil))
+
+	// Stack frames are part of the fingerprint
+	err := errors.New("boom")
+	assert.NotEqual(t, Of(err, nil), Of(err, Callers(0)))
+}
+
+func TestOf_Stacker(t *testing.T) {
+	failAt := func(id int) error { return newStackError(fmt.Sprintf("order %d failed", id)) }
+	failElsewhere := func() error { return newStackError("order failed") }
+
+	// The frames of a Stacker in the chain are used when no program counters are given
+	assert.Equal(t, Of(failAt(1), nil), Of(failAt(2), nil))
+	assert.NotEqual(t, Of(failAt(1), nil), Of(failElsewhere(), nil))
+
+	// Program counters given take precedence
+	err := failAt(1)
+	pc := Callers(0)
+	assert.Equal(t, Of(err, pc), Of(failElsewhere(), pc))
+}
+
+func TestTracker_Summary(t *testing.T) {
+	tracker := NewTracker(time.Minute, zaptest.NewLogger(t))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tracker.now = func() time.Time { return now }
+
+	tracker.Record(errors.New("stale"), nil, "/old")
+	now = now.Add(2 * time.Minute)
+
+	fp := tracker.Record(errors.New("order 1 failed"), nil, "/orders")
+	tracker.Record(errors.New("order 2 failed"), nil, "/orders")
+	tracker.Record(&declinedError{reason: "card expired"}, nil, "/payments")
+
+	summary := tracker.Summary()
+
+	// The stale error left the window, the most frequent error comes first
+	require.Len(t, summary, 2)
+	assert.Equal(t, fp, summary[0].Fingerprint)
+	assert.Equal(t, 2, summary[0].Count)
+	assert.Equal(t, "order 1 failed", summary[0].Sample)
+	assert.Equal(t, "/orders", summary[0].Route)
+	assert.Equal(t, 1, summary[1].Count)
+}
+
+func TestTracker_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tracker := NewTracker(0, zaptest.NewLogger(t))
+
+	engine := gin.New()
+	engine.Use(gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	engine.Use(tracker.Middleware())
+	engine.GET("/error", func(c *gin.Context) {
+		_ = c.Error(errors.New("database unavailable"))
+		c.Status(http.StatusServiceUnavailable)
+	})
+	engine.GET("/client-error", func(c *gin.Context) {
+		_ = c.Error(errors.New("bad input"))
+		c.Status(http.StatusBadRequest)
+	})
+	engine.GET("/panic", func(c *gin.Context) {
+		panic("nil map")
+	})
+	engine.GET("/admin/errors", tracker.Handler())
+
+	for _, path := range []string{"/error", "/error", "/client-error", "/panic"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		if path == "/client-error" {
+			assert.Empty(t, rec.Header().Get(Header))
+			continue
+		}
+		assert.GreaterOrEqual(t, rec.Code, http.StatusInternalServerError)
+		assert.NotEmpty(t, rec.Header().Get(Header), path)
+	}
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/errors", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		Window string  `json:"window"`
+		Errors []Entry `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	assert.Equal(t, "1h0m0s", body.Window)
+	require.Len(t, body.Errors, 2)
+	assert.Equal(t, "database unavailable", body.Errors[0].Sample)
+	assert.Equal(t, 2, body.Errors[0].Count)
+	assert.Equal(t, "panic: nil map", body.Errors[1].Sample)
+	assert.Equal(t, "/panic", body.Errors[1].Route)
+}
@@ -0,0 +1,199 @@
+package fingerprint
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// contextKey is the gin context key holding the request's error fingerprint
+const contextKey = "fingerprint"
+
+// DefaultWindow is how long an error stays in the summary after it was last seen
+const DefaultWindow = time.Hour
+
+// maxEntries bounds the distinct fingerprints tracked, the least recently seen is evicted first
+const maxEntries = 1000
+
+// Entry summarizes the occurrences of one fingerprint within the window
+type Entry struct {
+	Fingerprint string    `json:"fingerprint"`
+	Count       int       `json:"count"`
+	FirstSeen   time.Time `json:"firstSeen"`
+	LastSeen    time.Time `json:"lastSeen"`
+	Sample      string    `json:"sample"`
+	Route       string    `json:"route,omitempty"`
+}
+
+// Tracker keeps a rolling summary of recent errors by fingerprint, so novel failures stand out
+// without external tooling
+type Tracker struct {
+	// mu protects entries
+	mu      sync.Mutex
+	entries map[string]*Entry
+
+	window time.Duration
+	logger *zap.Logger
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// NewTracker creates a tracker keeping errors seen within the window, DefaultWindow when zero
+func NewTracker(window time.Duration, logger *zap.Logger) *Tracker {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if window <= 0 {
+		window = DefaultWindow
+	}
+
+	return &Tracker{
+		entries: make(map[string]*Entry),
+		window:  window,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// Record adds an occurrence of the error and returns its fingerprint, the first occurrence of a
+// fingerprint within the window is logged as a novel error
+func (t *Tracker) Record(err error, pc []uintptr, route string) string {
+	fp := Of(err, pc)
+	now := t.now()
+
+	t.mu.Lock()
+	t.evictLocked(now)
+
+	entry, seen := t.entries[fp]
+	if !seen {
+		entry = &Entry{Fingerprint: fp, FirstSeen: now, Sample: err.Error(), Route: route}
+		t.entries[fp] = entry
+	}
+	entry.Count++
+	entry.LastSeen = now
+	t.mu.Unlock()
+
+	if !seen {
+		t.logger.Warn("Novel error", zap.String("fingerprint", fp), zap.String("route", route), zap.Error(err))
+	}
+
+	return fp
+}
+
+// Summary returns the errors seen within the window, most frequent first
+func (t *Tracker) Summary() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.evictLocked(t.now())
+
+	summary := make([]Entry, 0, len(t.entries))
+	for _, entry := range t.entries {
+		summary = append(summary, *entry)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Count != summary[j].Count {
+			return summary[i].Count > summary[j].Count
+		}
+		return summary[i].LastSeen.After(summary[j].LastSeen)
+	})
+
+	return summary
+}
+
+// evictLocked drops entries outside the window and the least recently seen beyond maxEntries
+func (t *Tracker) evictLocked(now time.Time) {
+	for fp, entry := range t.entries {
+		if now.Sub(entry.LastSeen) > t.window {
+			delete(t.entries, fp)
+		}
+	}
+
+	for len(t.entries) >= maxEntries {
+		var oldest *Entry
+		for _, entry := range t.entries {
+			if oldest == nil || entry.LastSeen.Before(oldest.LastSeen) {
+				oldest = entry
+			}
+		}
+		delete(t.entries, oldest.Fingerprint)
+	}
+}
+
+// Middleware returns middleware fingerprinting 5xx failures: errors added to the gin context and
+// panics, which are re-raised for the recovery middleware after being recorded. Panics are
+// fingerprinted with their stack, errors with the stack of a Stacker in their chain, so plain
+// errors of the same type share a fingerprint. The fingerprint is logged, stored for FromContext
+// and sent in the X-Error-Fingerprint header when the response has not been written yet. Install
+// it after the recovery middleware.
+func (t *Tracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				err, ok := recovered.(error)
+				if !ok {
+					err = fmt.Errorf("panic: %v", recovered)
+				}
+
+				// Skip the deferred function and the runtime's panic frames
+				t.attach(c, t.Record(err, Callers(2), c.FullPath()))
+				panic(recovered)
+			}
+		}()
+
+		c.Next()
+
+		if c.Writer.Status() < http.StatusInternalServerError || len(c.Errors) == 0 {
+			return
+		}
+
+		err := c.Errors.Last().Err
+		fp := t.Record(err, nil, c.FullPath())
+		t.attach(c, fp)
+		t.logger.Error("Request failed",
+			zap.String("fingerprint", fp),
+			zap.String("route", c.FullPath()),
+			zap.Int("status", c.Writer.Status()),
+			zap.Error(err))
+	}
+}
+
+// attach stores the fingerprint on the request and the response when still possible
+func (t *Tracker) attach(c *gin.Context, fp string) {
+	c.Set(contextKey, fp)
+	if !c.Writer.Written() {
+		c.Header(Header, fp)
+	}
+}
+
+// Handler returns a handler reporting the rolling error summary, meant for an admin route
+func (t *Tracker) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"window": t.window.String(),
+			"errors": t.Summary(),
+		})
+	}
+}
+
+// FromContext returns the fingerprint of the request's failure, if any
+func FromContext(c *gin.Context) (string, bool) {
+	fp, ok := c.Get(contextKey)
+	if !ok {
+		return "", false
+	}
+
+	return fp.(string), true
+}
@@ -0,0 +1,253 @@
+// Package gdpr orchestrates data subject requests. Services register the export and erasure of
+// each category of personal data they hold on the Registry passed as a dependency, and the HTTP
+// starters expose authenticated admin endpoints running them across categories, recording every
+// request in an audit trail.
+package gdpr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Kinds of requests
+const (
+	KindExport = "export"
+	KindErase  = "erase"
+)
+
+// Statuses of requests and categories
+const (
+	StatusStarted   = "started"
+	StatusCompleted = "completed"
+	StatusFailed    = "failed"
+
+	// StatusRetained is set on categories kept on erasure, like invoices under a legal retention
+	StatusRetained = "retained"
+)
+
+// Category is a kind of personal data held by the service
+type Category struct {
+	// Name identifies the category, like "profile" or "orders"
+	Name string
+
+	// Export returns the data of the subject, encoded as JSON in the export
+	Export func(ctx context.Context, subject string) (interface{}, error)
+
+	// Erase deletes or anonymizes the data of the subject, nil when the data must be retained
+	Erase func(ctx context.Context, subject string) error
+}
+
+// CategoryResult is the outcome of a request for a category
+type CategoryResult struct {
+	Name   string      `json:"name"`
+	Status string      `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+}
+
+// Report is the outcome of a request
+type Report struct {
+	ID          string           `json:"id"`
+	Kind        string           `json:"kind"`
+	Subject     string           `json:"subject"`
+	Requester   string           `json:"requester"`
+	Status      string           `json:"status"`
+	RequestedAt time.Time        `json:"requestedAt"`
+	CompletedAt time.Time        `json:"completedAt,omitempty"`
+	Categories  []CategoryResult `json:"categories"`
+}
+
+// Auditor records the audit trail of requests, it receives each request when it starts and when
+// it completes, without the exported data
+type Auditor interface {
+	Record(ctx context.Context, report Report) error
+}
+
+// Registry holds the categories of a service and runs requests over them
+type Registry struct {
+	// mu protects categories
+	mu         sync.RWMutex
+	categories []Category
+
+	auditor Auditor
+	logger  *zap.Logger
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// NewRegistry creates an empty registry recording its audit trail with the auditor, in the logs
+// when it is nil
+func NewRegistry(auditor Auditor, logger *zap.Logger) *Registry {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if auditor == nil {
+		auditor = NewLogAuditor(logger)
+	}
+
+	return &Registry{auditor: auditor, logger: logger, now: time.Now}
+}
+
+// Register adds a category, categories are processed in registration order. A category with the
+// name of a registered one replaces it, so a restarted service can register its categories again.
+func (r *Registry) Register(category Category) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.categories {
+		if r.categories[i].Name == category.Name {
+			r.categories[i] = category
+			return
+		}
+	}
+	r.categories = append(r.categories, category)
+}
+
+// Len returns the number of registered categories
+func (r *Registry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.categories)
+}
+
+// Export collects the data of the subject from every category
+func (r *Registry) Export(ctx context.Context, subject, requester string) (Report, error) {
+	return r.run(ctx, KindExport, subject, requester, func(ctx context.Context, category Category) CategoryResult {
+		if category.Export == nil {
+			return CategoryResult{Name: category.Name, Status: StatusCompleted}
+		}
+
+		data, err := category.Export(ctx, subject)
+		if err != nil {
+			return CategoryResult{Name: category.Name, Status: StatusFailed, Error: err.Error()}
+		}
+		return CategoryResult{Name: category.Name, Status: StatusCompleted, Data: data}
+	})
+}
+
+// Erase erases the data of the subject from every category. A failing category does not prevent
+// the others from being erased, the request can be run again to retry.
+func (r *Registry) Erase(ctx context.Context, subject, requester string) (Report, error) {
+	return r.run(ctx, KindErase, subject, requester, func(ctx context.Context, category Category) CategoryResult {
+		if category.Erase == nil {
+			return CategoryResult{Name: category.Name, Status: StatusRetained}
+		}
+
+		if err := category.Erase(ctx, subject); err != nil {
+			return CategoryResult{Name: category.Name, Status: StatusFailed, Error: err.Error()}
+		}
+		return CategoryResult{Name: category.Name, Status: StatusCompleted}
+	})
+}
+
+// run processes each category and records the request before and after. The request is not run
+// when it cannot be audited.
+func (r *Registry) run(
+	ctx context.Context,
+	kind, subject, requester string,
+	process func(ctx context.Context, category Category) CategoryResult,
+) (Report, error) {
+	if subject == "" {
+		return Report{}, errors.New("data subject is required")
+	}
+
+	r.mu.RLock()
+	categories := append([]Category(nil), r.categories...)
+	r.mu.RUnlock()
+
+	id, err := newRequestID()
+	if err != nil {
+		return Report{}, err
+	}
+
+	report := Report{
+		ID:          id,
+		Kind:        kind,
+		Subject:     subject,
+		Requester:   requester,
+		Status:      StatusStarted,
+		RequestedAt: r.now().UTC(),
+		Categories:  []CategoryResult{},
+	}
+	if err := r.auditor.Record(ctx, report); err != nil {
+		return Report{}, fmt.Errorf("failed to audit data subject request: %w", err)
+	}
+
+	report.Status = StatusCompleted
+	for _, category := range categories {
+		result := process(ctx, category)
+		if result.Status == StatusFailed {
+			report.Status = StatusFailed
+			r.logger.Error("Data subject request failed for category",
+				zap.String("request", report.ID),
+				zap.String("kind", kind),
+				zap.String("category", category.Name),
+				zap.String("error", result.Error))
+		}
+		report.Categories = append(report.Categories, result)
+	}
+	report.CompletedAt = r.now().UTC()
+
+	// The exported data stays out of the audit trail
+	audited := report
+	audited.Categories = make([]CategoryResult, len(report.Categories))
+	for i, result := range report.Categories {
+		result.Data = nil
+		audited.Categories[i] = result
+	}
+	if err := r.auditor.Record(context.WithoutCancel(ctx), audited); err != nil {
+		return report, fmt.Errorf("failed to audit data subject request: %w", err)
+	}
+
+	return report, nil
+}
+
+// newRequestID returns a random request ID
+func newRequestID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// logAuditor records the audit trail in the logs
+type logAuditor struct {
+	logger *zap.Logger
+}
+
+// NewLogAuditor creates an auditor logging each request, ship the logs to an append-only store to
+// keep the trail
+func NewLogAuditor(logger *zap.Logger) Auditor {
+	return logAuditor{logger: logger}
+}
+
+// Record logs the request
+func (a logAuditor) Record(ctx context.Context, report Report) error {
+	categories := make([]string, len(report.Categories))
+	for i, result := range report.Categories {
+		categories[i] = result.Name + ":" + result.Status
+	}
+
+	a.logger.Info("Data subject request",
+		zap.String("request", report.ID),
+		zap.String("kind", report.Kind),
+		zap.String("subject", report.Subject),
+		zap.String("requester", report.Requester),
+		zap.String("status", report.Status),
+		zap.Strings("categories", categories))
+	return nil
+}
@@ -0,0 +1,111 @@
+package gdpr
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// memoryAuditor records the audit trail in memory
+type memoryAuditor struct {
+	mu      sync.Mutex
+	reports []Report
+	err     error
+}
+
+func (a *memoryAuditor) Record(ctx context.Context, report Report) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.err != nil {
+		return a.err
+	}
+	a.reports = append(a.reports, report)
+	return nil
+}
+
+func newRegistry(t *testing.T, auditor Auditor) *Registry {
+	r := NewRegistry(auditor, zaptest.NewLogger(t))
+	r.Register(Category{
+		Name: "profile",
+		Export: func(ctx context.Context, subject string) (interface{}, error) {
+			return map[string]string{"email": subject + "@example.com"}, nil
+		},
+		Erase: func(ctx context.Context, subject string) error { return nil },
+	})
+	r.Register(Category{
+		Name: "invoices",
+		Export: func(ctx context.Context, subject string) (interface{}, error) {
+			return []string{"INV-1"}, nil
+		},
+	})
+	return r
+}
+
+func TestRegistry_Export(t *testing.T) {
+	auditor := &memoryAuditor{}
+	r := newRegistry(t, auditor)
+
+	report, err := r.Export(context.Background(), "alice", "dpo")
+	require.NoError(t, err)
+
+	assert.Equal(t, KindExport, report.Kind)
+	assert.Equal(t, StatusCompleted, report.Status)
+	assert.Equal(t, []CategoryResult{
+		{Name: "profile", Status: StatusCompleted, Data: map[string]string{"email": "alice@example.com"}},
+		{Name: "invoices", Status: StatusCompleted, Data: []string{"INV-1"}},
+	}, report.Categories)
+
+	// The request is audited when it starts and completes, without the exported data
+	require.Len(t, auditor.reports, 2)
+	assert.Equal(t, StatusStarted, auditor.reports[0].Status)
+	assert.Equal(t, report.ID, auditor.reports[1].ID)
+	assert.Equal(t, "dpo", auditor.reports[1].Requester)
+	for _, result := range auditor.reports[1].Categories {
+		assert.Nil(t, result.Data)
+	}
+	assert.NotNil(t, report.Categories[0].Data, "auditing must not strip the returned data")
+}
+
+func TestRegistry_Erase(t *testing.T) {
+	auditor := &memoryAuditor{}
+	r := newRegistry(t, auditor)
+	r.Register(Category{
+		Name: "analytics",
+		Erase: func(ctx context.Context, subject string) error {
+			return errors.New("warehouse unavailable")
+		},
+	})
+
+	report, err := r.Erase(context.Background(), "alice", "dpo")
+	require.NoError(t, err)
+
+	assert.Equal(t, StatusFailed, report.Status)
+	assert.Equal(t, []CategoryResult{
+		{Name: "profile", Status: StatusCompleted},
+		{Name: "invoices", Status: StatusRetained},
+		{Name: "analytics", Status: StatusFailed, Error: "warehouse unavailable"},
+	}, report.Categories)
+	assert.Equal(t, StatusFailed, auditor.reports[1].Status)
+}
+
+func TestRegistry_AuditFailure(t *testing.T) {
+	erased := false
+	r := NewRegistry(&memoryAuditor{err: errors.New("audit store down")}, zaptest.NewLogger(t))
+	r.Register(Category{Name: "profile", Erase: func(ctx context.Context, subject string) error {
+		erased = true
+		return nil
+	}})
+
+	// Requests that cannot be audited do not run
+	_, err := r.Erase(context.Background(), "alice", "dpo")
+	assert.EqualError(t, err, "failed to audit data subject request: audit store down")
+	assert.False(t, erased)
+
+	_, err = r.Erase(context.Background(), "", "dpo")
+	assert.Error(t, err)
+}
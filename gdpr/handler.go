@@ -0,0 +1,123 @@
+package gdpr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"go.uber.org/zap"
+)
+
+// Routes of the admin handlers
+const (
+	ExportPath = "/admin/data-subjects/:subject/export"
+	ErasePath  = "/admin/data-subjects/:subject/erase"
+)
+
+// Routes of the admin handlers in the net/http pattern syntax, used by ServeMux and chi
+const (
+	ExportPattern = "/admin/data-subjects/{subject}/export"
+	ErasePattern  = "/admin/data-subjects/{subject}/erase"
+)
+
+// Permissions required by the admin handlers
+const (
+	ExportPermission = "data-subjects:export"
+	ErasePermission  = "data-subjects:erase"
+)
+
+// runFunc runs a data subject request
+type runFunc func(ctx context.Context, subject, requester string) (Report, error)
+
+// ExportHandler runs an export for the subject of the path and responds with the report, the
+// requester is the subject set by the authentication middleware
+func (r *Registry) ExportHandler() gin.HandlerFunc {
+	return r.handler(r.Export)
+}
+
+// EraseHandler runs an erasure for the subject of the path and responds with the report
+func (r *Registry) EraseHandler() gin.HandlerFunc {
+	return r.handler(r.Erase)
+}
+
+// ExportHTTPHandler is the net/http variant of ExportHandler, the subject is the "subject" path
+// value and the requester the subject of authz.SubjectFromContext
+func (r *Registry) ExportHTTPHandler() http.Handler {
+	return r.httpHandler(r.Export)
+}
+
+// EraseHTTPHandler is the net/http variant of EraseHandler
+func (r *Registry) EraseHTTPHandler() http.Handler {
+	return r.httpHandler(r.Erase)
+}
+
+// handler runs the request and responds with its report, 500 when a category failed
+func (r *Registry) handler(run runFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requester, ok := authz.Subject(c)
+		if !ok {
+			authz.AbortWithProblem(c, authz.Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		status, report, ok := r.serve(c.Request.Context(), run, c.Param("subject"), requester)
+		if !ok {
+			authz.AbortWithProblem(c, requestFailed())
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(status, report)
+	}
+}
+
+// httpHandler is the net/http variant of handler
+func (r *Registry) httpHandler(run runFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requester, ok := authz.SubjectFromContext(req.Context())
+		if !ok {
+			authz.WriteProblem(w, authz.Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		status, report, ok := r.serve(req.Context(), run, req.PathValue("subject"), requester)
+		if !ok {
+			authz.WriteProblem(w, requestFailed())
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// serve runs the request and returns the status of its report, false when the request did not
+// run. The error is logged, it is not sent to the client.
+func (r *Registry) serve(ctx context.Context, run runFunc, subject, requester string) (int, Report, bool) {
+	report, err := run(ctx, subject, requester)
+	if err != nil {
+		r.logger.Error("Data subject request failed",
+			zap.String("request", report.ID),
+			zap.String("requester", requester),
+			zap.Error(err))
+	}
+	if err != nil && report.ID == "" {
+		return 0, Report{}, false
+	}
+
+	status := http.StatusOK
+	if err != nil || report.Status == StatusFailed {
+		status = http.StatusInternalServerError
+	}
+	return status, report, true
+}
+
+// requestFailed returns the problem sent when a data subject request did not run
+func requestFailed() authz.Problem {
+	return authz.Problem{Problem: problem.Internal("the data subject request could not be run")}
+}
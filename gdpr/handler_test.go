@@ -0,0 +1,120 @@
+package gdpr
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Handlers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	auditor := &memoryAuditor{}
+	r := newRegistry(t, auditor)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			authz.SetSubject(c, subject)
+		}
+	})
+	engine.POST(ExportPath, r.ExportHandler())
+	engine.POST(ErasePath, r.EraseHandler())
+
+	post := func(path, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if subject != "" {
+			req.Header.Set("X-Subject", subject)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/admin/data-subjects/alice/export", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = post("/admin/data-subjects/alice/export", "dpo")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "alice", report.Subject)
+	assert.Equal(t, "dpo", report.Requester)
+	assert.Len(t, report.Categories, 2)
+
+	rec = post("/admin/data-subjects/alice/erase", "dpo")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, KindErase, report.Kind)
+
+	// Both requests were audited twice
+	assert.Len(t, auditor.reports, 4)
+}
+
+func TestRegistry_HandlerAuditFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := newRegistry(t, &memoryAuditor{err: errors.New("audit store down")})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) { authz.SetSubject(c, "dpo") })
+	engine.POST(ExportPath, r.ExportHandler())
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/data-subjects/alice/export", nil))
+
+	// The internal error stays in the logs
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+	assert.NotContains(t, rec.Body.String(), "audit store down")
+
+	var p authz.Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+	assert.Equal(t, problem.InternalProblemType, p.Type)
+}
+
+func TestRegistry_HTTPHandlers(t *testing.T) {
+	r := newRegistry(t, &memoryAuditor{})
+
+	mux := http.NewServeMux()
+	mux.Handle("POST "+ExportPattern, r.ExportHTTPHandler())
+	mux.Handle("POST "+ErasePattern, r.EraseHTTPHandler())
+
+	post := func(path, subject string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if subject != "" {
+			req = req.WithContext(authz.WithSubject(req.Context(), subject))
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("/admin/data-subjects/alice/export", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+
+	rec = post("/admin/data-subjects/alice/export", "dpo")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var report Report
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "alice", report.Subject)
+	assert.Equal(t, "dpo", report.Requester)
+
+	rec = post("/admin/data-subjects/alice/erase", "dpo")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, KindErase, report.Kind)
+}
@@ -0,0 +1,219 @@
+// Package health aggregates the health checks of a service. Services register checkers on the
+// Registry passed as a dependency during Initialize, and the HTTP starters expose them on
+// LivePath and ReadyPath.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout bounds a check registered without WithTimeout
+const DefaultTimeout = 5 * time.Second
+
+// Routes of the health handlers
+const (
+	LivePath  = "/health/live"
+	ReadyPath = "/health/ready"
+)
+
+// Statuses of reports and results
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+)
+
+// Checker checks the health of a component the service depends on
+type Checker interface {
+	// Name identifies the check in reports
+	Name() string
+
+	// Check returns an error when the component is unhealthy, it must return once ctx is done
+	Check(ctx context.Context) error
+}
+
+// checkFunc adapts a function to Checker
+type checkFunc struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+func (c checkFunc) Name() string                    { return c.name }
+func (c checkFunc) Check(ctx context.Context) error { return c.fn(ctx) }
+
+// CheckFunc returns a Checker running the function
+func CheckFunc(name string, fn func(ctx context.Context) error) Checker {
+	return checkFunc{name: name, fn: fn}
+}
+
+// Option configures a registered check
+type Option func(*check)
+
+// WithTimeout bounds the check, DefaultTimeout when not set
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *check) {
+		c.timeout = timeout
+	}
+}
+
+// check is a registered checker
+type check struct {
+	checker Checker
+	timeout time.Duration
+}
+
+// Result is the outcome of a check
+type Result struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"durationNs"`
+}
+
+// Report aggregates the results of the checks, it passes when every check passes
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Registry holds the liveness and readiness checks of a service
+type Registry struct {
+	// mu protects live and ready
+	mu    sync.RWMutex
+	live  []check
+	ready []check
+}
+
+// NewRegistry creates an empty registry, reports of an empty registry pass
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a readiness check, failing it takes the service out of rotation. A check with
+// the name of a registered one replaces it, so a restarted service can register its checks again.
+func (r *Registry) Register(checker Checker, opts ...Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ready = register(r.ready, newCheck(checker, opts))
+}
+
+// RegisterLiveness adds a liveness check, failing it gets the service restarted, so it should
+// only fail when the process cannot recover on its own. Like Register, it replaces a check with
+// the same name.
+func (r *Registry) RegisterLiveness(checker Checker, opts ...Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.live = register(r.live, newCheck(checker, opts))
+}
+
+// Live runs the liveness checks
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.live...)
+	r.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+// Ready runs the readiness checks
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check(nil), r.ready...)
+	r.mu.RUnlock()
+	return run(ctx, checks)
+}
+
+// LiveHandler returns a handler reporting the liveness checks as JSON, it responds 503 when
+// one fails
+func (r *Registry) LiveHandler() http.Handler {
+	return reportHandler(r.Live)
+}
+
+// ReadyHandler returns a handler reporting the readiness checks as JSON, it responds 503 when
+// one fails
+func (r *Registry) ReadyHandler() http.Handler {
+	return reportHandler(r.Ready)
+}
+
+// register adds the check to checks, replacing a check with the same name
+func register(checks []check, c check) []check {
+	for i := range checks {
+		if checks[i].checker.Name() == c.checker.Name() {
+			checks[i] = c
+			return checks
+		}
+	}
+	return append(checks, c)
+}
+
+// newCheck applies the options to a checker
+func newCheck(checker Checker, opts []Option) check {
+	c := check{checker: checker, timeout: DefaultTimeout}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// run runs the checks in parallel, each under its own timeout
+func run(ctx context.Context, checks []check) Report {
+	report := Report{Status: StatusPass, Checks: make([]Result, len(checks))}
+
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func(i int, c check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			// Checks ignoring the context are abandoned once it is done
+			start := time.Now()
+			done := make(chan error, 1)
+			go func() { done <- c.checker.Check(ctx) }()
+
+			var err error
+			select {
+			case err = <-done:
+			case <-ctx.Done():
+				err = fmt.Errorf("check did not complete: %w", ctx.Err())
+			}
+
+			result := Result{Name: c.checker.Name(), Status: StatusPass, Duration: time.Since(start)}
+			if err != nil {
+				result.Status, result.Error = StatusFail, err.Error()
+			}
+			report.Checks[i] = result
+		}(i, c)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status == StatusFail {
+			report.Status = StatusFail
+		}
+	}
+
+	return report
+}
+
+// reportHandler serves the report as JSON
+func reportHandler(check func(ctx context.Context) Report) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := check(r.Context())
+
+		status := http.StatusOK
+		if report.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
@@ -0,0 +1,96 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Ready(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("empty registry passes", func(t *testing.T) {
+		report := NewRegistry().Ready(ctx)
+
+		assert.Equal(t, StatusPass, report.Status)
+		assert.Empty(t, report.Checks)
+	})
+
+	t.Run("aggregates checks", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(CheckFunc("database", func(ctx context.Context) error { return nil }))
+		registry.Register(CheckFunc("cache", func(ctx context.Context) error { return errors.New("connection refused") }))
+		registry.RegisterLiveness(CheckFunc("deadlock", func(ctx context.Context) error { return nil }))
+
+		report := registry.Ready(ctx)
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, StatusFail, report.Status)
+		assert.Equal(t, "database", report.Checks[0].Name)
+		assert.Equal(t, StatusPass, report.Checks[0].Status)
+		assert.Equal(t, "cache", report.Checks[1].Name)
+		assert.Equal(t, StatusFail, report.Checks[1].Status)
+		assert.Equal(t, "connection refused", report.Checks[1].Error)
+
+		live := registry.Live(ctx)
+		assert.Equal(t, StatusPass, live.Status)
+		assert.Len(t, live.Checks, 1)
+	})
+
+	t.Run("checks time out individually", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Register(CheckFunc("stuck", func(ctx context.Context) error {
+			// Ignores the context
+			time.Sleep(time.Second)
+			return nil
+		}), WithTimeout(20*time.Millisecond))
+		registry.Register(CheckFunc("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}), WithTimeout(30*time.Millisecond))
+
+		start := time.Now()
+		report := registry.Ready(ctx)
+
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, StatusFail, report.Status)
+		assert.Equal(t, "check did not complete: context deadline exceeded", report.Checks[0].Error)
+		assert.Equal(t, StatusFail, report.Checks[1].Status)
+	})
+}
+
+func TestRegistry_Handlers(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(CheckFunc("cache", func(ctx context.Context) error { return errors.New("connection refused") }))
+
+	tests := []struct {
+		name           string
+		handler        http.Handler
+		expectedCode   int
+		expectedStatus string
+	}{
+		{name: "live", handler: registry.LiveHandler(), expectedCode: http.StatusOK, expectedStatus: StatusPass},
+		{name: "ready", handler: registry.ReadyHandler(), expectedCode: http.StatusServiceUnavailable, expectedStatus: StatusFail},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+			var report Report
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+			assert.Equal(t, tt.expectedStatus, report.Status)
+		})
+	}
+}
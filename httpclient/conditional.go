@@ -0,0 +1,319 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Default conditional transport limits
+const (
+	DefaultMaxEntries   = 256
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// CacheStatusHeader is set on responses served from the cache after a 304 from upstream
+const CacheStatusHeader = "X-Cache"
+
+// ConditionalTransport is an http.RoundTripper that remembers the ETag and Last-Modified
+// validators of GET responses per URL and revalidates with If-None-Match/If-Modified-Since on the
+// next request. When upstream answers 304 Not Modified the cached body is returned as a 200, so
+// callers polling an API see the same response without the upstream re-sending it. Responses are
+// only reused for requests with the same values of the headers they Vary on, and requests carrying
+// credentials (Authorization, Cookie) are passed through.
+type ConditionalTransport struct {
+	// Base performs the requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+
+	// MaxEntries bounds the number of cached URLs, least recently used entries are evicted first,
+	// DefaultMaxEntries when zero
+	MaxEntries int
+
+	// MaxBodyBytes is the largest body that is cached, DefaultMaxBodyBytes when zero
+	MaxBodyBytes int64
+
+	// mu protects entries and lru
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List
+}
+
+// cachedResponse is a stored response and its validators
+type cachedResponse struct {
+	key          string
+	etag         string
+	lastModified string
+	raw          []byte
+
+	// vary holds the request values of the headers the response varies on
+	vary map[string]string
+}
+
+// matches reports whether the request has the header values the response varies on
+func (c *cachedResponse) matches(req *http.Request) bool {
+	for name, value := range c.vary {
+		if strings.Join(req.Header.Values(name), ",") != value {
+			return false
+		}
+	}
+	return true
+}
+
+// NewConditionalTransport creates a conditional transport over base with the default limits
+func NewConditionalTransport(base http.RoundTripper) *ConditionalTransport {
+	return &ConditionalTransport{
+		Base:         base,
+		MaxEntries:   DefaultMaxEntries,
+		MaxBodyBytes: DefaultMaxBodyBytes,
+	}
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *ConditionalTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.cacheable(req) {
+		return t.base().RoundTrip(req)
+	}
+
+	key := req.URL.String()
+	entry := t.get(key)
+	if entry != nil && !entry.matches(req) {
+		// The response stored for another variant is replaced by this request's
+		entry = nil
+	}
+
+	outgoing := req
+	if entry != nil {
+		outgoing = req.Clone(req.Context())
+		if entry.etag != "" {
+			outgoing.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			outgoing.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	resp, err := t.base().RoundTrip(outgoing)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		cached, err := t.refresh(entry, req, resp.Header)
+		if err != nil {
+			t.remove(key)
+			return t.base().RoundTrip(req)
+		}
+		cached.Header.Set(CacheStatusHeader, "REVALIDATED")
+		return cached, nil
+
+	case resp.StatusCode == http.StatusOK:
+		return t.store(key, req, resp)
+
+	default:
+		return resp, nil
+	}
+}
+
+// cacheable reports whether the request can use validators, requests that already carry their
+// own conditions or ranges are passed through untouched
+func (t *ConditionalTransport) cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet {
+		return false
+	}
+	for _, h := range []string{"If-None-Match", "If-Modified-Since", "Range", "Authorization", "Cookie"} {
+		if req.Header.Get(h) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// store caches a 200 response carrying validators and returns an equivalent response to the caller
+func (t *ConditionalTransport) store(key string, req *http.Request, resp *http.Response) (*http.Response, error) {
+	etag := resp.Header.Get("ETag")
+	lastModified := resp.Header.Get("Last-Modified")
+	if (etag == "" && lastModified == "") || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, nil
+	}
+
+	vary, ok := varyValues(req, resp)
+	if !ok {
+		return resp, nil
+	}
+
+	// Read up to the limit, bodies over it are streamed to the caller and not cached
+	maxBodyBytes := t.MaxBodyBytes
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes+1))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	if int64(len(body)) > maxBodyBytes {
+		resp.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), resp.Body), Closer: resp.Body}
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	raw, err := dumpResponse(resp, body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.put(&cachedResponse{key: key, etag: etag, lastModified: lastModified, raw: raw, vary: vary})
+	return resp, nil
+}
+
+// refresh returns the cached response updated with the headers of the 304 revalidating it, like
+// new validators, and stores the updated response
+func (t *ConditionalTransport) refresh(entry *cachedResponse, req *http.Request, header http.Header) (*http.Response, error) {
+	cached, err := http.ReadResponse(bufio.NewReader(bytes.NewReader(entry.raw)), req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(cached.Body)
+	cached.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// The 304 describes the stored body, its framing headers do not
+	for name, values := range header {
+		switch name {
+		case "Content-Length", "Transfer-Encoding", "Content-Encoding", "Content-Range":
+			continue
+		}
+		cached.Header[name] = values
+	}
+
+	raw, err := dumpResponse(cached, body)
+	if err != nil {
+		return nil, err
+	}
+	cached.Body = io.NopCloser(bytes.NewReader(body))
+
+	t.put(&cachedResponse{
+		key:          entry.key,
+		etag:         cached.Header.Get("ETag"),
+		lastModified: cached.Header.Get("Last-Modified"),
+		raw:          raw,
+		vary:         entry.vary,
+	})
+	return cached, nil
+}
+
+// varyValues returns the request values of the headers the response varies on, false when it
+// varies on everything
+func varyValues(req *http.Request, resp *http.Response) (map[string]string, bool) {
+	var vary map[string]string
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if name == "*" {
+				return nil, false
+			}
+			if vary == nil {
+				vary = make(map[string]string)
+			}
+			name = http.CanonicalHeaderKey(name)
+			vary[name] = strings.Join(req.Header.Values(name), ",")
+		}
+	}
+	return vary, true
+}
+
+// get returns the cached entry for a key and marks it as recently used
+func (t *ConditionalTransport) get(key string) *cachedResponse {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[key]; ok {
+		t.lru.MoveToFront(el)
+		return el.Value.(*cachedResponse)
+	}
+	return nil
+}
+
+// put adds or replaces an entry, evicting the least recently used when full
+func (t *ConditionalTransport) put(entry *cachedResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.entries == nil {
+		t.entries = make(map[string]*list.Element)
+		t.lru = list.New()
+	}
+
+	if el, ok := t.entries[entry.key]; ok {
+		el.Value = entry
+		t.lru.MoveToFront(el)
+		return
+	}
+
+	t.entries[entry.key] = t.lru.PushFront(entry)
+
+	maxEntries := t.MaxEntries
+	if maxEntries <= 0 {
+		maxEntries = DefaultMaxEntries
+	}
+	for t.lru.Len() > maxEntries {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*cachedResponse).key)
+	}
+}
+
+// remove drops an entry
+func (t *ConditionalTransport) remove(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.entries[key]; ok {
+		t.lru.Remove(el)
+		delete(t.entries, key)
+	}
+}
+
+// base returns the underlying round tripper
+func (t *ConditionalTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// dumpResponse serializes the response with its full body for later replay
+func dumpResponse(resp *http.Response, body []byte) ([]byte, error) {
+	clone := *resp
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+	clone.ContentLength = int64(len(body))
+	clone.TransferEncoding = nil
+
+	var buf bytes.Buffer
+	if err := clone.Write(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// readCloser pairs a reader with the closer of the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+var _ http.RoundTripper = (*ConditionalTransport)(nil)
@@ -0,0 +1,216 @@
+package httpclient
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionalTransport_RoundTrip(t *testing.T) {
+	var full, notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+		case "/modified":
+			if r.Header.Get("If-Modified-Since") == "Mon, 02 Jan 2006 15:04:05 GMT" {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("Last-Modified", "Mon, 02 Jan 2006 15:04:05 GMT")
+		case "/no-store":
+			w.Header().Set("ETag", `"v1"`)
+			w.Header().Set("Cache-Control", "no-store")
+		}
+		atomic.AddInt32(&full, 1)
+		fmt.Fprintf(w, "body of %s", r.URL.Path)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		path            string
+		wantFull        int32
+		wantNotModified int32
+	}{
+		{path: "/etag", wantFull: 1, wantNotModified: 2},
+		{path: "/modified", wantFull: 1, wantNotModified: 2},
+		{path: "/no-store", wantFull: 3, wantNotModified: 0},
+		{path: "/plain", wantFull: 3, wantNotModified: 0},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.path, func(t *testing.T) {
+			atomic.StoreInt32(&full, 0)
+			atomic.StoreInt32(&notModified, 0)
+			client := &http.Client{Transport: NewConditionalTransport(nil)}
+
+			for i := 0; i < 3; i++ {
+				resp, err := client.Get(server.URL + tt.path)
+				require.NoError(t, err)
+				body, err := io.ReadAll(resp.Body)
+				resp.Body.Close()
+				require.NoError(t, err)
+
+				assert.Equal(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, "body of "+tt.path, string(body))
+				if i > 0 && tt.wantNotModified > 0 {
+					assert.Equal(t, "REVALIDATED", resp.Header.Get(CacheStatusHeader))
+				}
+			}
+
+			assert.Equal(t, tt.wantFull, atomic.LoadInt32(&full))
+			assert.Equal(t, tt.wantNotModified, atomic.LoadInt32(&notModified))
+		})
+	}
+}
+
+func TestConditionalTransport_LargeBodyNotCached(t *testing.T) {
+	large := strings.Repeat("x", 64)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("ETag", `"big"`)
+		io.WriteString(w, large)
+	}))
+	defer server.Close()
+
+	transport := NewConditionalTransport(nil)
+	transport.MaxBodyBytes = 16
+	client := &http.Client{Transport: transport}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, large, string(body))
+	}
+	assert.Empty(t, transport.entries)
+}
+
+func TestConditionalTransport_Eviction(t *testing.T) {
+	transport := &ConditionalTransport{MaxEntries: 2}
+
+	transport.put(&cachedResponse{key: "a"})
+	transport.put(&cachedResponse{key: "b"})
+	transport.get("a")
+	transport.put(&cachedResponse{key: "c"})
+
+	assert.NotNil(t, transport.get("a"))
+	assert.Nil(t, transport.get("b"))
+	assert.NotNil(t, transport.get("c"))
+}
+
+func TestConditionalTransport_DefaultLimits(t *testing.T) {
+	var notModified int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "body")
+	}))
+	defer server.Close()
+
+	// A zero transport caches with the default limits
+	client := &http.Client{Transport: &ConditionalTransport{}}
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+}
+
+func TestConditionalTransport_Vary(t *testing.T) {
+	var full int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		language := r.Header.Get("Accept-Language")
+		if r.Header.Get("If-None-Match") == `"`+language+`"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		w.Header().Set("ETag", `"`+language+`"`)
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprintf(w, "hello in %s", language)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewConditionalTransport(nil)}
+	get := func(language, cookie string) string {
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+		req.Header.Set("Accept-Language", language)
+		if cookie != "" {
+			req.Header.Set("Cookie", cookie)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "hello in en", get("en", ""))
+	assert.Equal(t, "hello in fr", get("fr", ""))
+	assert.Equal(t, "hello in fr", get("fr", ""))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&full))
+
+	// Requests with cookies are not revalidated with the shared entry
+	assert.Equal(t, "hello in fr", get("fr", "session=alice"))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&full))
+}
+
+func TestConditionalTransport_NotModifiedRefreshesValidators(t *testing.T) {
+	var conditions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conditions = append(conditions, r.Header.Get("If-None-Match"))
+		switch r.Header.Get("If-None-Match") {
+		case `"v1"`:
+			// The representation is unchanged but upstream now names it v2
+			w.Header().Set("ETag", `"v2"`)
+			w.Header().Set("Cache-Control", "max-age=60")
+			w.WriteHeader(http.StatusNotModified)
+		case `"v2"`:
+			w.WriteHeader(http.StatusNotModified)
+		default:
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, "body")
+		}
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewConditionalTransport(nil)}
+	var resp *http.Response
+	for i := 0; i < 3; i++ {
+		var err error
+		resp, err = client.Get(server.URL)
+		require.NoError(t, err)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "body", string(body))
+	}
+
+	assert.Equal(t, []string{"", `"v1"`, `"v2"`}, conditions)
+	assert.Equal(t, `"v2"`, resp.Header.Get("ETag"))
+	assert.Equal(t, "max-age=60", resp.Header.Get("Cache-Control"))
+}
@@ -0,0 +1,135 @@
+// Package maxprocs sizes GOMAXPROCS from the CPU quota of the container, so services limited to
+// a few CPUs on a large host do not schedule more threads than they can run and get throttled.
+package maxprocs
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// Sources of the GOMAXPROCS value
+const (
+	SourceConfig = "config"
+	SourceEnv    = "env"
+	SourceCgroup = "cgroup"
+	SourceNone   = "none"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted, it is replaced in tests
+var cgroupRoot = "/sys/fs/cgroup"
+
+// Settings describe the GOMAXPROCS value in effect
+type Settings struct {
+	// Procs is GOMAXPROCS
+	Procs int `json:"procs"`
+
+	// Previous is GOMAXPROCS before Apply
+	Previous int `json:"previous"`
+
+	// Source is where Procs comes from
+	Source string `json:"source"`
+
+	// Quota is the CPU quota of the container in CPUs, 0 when there is none
+	Quota float64 `json:"quota"`
+}
+
+// Apply sets GOMAXPROCS to procs when it is positive, otherwise to the CPU quota of the
+// container rounded down, at least 1 and at most the number of CPUs. A GOMAXPROCS environment
+// variable takes precedence over the quota, but not over procs.
+func Apply(procs int, logger *zap.Logger) (Settings, error) {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	quota, err := CPUQuota()
+	if err != nil {
+		logger.Warn("Failed to read the container CPU quota", zap.Error(err))
+	}
+
+	settings := Settings{Previous: runtime.GOMAXPROCS(0), Quota: quota, Source: SourceNone}
+	switch {
+	case procs > 0:
+		settings.Source = SourceConfig
+		runtime.GOMAXPROCS(procs)
+	case os.Getenv("GOMAXPROCS") != "":
+		// The runtime already applied it
+		settings.Source = SourceEnv
+	case quota > 0:
+		settings.Source = SourceCgroup
+		runtime.GOMAXPROCS(min(max(int(math.Floor(quota)), 1), runtime.NumCPU()))
+	}
+	settings.Procs = runtime.GOMAXPROCS(0)
+
+	logger.Info("Applied GOMAXPROCS",
+		zap.Int("procs", settings.Procs),
+		zap.Int("previous", settings.Previous),
+		zap.String("source", settings.Source),
+		zap.Float64("quota", settings.Quota))
+
+	return settings, err
+}
+
+// CPUQuota returns the CPU quota of the cgroup of the process in CPUs, 0 when there is none or
+// the process does not run in a cgroup
+func CPUQuota() (float64, error) {
+	// cgroup v2: "<quota> <period>", the quota is "max" when unlimited
+	data, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu.max"))
+	if err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 {
+			return 0, fmt.Errorf("invalid cpu.max: %q", data)
+		}
+		if fields[0] == "max" {
+			return 0, nil
+		}
+		return quotaOf(fields[0], fields[1])
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return 0, err
+	}
+
+	// cgroup v1: the quota is -1 when unlimited
+	quota, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_quota_us"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	period, err := os.ReadFile(filepath.Join(cgroupRoot, "cpu", "cpu.cfs_period_us"))
+	if err != nil {
+		return 0, err
+	}
+	if strings.TrimSpace(string(quota)) == "-1" {
+		return 0, nil
+	}
+
+	return quotaOf(strings.TrimSpace(string(quota)), strings.TrimSpace(string(period)))
+}
+
+// quotaOf returns the quota in CPUs of a quota and period in microseconds
+func quotaOf(quota, period string) (float64, error) {
+	q, err := strconv.ParseFloat(quota, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cpu quota %q: %w", quota, err)
+	}
+	p, err := strconv.ParseFloat(period, 64)
+	if err != nil || p <= 0 {
+		return 0, fmt.Errorf("invalid cpu period %q", period)
+	}
+
+	return q / p, nil
+}
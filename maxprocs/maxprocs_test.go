@@ -0,0 +1,129 @@
+package maxprocs
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// useCgroup points the cgroup root to a temporary directory with the files
+func useCgroup(t *testing.T, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	previous := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = previous })
+}
+
+// keepMaxProcs restores GOMAXPROCS once the test ends
+func keepMaxProcs(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() { runtime.GOMAXPROCS(procs) })
+}
+
+func TestCPUQuota(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected float64
+		wantErr  bool
+	}{
+		{name: "cgroup v2", files: map[string]string{"cpu.max": "150000 100000\n"}, expected: 1.5},
+		{name: "cgroup v2 unlimited", files: map[string]string{"cpu.max": "max 100000\n"}},
+		{name: "cgroup v1", files: map[string]string{"cpu/cpu.cfs_quota_us": "200000\n", "cpu/cpu.cfs_period_us": "100000\n"}, expected: 2},
+		{name: "cgroup v1 unlimited", files: map[string]string{"cpu/cpu.cfs_quota_us": "-1\n", "cpu/cpu.cfs_period_us": "100000\n"}},
+		{name: "no cgroup"},
+		{name: "invalid", files: map[string]string{"cpu.max": "lots 100000"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			useCgroup(t, tt.files)
+
+			quota, err := CPUQuota()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, quota)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	t.Run("rounds the quota down", func(t *testing.T) {
+		keepMaxProcs(t)
+		t.Setenv("GOMAXPROCS", "")
+		useCgroup(t, map[string]string{"cpu.max": "150000 100000"})
+
+		settings, err := Apply(0, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, settings.Procs)
+		assert.Equal(t, SourceCgroup, settings.Source)
+		assert.Equal(t, 1.5, settings.Quota)
+		assert.Equal(t, 1, runtime.GOMAXPROCS(0))
+	})
+
+	t.Run("fractional quota gets one proc", func(t *testing.T) {
+		keepMaxProcs(t)
+		t.Setenv("GOMAXPROCS", "")
+		useCgroup(t, map[string]string{"cpu.max": "50000 100000"})
+
+		settings, err := Apply(0, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, settings.Procs)
+	})
+
+	t.Run("override", func(t *testing.T) {
+		keepMaxProcs(t)
+		t.Setenv("GOMAXPROCS", "4")
+		useCgroup(t, map[string]string{"cpu.max": "150000 100000"})
+
+		settings, err := Apply(3, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, settings.Procs)
+		assert.Equal(t, SourceConfig, settings.Source)
+	})
+
+	t.Run("environment takes precedence over the quota", func(t *testing.T) {
+		keepMaxProcs(t)
+		t.Setenv("GOMAXPROCS", "4")
+		useCgroup(t, map[string]string{"cpu.max": "150000 100000"})
+
+		settings, err := Apply(0, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, SourceEnv, settings.Source)
+		assert.Equal(t, settings.Previous, settings.Procs)
+	})
+
+	t.Run("no quota", func(t *testing.T) {
+		keepMaxProcs(t)
+		t.Setenv("GOMAXPROCS", "")
+		useCgroup(t, nil)
+
+		settings, err := Apply(0, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, SourceNone, settings.Source)
+		assert.Equal(t, settings.Previous, settings.Procs)
+	})
+}
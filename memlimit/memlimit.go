@@ -0,0 +1,163 @@
+// Package memlimit sets the Go runtime memory limit (GOMEMLIMIT) and GC target (GOGC) from
+// configuration or the container memory limit, so the garbage collector works harder before
+// the container is OOM-killed.
+package memlimit
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// DefaultHeadroom is the fraction of the container memory limit left for non-heap memory when
+// Config.Headroom is zero
+const DefaultHeadroom = 0.1
+
+// Sources of the memory limit
+const (
+	SourceConfig = "config"
+	SourceEnv    = "env"
+	SourceCgroup = "cgroup"
+	SourceNone   = "none"
+)
+
+// cgroupRoot is where the cgroup filesystem is mounted, it is replaced in tests
+var cgroupRoot = "/sys/fs/cgroup"
+
+// unlimitedV1 is the smallest cgroup v1 limit considered unlimited, v1 reports no limit as the
+// largest page-aligned int64
+const unlimitedV1 = math.MaxInt64 / 2
+
+// Config configures the runtime memory settings
+type Config struct {
+	// MemoryLimit is the memory limit in bytes, the container memory limit minus the headroom
+	// is used when it is zero
+	MemoryLimit int64
+
+	// Headroom is the fraction of the container memory limit left for non-heap memory,
+	// DefaultHeadroom when zero
+	Headroom float64
+
+	// GCPercent sets GOGC, zero keeps the current value and a negative value turns the
+	// collector off until the memory limit is reached
+	GCPercent int
+}
+
+// Settings are the runtime memory settings in effect
+type Settings struct {
+	// MemoryLimit is the memory limit in bytes, math.MaxInt64 when there is none
+	MemoryLimit int64 `json:"memoryLimit"`
+
+	// Source is where the memory limit comes from
+	Source string `json:"source"`
+
+	// ContainerLimit is the container memory limit in bytes, 0 when there is none
+	ContainerLimit int64 `json:"containerLimit"`
+
+	// GCPercent is the GOGC value, negative when the collector is off
+	GCPercent int `json:"gcPercent"`
+}
+
+// Apply sets the memory limit and GC target and logs the values in effect. A GOMEMLIMIT
+// environment variable takes precedence over the detected container limit, but not over an
+// explicit Config.MemoryLimit.
+func Apply(config Config, logger *zap.Logger) (Settings, error) {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	headroom := config.Headroom
+	if headroom <= 0 {
+		headroom = DefaultHeadroom
+	}
+	if headroom >= 1 {
+		return Settings{}, fmt.Errorf("invalid memory headroom %v, must be below 1", headroom)
+	}
+
+	containerLimit, err := ContainerLimit()
+	if err != nil {
+		logger.Warn("Failed to read the container memory limit", zap.Error(err))
+	}
+
+	settings := Settings{ContainerLimit: containerLimit, Source: SourceNone}
+	switch {
+	case config.MemoryLimit > 0:
+		settings.Source = SourceConfig
+		debug.SetMemoryLimit(config.MemoryLimit)
+	case os.Getenv("GOMEMLIMIT") != "":
+		// The runtime already applied it
+		settings.Source = SourceEnv
+	case containerLimit > 0:
+		settings.Source = SourceCgroup
+		debug.SetMemoryLimit(int64(float64(containerLimit) * (1 - headroom)))
+	}
+	settings.MemoryLimit = debug.SetMemoryLimit(-1)
+
+	if config.GCPercent != 0 {
+		debug.SetGCPercent(config.GCPercent)
+	}
+	// SetGCPercent returns the previous value, set it back to read the current one
+	settings.GCPercent = debug.SetGCPercent(100)
+	debug.SetGCPercent(settings.GCPercent)
+
+	logger.Info("Applied runtime memory settings",
+		zap.Int64("memoryLimit", settings.MemoryLimit),
+		zap.String("source", settings.Source),
+		zap.Int64("containerLimit", settings.ContainerLimit),
+		zap.Int("gcPercent", settings.GCPercent))
+
+	return settings, nil
+}
+
+// ContainerLimit returns the memory limit of the cgroup of the process in bytes, 0 when there
+// is none or the process does not run in a cgroup
+func ContainerLimit() (int64, error) {
+	// cgroup v2
+	limit, err := readLimit(filepath.Join(cgroupRoot, "memory.max"))
+	if err == nil || !errors.Is(err, os.ErrNotExist) {
+		return limit, err
+	}
+
+	// cgroup v1
+	limit, err = readLimit(filepath.Join(cgroupRoot, "memory", "memory.limit_in_bytes"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if limit >= unlimitedV1 {
+		return 0, err
+	}
+
+	return limit, err
+}
+
+// readLimit reads a cgroup memory limit file, "max" means there is no limit
+func readLimit(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, nil
+	}
+
+	limit, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory limit in %s: %w", path, err)
+	}
+
+	return limit, nil
+}
@@ -0,0 +1,133 @@
+package memlimit
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// useCgroup points the cgroup root to a temporary directory with the files
+func useCgroup(t *testing.T, files map[string]string) {
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	previous := cgroupRoot
+	cgroupRoot = root
+	t.Cleanup(func() { cgroupRoot = previous })
+}
+
+// keepRuntimeSettings restores the memory limit and GC target once the test ends
+func keepRuntimeSettings(t *testing.T) {
+	limit := debug.SetMemoryLimit(-1)
+	gcPercent := debug.SetGCPercent(100)
+	debug.SetGCPercent(gcPercent)
+	t.Cleanup(func() {
+		debug.SetMemoryLimit(limit)
+		debug.SetGCPercent(gcPercent)
+	})
+}
+
+func TestContainerLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected int64
+		wantErr  bool
+	}{
+		{name: "cgroup v2", files: map[string]string{"memory.max": "536870912\n"}, expected: 512 << 20},
+		{name: "cgroup v2 unlimited", files: map[string]string{"memory.max": "max\n"}},
+		{name: "cgroup v1", files: map[string]string{"memory/memory.limit_in_bytes": "268435456\n"}, expected: 256 << 20},
+		{name: "cgroup v1 unlimited", files: map[string]string{"memory/memory.limit_in_bytes": "9223372036854771712\n"}},
+		{name: "no cgroup"},
+		{name: "invalid", files: map[string]string{"memory.max": "lots"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			useCgroup(t, tt.files)
+
+			limit, err := ContainerLimit()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	t.Run("container limit minus headroom", func(t *testing.T) {
+		keepRuntimeSettings(t)
+		t.Setenv("GOMEMLIMIT", "")
+		useCgroup(t, map[string]string{"memory.max": "1000000000"})
+
+		settings, err := Apply(Config{Headroom: 0.2, GCPercent: -1}, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, Settings{
+			MemoryLimit:    800000000,
+			Source:         SourceCgroup,
+			ContainerLimit: 1000000000,
+			GCPercent:      -1,
+		}, settings)
+		assert.Equal(t, int64(800000000), debug.SetMemoryLimit(-1))
+	})
+
+	t.Run("configured limit", func(t *testing.T) {
+		keepRuntimeSettings(t)
+		t.Setenv("GOMEMLIMIT", "1GiB")
+		useCgroup(t, map[string]string{"memory.max": "1000000000"})
+
+		settings, err := Apply(Config{MemoryLimit: 300 << 20}, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(300<<20), settings.MemoryLimit)
+		assert.Equal(t, SourceConfig, settings.Source)
+	})
+
+	t.Run("environment limit takes precedence over the container", func(t *testing.T) {
+		keepRuntimeSettings(t)
+		t.Setenv("GOMEMLIMIT", "1GiB")
+		useCgroup(t, map[string]string{"memory.max": "1000000000"})
+
+		settings, err := Apply(Config{}, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, SourceEnv, settings.Source)
+	})
+
+	t.Run("no limit", func(t *testing.T) {
+		keepRuntimeSettings(t)
+		t.Setenv("GOMEMLIMIT", "")
+		debug.SetMemoryLimit(math.MaxInt64)
+		useCgroup(t, nil)
+
+		settings, err := Apply(Config{}, logger)
+
+		require.NoError(t, err)
+		assert.Equal(t, SourceNone, settings.Source)
+		assert.Equal(t, int64(math.MaxInt64), settings.MemoryLimit)
+	})
+
+	t.Run("invalid headroom", func(t *testing.T) {
+		_, err := Apply(Config{Headroom: 1}, logger)
+
+		assert.EqualError(t, err, "invalid memory headroom 1, must be below 1")
+	})
+}
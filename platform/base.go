@@ -0,0 +1,194 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/health"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// DefaultHealthPath is the route of the liveness report registered by BaseHTTPService
+const DefaultHealthPath = "/healthz"
+
+// BaseService implements the Initialize boilerplate shared by services, embed it and read the
+// captured dependencies from its fields
+type BaseService struct {
+	// Logger is the logger passed as a dependency, a production logger when none was passed
+	Logger *zap.Logger
+
+	// Metadata is the service metadata passed by the launcher
+	Metadata ServiceMetadata
+
+	// Health is the health registry passed by the launcher, the one the starters expose, an
+	// empty registry when none was passed
+	Health *health.Registry
+}
+
+// Initialize captures the logger, service metadata and health registry from the dependencies
+func (b *BaseService) Initialize(ctx context.Context, deps ...interface{}) error {
+	if logger, ok := DepOf[*zap.Logger](deps...); ok {
+		b.Logger = logger
+	}
+	if b.Logger == nil {
+		var err error
+		b.Logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if metadata, ok := DepOf[ServiceMetadata](deps...); ok {
+		b.Metadata = metadata
+	}
+
+	if registry, ok := DepOf[*health.Registry](deps...); ok {
+		b.Health = registry
+	}
+	if b.Health == nil {
+		b.Health = health.NewRegistry()
+	}
+
+	return nil
+}
+
+// BaseHTTPService implements the boilerplate of HTTP services, embedding services only write
+// their routes:
+//
+//	type MyService struct {
+//		platform.BaseHTTPService
+//	}
+//
+//	func (s *MyService) ConfigureRoutes(ctx context.Context, engine platform.Engine) error {
+//		if err := s.BaseHTTPService.ConfigureRoutes(ctx, engine); err != nil {
+//			return err
+//		}
+//		engine.Handle(http.MethodGet, "/hello", s.hello)
+//		return nil
+//	}
+type BaseHTTPService struct {
+	BaseService
+}
+
+// Type returns HTTPServiceType
+func (b *BaseHTTPService) Type() ServiceType {
+	return HTTPServiceType
+}
+
+// ConfigureRoutes registers the liveness report of the health registry on DefaultHealthPath, the
+// same checks the starters expose on health.LivePath
+func (b *BaseHTTPService) ConfigureRoutes(ctx context.Context, engine Engine) error {
+	registry := b.Health
+	if registry == nil {
+		registry = health.NewRegistry()
+	}
+	engine.Handle(http.MethodGet, DefaultHealthPath, gin.WrapH(registry.LiveHandler()))
+
+	return nil
+}
+
+// BaseGRPCService implements the boilerplate of gRPC services, embedding services only
+// register their implementations, calling BaseGRPCService.RegisterServices for the health service
+type BaseGRPCService struct {
+	BaseService
+}
+
+// Type returns GRPCServiceType
+func (b *BaseGRPCService) Type() ServiceType {
+	return GRPCServiceType
+}
+
+// RegisterServices registers the standard gRPC health service, reporting the server as serving
+// while the readiness checks of the health registry pass
+func (b *BaseGRPCService) RegisterServices(ctx context.Context, server *grpc.Server) error {
+	registry := b.Health
+	if registry == nil {
+		registry = health.NewRegistry()
+	}
+	healthpb.RegisterHealthServer(server, &registryHealthServer{Server: grpchealth.NewServer(), registry: registry})
+	return nil
+}
+
+// registryHealthServer is a gRPC health server reporting the status of the server as a whole, the
+// empty service name, from the readiness checks of the registry. The status is refreshed on each
+// Check and when a Watch starts, watchers are notified of the changes later checks find.
+type registryHealthServer struct {
+	*grpchealth.Server
+	registry *health.Registry
+}
+
+// Check refreshes the status of the server from the registry before reporting it
+func (s *registryHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if req.GetService() == "" {
+		s.refresh(ctx)
+	}
+	return s.Server.Check(ctx, req)
+}
+
+// Watch refreshes the status of the server from the registry before streaming it
+func (s *registryHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if req.GetService() == "" {
+		s.refresh(stream.Context())
+	}
+	return s.Server.Watch(req, stream)
+}
+
+// refresh sets the status of the server from the readiness report of the registry
+func (s *registryHealthServer) refresh(ctx context.Context) {
+	status := healthpb.HealthCheckResponse_SERVING
+	if s.registry.Ready(ctx).Status != health.StatusPass {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	s.SetServingStatus("", status)
+}
+
+// BaseWorkerService implements the boilerplate of Temporal, MQTT and TCP services, which serve no
+// routes. Set Kind to the service type and implement the registration method of that type:
+//
+//	type Ingest struct {
+//		platform.BaseWorkerService
+//	}
+//
+//	func NewIngest() *Ingest {
+//		return &Ingest{BaseWorkerService: platform.BaseWorkerService{Kind: platform.MQTTServiceType}}
+//	}
+//
+//	func (s *Ingest) RegisterHandlers(ctx context.Context, router platform.MQTTRouter) error {
+//		router.Handle("sensors/+/reading", 1, s.reading)
+//		return nil
+//	}
+type BaseWorkerService struct {
+	BaseService
+
+	// Kind is the type returned by Type: TemporalServiceType, MQTTServiceType or TCPServiceType
+	Kind ServiceType
+}
+
+// Initialize captures the dependencies like BaseService and checks Kind is a worker type
+func (b *BaseWorkerService) Initialize(ctx context.Context, deps ...interface{}) error {
+	switch b.Kind {
+	case TemporalServiceType, MQTTServiceType, TCPServiceType:
+	default:
+		return fmt.Errorf("worker service kind %q must be %s, %s or %s",
+			b.Kind, TemporalServiceType, MQTTServiceType, TCPServiceType)
+	}
+
+	return b.BaseService.Initialize(ctx, deps...)
+}
+
+// Type returns Kind
+func (b *BaseWorkerService) Type() ServiceType {
+	return b.Kind
+}
+
+var (
+	_ HTTPService = (*BaseHTTPService)(nil)
+	_ GRPCService = (*BaseGRPCService)(nil)
+	_ Service     = (*BaseWorkerService)(nil)
+)
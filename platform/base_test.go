@@ -0,0 +1,146 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type embeddingHTTPService struct {
+	BaseHTTPService
+}
+
+func (s *embeddingHTTPService) ConfigureRoutes(ctx context.Context, engine Engine) error {
+	if err := s.BaseHTTPService.ConfigureRoutes(ctx, engine); err != nil {
+		return err
+	}
+	engine.Handle(http.MethodGet, "/hello", func(c *gin.Context) {
+		s.Logger.Info("Saying hello")
+		c.String(http.StatusOK, "hello")
+	})
+	return nil
+}
+
+func TestBaseService_Initialize(t *testing.T) {
+	logger := zap.NewNop()
+	metadata := ServiceMetadata{Platform: VM, ServiceType: HTTPServiceType}
+
+	var base BaseService
+	require.NoError(t, base.Initialize(context.Background(), logger, metadata))
+	assert.Same(t, logger, base.Logger)
+	assert.Equal(t, metadata, base.Metadata)
+	assert.NotNil(t, base.Health)
+
+	registry := health.NewRegistry()
+	var withRegistry BaseService
+	require.NoError(t, withRegistry.Initialize(context.Background(), registry))
+	assert.Same(t, registry, withRegistry.Health)
+
+	// A logger is created when none is passed
+	var bare BaseService
+	require.NoError(t, bare.Initialize(context.Background()))
+	assert.NotNil(t, bare.Logger)
+}
+
+func TestBaseHTTPService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := &embeddingHTTPService{}
+	var _ HTTPService = service
+
+	registry := health.NewRegistry()
+	engine := gin.New()
+	require.NoError(t, service.Initialize(context.Background(), zap.NewNop(), registry))
+	require.NoError(t, service.ConfigureRoutes(context.Background(), engine))
+	assert.Equal(t, HTTPServiceType, service.Type())
+
+	for path, want := range map[string]string{DefaultHealthPath: `{"status":"pass","checks":[]}` + "\n", "/hello": "hello"} {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, want, rec.Body.String(), path)
+	}
+
+	// The default health route reports the liveness checks of the shared registry
+	registry.RegisterLiveness(health.CheckFunc("deadlock", func(ctx context.Context) error { return errors.New("stuck") }))
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, DefaultHealthPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+}
+
+func TestBaseGRPCService(t *testing.T) {
+	ctx := context.Background()
+	registry := health.NewRegistry()
+	var checkErr error
+	registry.Register(health.CheckFunc("database", func(ctx context.Context) error { return checkErr }))
+
+	service := &BaseGRPCService{}
+	require.NoError(t, service.Initialize(ctx, registry, zap.NewNop()))
+	server := grpc.NewServer()
+
+	assert.Equal(t, GRPCServiceType, service.Type())
+	require.NoError(t, service.RegisterServices(ctx, server))
+	assert.Contains(t, server.GetServiceInfo(), "grpc.health.v1.Health")
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = server.Serve(ln) }()
+	defer server.Stop()
+
+	conn, err := grpc.NewClient(ln.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	client := healthpb.NewHealthClient(conn)
+
+	// The status follows the readiness checks of the registry
+	for _, tt := range []struct {
+		checkErr error
+		expected healthpb.HealthCheckResponse_ServingStatus
+	}{
+		{checkErr: nil, expected: healthpb.HealthCheckResponse_SERVING},
+		{checkErr: errors.New("connection refused"), expected: healthpb.HealthCheckResponse_NOT_SERVING},
+		{checkErr: nil, expected: healthpb.HealthCheckResponse_SERVING},
+	} {
+		checkErr = tt.checkErr
+		resp, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, resp.GetStatus())
+	}
+}
+
+type embeddingTCPService struct {
+	BaseWorkerService
+}
+
+func (s *embeddingTCPService) HandleConn(ctx context.Context, conn net.Conn) error {
+	return nil
+}
+
+func TestBaseWorkerService(t *testing.T) {
+	service := &embeddingTCPService{BaseWorkerService: BaseWorkerService{Kind: TCPServiceType}}
+	var _ TCPService = service
+
+	logger := zap.NewNop()
+	require.NoError(t, service.Initialize(context.Background(), logger))
+	assert.Equal(t, TCPServiceType, service.Type())
+	assert.Same(t, logger, service.Logger)
+	assert.NotNil(t, service.Health)
+
+	for _, kind := range []ServiceType{"", HTTPServiceType, GRPCServiceType} {
+		service := &BaseWorkerService{Kind: kind}
+		assert.Error(t, service.Initialize(context.Background()), kind)
+	}
+}
@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// cloudFunctionShutdownTimeout bounds how long in-flight invocations may run after SIGTERM
+const cloudFunctionShutdownTimeout = 10 * time.Second
+
+// NewHTTPFunction initializes an HTTP service and returns its engine as a function handler, in the
+// shape expected by the functions framework. Register it from an init function so the same
+// service can be deployed as a Cloud Function:
+//
+//	func init() {
+//		fn, err := platform.NewHTTPFunction(ctx, NewService(), logger, gin.New(), logger)
+//		if err != nil {
+//			log.Fatal(err)
+//		}
+//		functions.HTTP("MyService", fn)
+//	}
+func NewHTTPFunction(ctx context.Context, service HTTPService, logger *zap.Logger, deps ...interface{}) (http.HandlerFunc, error) {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if err := service.Initialize(ctx, deps...); err != nil {
+		logger.Error("Failed to initialize service", zap.Error(err))
+		return nil, fmt.Errorf("failed to initialize service: %w", err)
+	}
+
+	// Find the engine in the dependencies
+	engine, ok := DepOf[Engine](deps...)
+	if !ok {
+		return nil, errors.New("engine not found in dependencies for HTTP service")
+	}
+
+	handler, ok := engine.(http.Handler)
+	if !ok {
+		return nil, errors.New("engine does not implement http.Handler, cannot serve as a function")
+	}
+
+	if err := service.ConfigureRoutes(ctx, engine); err != nil {
+		logger.Error("Failed to configure routes", zap.Error(err))
+		return nil, fmt.Errorf("failed to configure routes: %w", err)
+	}
+	mountHealthEngine(engine, deps...)
+	mountDataSubjectsEngine(engine, logger, deps...)
+
+	return handler.ServeHTTP, nil
+}
+
+// CloudFunctionStarter serves an HTTP service the way the Cloud Functions (2nd gen) runtime
+// does: on the port given by the PORT environment variable, stopping on SIGTERM. It lets the
+// function run locally or in any container with the same wiring used by NewHTTPFunction.
+type CloudFunctionStarter struct {
+	logger *zap.Logger
+	vm     *VMServiceStarter
+}
+
+// NewCloudFunctionStarter creates a new Cloud Functions starter
+func NewCloudFunctionStarter(logger *zap.Logger) *CloudFunctionStarter {
+	vm := NewVMServiceStarter(logger)
+	return &CloudFunctionStarter{
+		logger: vm.logger,
+		vm:     vm,
+	}
+}
+
+// Start serves the function until a shutdown signal is received
+func (s *CloudFunctionStarter) Start(ctx context.Context, service Service, deps ...interface{}) error {
+	httpService, ok := service.(HTTPService)
+	if !ok {
+		return fmt.Errorf("unsupported service type for Cloud Functions platform: %s", service.Type())
+	}
+
+	fn, err := NewHTTPFunction(ctx, httpService, s.logger, deps...)
+	if err != nil {
+		return err
+	}
+
+	stopHooks, err := startLifecycle(ctx, service, s.logger)
+	if err != nil {
+		return err
+	}
+	defer stopHooks()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	server := &http.Server{Addr: ":" + port, Handler: fn}
+
+	// Stop accepting invocations once a shutdown signal is received
+	ctx = s.vm.setupSignalHandling(ctx)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cloudFunctionShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			s.logger.Warn("Function server shutdown did not complete", zap.Error(err))
+		}
+	}()
+
+	s.logger.Info("Serving function", zap.String("port", port))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		s.logger.Error("Function server failed", zap.Error(err))
+		return fmt.Errorf("function server failed: %w", err)
+	}
+
+	// Return once in-flight invocations are drained, before the stop hooks run
+	<-shutdownDone
+
+	return nil
+}
+
+var _ ServiceStarter = (*CloudFunctionStarter)(nil)
@@ -0,0 +1,135 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// newRoutedHTTPService returns a mock HTTP service that registers GET /hello
+func newRoutedHTTPService() *MockHTTPService {
+	service := new(MockHTTPService)
+	service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+	service.On("ConfigureRoutes", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(Engine).Handle(http.MethodGet, "/hello", func(c *gin.Context) {
+			c.String(http.StatusOK, "hello")
+		})
+	})
+	return service
+}
+
+func TestNewHTTPFunction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+
+	t.Run("serves configured routes", func(t *testing.T) {
+		service := newRoutedHTTPService()
+
+		fn, err := NewHTTPFunction(ctx, service, logger, gin.New())
+		require.NoError(t, err)
+
+		rec := httptest.NewRecorder()
+		fn(rec, httptest.NewRequest(http.MethodGet, "/hello", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "hello", rec.Body.String())
+		service.AssertExpectations(t)
+	})
+
+	tests := []struct {
+		name        string
+		service     func() HTTPService
+		deps        []interface{}
+		expectedErr string
+	}{
+		{
+			name: "initialize error",
+			service: func() HTTPService {
+				service := new(MockHTTPService)
+				service.On("Initialize", mock.Anything, mock.Anything).Return(errors.New("initialize error"))
+				return service
+			},
+			expectedErr: "failed to initialize service: initialize error",
+		},
+		{
+			name: "no engine",
+			service: func() HTTPService {
+				service := new(MockHTTPService)
+				service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+				return service
+			},
+			expectedErr: "engine not found in dependencies for HTTP service",
+		},
+		{
+			name: "engine is not an http.Handler",
+			service: func() HTTPService {
+				service := new(MockHTTPService)
+				service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+				return service
+			},
+			deps:        []interface{}{new(MockEngine)},
+			expectedErr: "engine does not implement http.Handler, cannot serve as a function",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			fn, err := NewHTTPFunction(ctx, tt.service(), nil, tt.deps...)
+
+			assert.Nil(t, fn)
+			assert.EqualError(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestCloudFunctionStarter_Start(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("serves on PORT until the context is done", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+		t.Setenv("PORT", strconv.Itoa(port))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- NewCloudFunctionStarter(zaptest.NewLogger(t)).Start(ctx, newRoutedHTTPService(), gin.New())
+		}()
+
+		var resp *http.Response
+		assert.Eventually(t, func() bool {
+			resp, err = http.Get("http://127.0.0.1:" + strconv.Itoa(port) + "/hello")
+			return err == nil
+		}, time.Second, 10*time.Millisecond)
+		require.NotNil(t, resp)
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		cancel()
+		assert.NoError(t, <-done)
+	})
+
+	t.Run("non HTTP service", func(t *testing.T) {
+		service := new(MockService)
+		service.On("Type").Return(ServiceType("unknown"))
+
+		err := NewCloudFunctionStarter(zaptest.NewLogger(t)).Start(context.Background(), service)
+
+		assert.EqualError(t, err, "unsupported service type for Cloud Functions platform: unknown")
+	})
+}
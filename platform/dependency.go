@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// DependencyKey identifies a dependency by type, a dependency satisfies the key when it is
+// assignable to the type, so interface keys are satisfied by any implementation
+type DependencyKey struct {
+	// Name is the type name used in errors, e.g. "platform.Engine"
+	Name string
+
+	// Optional dependencies are reported but do not prevent the service from starting
+	Optional bool
+
+	typ reflect.Type
+}
+
+// Require returns the key of a dependency the service cannot start without
+func Require[T any]() DependencyKey {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	return DependencyKey{Name: typ.String(), typ: typ}
+}
+
+// Optional returns the key of a dependency the service can start without
+func Optional[T any]() DependencyKey {
+	key := Require[T]()
+	key.Optional = true
+	return key
+}
+
+// DepOf returns the first dependency of type T, for interface types the first implementation.
+// It replaces the type assertion loops over deps in starters and Initialize implementations:
+//
+//	engine, ok := platform.DepOf[platform.Engine](deps...)
+func DepOf[T any](deps ...interface{}) (T, bool) {
+	for _, dep := range deps {
+		if d, ok := dep.(T); ok {
+			return d, true
+		}
+	}
+
+	var zero T
+	return zero, false
+}
+
+// MustDep returns the first dependency of type T and panics when there is none, for
+// dependencies a service cannot run without
+func MustDep[T any](deps ...interface{}) T {
+	d, ok := DepOf[T](deps...)
+	if !ok {
+		panic(fmt.Sprintf("missing dependency %s", Require[T]().Name))
+	}
+
+	return d
+}
+
+// DependentService is implemented by services declaring the dependencies they need, so a
+// missing dependency is reported before the service starts
+type DependentService interface {
+	// Requires returns the dependencies the service needs
+	Requires() []DependencyKey
+}
+
+// Initializer is implemented by dependencies that must connect or warm up before the service
+// starts, like database pools or broker clients. The launcher initializes them in parallel, so
+// an Initializer needing another one initialized first must initialize it itself.
+type Initializer interface {
+	// Init prepares the dependency, it must return once ctx is done
+	Init(ctx context.Context) error
+}
+
+// DependencyResolver provides dependencies from a container, like a dependency injector. The
+// launcher passes the resolved dependencies to the service and calls release once it stops.
+type DependencyResolver interface {
+	// Resolve returns the dependencies of the service and the function releasing them
+	Resolve(ctx context.Context, service Service) (deps []interface{}, release func(ctx context.Context) error, err error)
+}
+
+// Satisfied reports whether one of deps satisfies the key
+func (k DependencyKey) Satisfied(deps ...interface{}) bool {
+	for _, dep := range deps {
+		if dep != nil && reflect.TypeOf(dep).AssignableTo(k.typ) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckDependencies verifies that deps satisfy the required dependencies declared by a
+// DependentService, it returns the missing optional dependencies. Services not declaring
+// dependencies always pass.
+func CheckDependencies(service Service, deps ...interface{}) (missingOptional []DependencyKey, err error) {
+	dependent, ok := service.(DependentService)
+	if !ok {
+		return nil, nil
+	}
+
+	var errs []error
+	for _, key := range dependent.Requires() {
+		if key.Satisfied(deps...) {
+			continue
+		}
+		if key.Optional {
+			missingOptional = append(missingOptional, key)
+			continue
+		}
+		errs = append(errs, fmt.Errorf("missing dependency %s required by service %T", key.Name, service))
+	}
+
+	return missingOptional, errors.Join(errs...)
+}
@@ -0,0 +1,111 @@
+package platform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type dependentService struct {
+	MockService
+	requires []DependencyKey
+}
+
+func (s *dependentService) Requires() []DependencyKey {
+	return s.requires
+}
+
+func TestDependencyKey(t *testing.T) {
+	engine := Require[Engine]()
+	assert.Equal(t, "platform.Engine", engine.Name)
+	assert.False(t, engine.Optional)
+
+	// Interface keys are satisfied by implementations
+	assert.True(t, engine.Satisfied("other", gin.New()))
+	assert.False(t, engine.Satisfied("other", nil))
+
+	logger := Optional[*zap.Logger]()
+	assert.Equal(t, "*zap.Logger", logger.Name)
+	assert.True(t, logger.Optional)
+	assert.True(t, logger.Satisfied(zap.NewNop()))
+}
+
+func TestCheckDependencies(t *testing.T) {
+	tests := []struct {
+		name            string
+		service         Service
+		deps            []interface{}
+		wantErr         string
+		wantMissingOpts []string
+	}{
+		{
+			name:    "service without declarations",
+			service: new(MockService),
+		},
+		{
+			name:    "all provided",
+			service: &dependentService{requires: []DependencyKey{Require[Engine](), Optional[*zap.Logger]()}},
+			deps:    []interface{}{gin.New(), zap.NewNop()},
+		},
+		{
+			name:            "missing optional",
+			service:         &dependentService{requires: []DependencyKey{Require[Engine](), Optional[*zap.Logger]()}},
+			deps:            []interface{}{gin.New()},
+			wantMissingOpts: []string{"*zap.Logger"},
+		},
+		{
+			name:    "missing required",
+			service: &dependentService{requires: []DependencyKey{Require[Engine](), Require[context.Context]()}},
+			wantErr: "missing dependency platform.Engine required by service *platform.dependentService\n" +
+				"missing dependency context.Context required by service *platform.dependentService",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			missing, err := CheckDependencies(tt.service, tt.deps...)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, key := range missing {
+				names = append(names, key.Name)
+			}
+			assert.Equal(t, tt.wantMissingOpts, names)
+		})
+	}
+}
+
+func TestDepOf(t *testing.T) {
+	engine := gin.New()
+	logger := zap.NewNop()
+	deps := []interface{}{"other", logger, engine}
+
+	got, ok := DepOf[Engine](deps...)
+	assert.True(t, ok)
+	assert.Same(t, engine, got)
+
+	gotLogger, ok := DepOf[*zap.Logger](deps...)
+	assert.True(t, ok)
+	assert.Same(t, logger, gotLogger)
+
+	_, ok = DepOf[context.Context](deps...)
+	assert.False(t, ok)
+}
+
+func TestMustDep(t *testing.T) {
+	logger := zap.NewNop()
+	assert.Same(t, logger, MustDep[*zap.Logger](logger))
+	assert.PanicsWithValue(t, "missing dependency platform.Engine", func() {
+		MustDep[Engine]("other")
+	})
+}
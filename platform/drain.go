@@ -0,0 +1,145 @@
+package platform
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultHijackedDrainTimeout bounds how long shutdown waits for hijacked connections, like
+// WebSockets, once the HTTP requests are drained
+const DefaultHijackedDrainTimeout = 10 * time.Second
+
+// hijackedPollInterval is how often shutdown checks whether the hijacked connections closed
+const hijackedPollInterval = 50 * time.Millisecond
+
+// drainingKey is the context key of the channel closed when the HTTP server starts draining
+type drainingKey struct{}
+
+// DrainingFromContext returns a channel closed when the HTTP server serving the request starts
+// shutting down, handlers of long-lived connections like WebSockets select on it to close them
+// gracefully. The channel of a context not created by the starters is never closed.
+func DrainingFromContext(ctx context.Context) <-chan struct{} {
+	draining, _ := ctx.Value(drainingKey{}).(chan struct{})
+	return draining
+}
+
+// connTracker counts the open and hijacked connections of an HTTP server
+type connTracker struct {
+	// mu protects conns, mapping each open connection to whether it was hijacked
+	mu    sync.Mutex
+	conns map[net.Conn]bool
+}
+
+// newConnTracker creates a tracker without connections
+func newConnTracker() *connTracker {
+	return &connTracker{conns: make(map[net.Conn]bool)}
+}
+
+// listener wraps ln so the tracker sees the connections it accepts
+func (t *connTracker) listener(ln net.Listener) net.Listener {
+	return &trackedListener{Listener: ln, tracker: t}
+}
+
+// connState marks hijacked connections, it is used as the server's ConnState hook
+func (t *connTracker) connState(conn net.Conn, state http.ConnState) {
+	if state != http.StateHijacked {
+		return
+	}
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		conn = tlsConn.NetConn()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.conns[conn]; ok {
+		t.conns[conn] = true
+	}
+}
+
+// counts returns the number of open connections, hijacked ones included, and hijacked ones
+func (t *connTracker) counts() (open, hijacked int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, isHijacked := range t.conns {
+		if isHijacked {
+			hijacked++
+		}
+	}
+	return len(t.conns), hijacked
+}
+
+// waitHijacked waits for the hijacked connections to close until the context is done, then
+// closes the remaining ones and returns how many were closed
+func (t *connTracker) waitHijacked(ctx context.Context) int {
+	ticker := time.NewTicker(hijackedPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, hijacked := t.counts(); hijacked == 0 {
+			return 0
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return t.closeHijacked()
+		}
+	}
+}
+
+// closeHijacked closes the hijacked connections and returns how many were open
+func (t *connTracker) closeHijacked() int {
+	t.mu.Lock()
+	var conns []net.Conn
+	for conn, hijacked := range t.conns {
+		if hijacked {
+			conns = append(conns, conn)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, conn := range conns {
+		conn.Close()
+	}
+	return len(conns)
+}
+
+// trackedListener registers accepted connections with the tracker
+type trackedListener struct {
+	net.Listener
+	tracker *connTracker
+}
+
+// Accept waits for the next connection and tracks it until it is closed
+func (l *trackedListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	tracked := &trackedConn{Conn: conn, tracker: l.tracker}
+	l.tracker.mu.Lock()
+	l.tracker.conns[tracked] = false
+	l.tracker.mu.Unlock()
+	return tracked, nil
+}
+
+// trackedConn removes itself from the tracker when closed
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+	once    sync.Once
+}
+
+// Close closes the connection and stops tracking it
+func (c *trackedConn) Close() error {
+	c.once.Do(func() {
+		c.tracker.mu.Lock()
+		delete(c.tracker.conns, c)
+		c.tracker.mu.Unlock()
+	})
+	return c.Conn.Close()
+}
@@ -0,0 +1,109 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// hijackingHandler hijacks the connection and closes it once the server drains, unless it ignores
+// draining
+func hijackingHandler(t *testing.T, ignoreDraining bool, hijacked chan<- struct{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if !assert.NoError(t, err) {
+			return
+		}
+		close(hijacked)
+
+		if !ignoreDraining {
+			<-DrainingFromContext(r.Context())
+			conn.Close()
+		}
+	})
+}
+
+func TestVMServiceStarter_serveHTTPDrainsHijackedConnections(t *testing.T) {
+	tests := []struct {
+		name           string
+		ignoreDraining bool
+	}{
+		{name: "handlers close on draining"},
+		{name: "remaining connections are closed after the timeout", ignoreDraining: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := freeAddr(t)
+			hijacked := make(chan struct{})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- NewVMServiceStarter(zaptest.NewLogger(t)).serveHTTP(ctx, hijackingHandler(t, tt.ignoreDraining, hijacked), HTTPConfig{
+					Addr:                 addr,
+					DrainTimeout:         time.Second,
+					HijackedDrainTimeout: 100 * time.Millisecond,
+				})
+			}()
+
+			var conn net.Conn
+			require.Eventually(t, func() bool {
+				var err error
+				conn, err = net.Dial("tcp", addr)
+				return err == nil
+			}, 5*time.Second, 10*time.Millisecond)
+			defer conn.Close()
+
+			_, err := conn.Write([]byte("GET /ws HTTP/1.1\r\nHost: test\r\n\r\n"))
+			require.NoError(t, err)
+			<-hijacked
+
+			cancel()
+			require.NoError(t, <-done)
+
+			// The connection is closed either way once the server returned
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			_, err = bufio.NewReader(conn).ReadByte()
+			assert.Error(t, err)
+			assert.NotErrorIs(t, err, context.DeadlineExceeded)
+		})
+	}
+}
+
+func TestConnTracker(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	tracker := newConnTracker()
+	tracked := tracker.listener(ln)
+	defer tracked.Close()
+
+	client, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	conn, err := tracked.Accept()
+	require.NoError(t, err)
+
+	open, hijacked := tracker.counts()
+	assert.Equal(t, 1, open)
+	assert.Equal(t, 0, hijacked)
+
+	tracker.connState(conn, http.StateHijacked)
+	open, hijacked = tracker.counts()
+	assert.Equal(t, 1, open)
+	assert.Equal(t, 1, hijacked)
+
+	require.NoError(t, conn.Close())
+	open, hijacked = tracker.counts()
+	assert.Equal(t, 0, open)
+	assert.Equal(t, 0, hijacked)
+}
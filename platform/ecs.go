@@ -0,0 +1,217 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// ECSMetadataURIEnv is set by the ECS agent on every container, for both EC2 and Fargate tasks
+	ECSMetadataURIEnv = "ECS_CONTAINER_METADATA_URI_V4"
+
+	// DefaultECSStopTimeout is how long ECS waits after SIGTERM before sending SIGKILL, unless the
+	// task definition sets stopTimeout
+	DefaultECSStopTimeout = 30 * time.Second
+
+	// ecsShutdownMargin is kept from the stop timeout so shutdown completes before SIGKILL
+	ecsShutdownMargin = 5 * time.Second
+
+	// ecsMetadataTimeout bounds the request to the task metadata endpoint
+	ecsMetadataTimeout = 5 * time.Second
+)
+
+// ECSLimits holds the task level resource limits, CPU is in CPU units (1024 per vCPU) and
+// Memory in MiB
+type ECSLimits struct {
+	CPU    float64 `json:"CPU"`
+	Memory int64   `json:"Memory"`
+}
+
+// ECSTaskMetadata describes the ECS task a service runs in, the ECS starter passes it as a
+// dependency to Service.Initialize
+type ECSTaskMetadata struct {
+	Cluster          string    `json:"Cluster"`
+	TaskARN          string    `json:"TaskARN"`
+	Family           string    `json:"Family"`
+	Revision         string    `json:"Revision"`
+	AvailabilityZone string    `json:"AvailabilityZone"`
+	LaunchType       string    `json:"LaunchType"`
+	Limits           ECSLimits `json:"Limits"`
+}
+
+// FetchECSTaskMetadata reads the task metadata from the endpoint given by ECS_CONTAINER_METADATA_URI_V4
+func FetchECSTaskMetadata(ctx context.Context, client *http.Client) (ECSTaskMetadata, error) {
+	var metadata ECSTaskMetadata
+
+	baseURI := os.Getenv(ECSMetadataURIEnv)
+	if baseURI == "" {
+		return metadata, fmt.Errorf("%s is not set, not running on ECS", ECSMetadataURIEnv)
+	}
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, ecsMetadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURI+"/task", nil)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to create task metadata request: %w", err)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return metadata, fmt.Errorf("failed to fetch task metadata: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return metadata, fmt.Errorf("task metadata endpoint returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&metadata); err != nil {
+		return metadata, fmt.Errorf("failed to decode task metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// ECSHealthHandler returns a handler reporting the service as healthy along with the task it
+// runs in, suitable for container and load balancer health checks
+func ECSHealthHandler(m ECSTaskMetadata) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"status":           "ok",
+			"cluster":          m.Cluster,
+			"taskArn":          m.TaskARN,
+			"family":           m.Family,
+			"revision":         m.Revision,
+			"availabilityZone": m.AvailabilityZone,
+		})
+	}
+}
+
+// ECSServiceStarter starts services in ECS tasks on EC2 or Fargate. The task metadata is passed
+// to Service.Initialize, and HTTP services are drained on SIGTERM within the task stop timeout so
+// in-flight requests complete before ECS sends SIGKILL. Other service types run as on a VM.
+type ECSServiceStarter struct {
+	logger *zap.Logger
+	vm     *VMServiceStarter
+
+	// StopTimeout must match the task definition stopTimeout, it defaults to DefaultECSStopTimeout
+	StopTimeout time.Duration
+
+	// Client is used to query the task metadata endpoint, it defaults to http.DefaultClient
+	Client *http.Client
+}
+
+// NewECSServiceStarter creates a new ECS service starter
+func NewECSServiceStarter(logger *zap.Logger) *ECSServiceStarter {
+	vm := NewVMServiceStarter(logger)
+	return &ECSServiceStarter{
+		logger:      vm.logger,
+		vm:          vm,
+		StopTimeout: DefaultECSStopTimeout,
+	}
+}
+
+// Start starts the service with the task metadata added to its dependencies
+func (e *ECSServiceStarter) Start(ctx context.Context, service Service, deps ...interface{}) error {
+	metadata, err := FetchECSTaskMetadata(ctx, e.Client)
+	if err != nil {
+		// Running outside ECS, e.g. locally, should not prevent the service from starting
+		e.logger.Warn("Failed to read ECS task metadata", zap.Error(err))
+	}
+
+	e.logger.Info("Starting service on ECS",
+		zap.String("cluster", metadata.Cluster),
+		zap.String("taskArn", metadata.TaskARN),
+		zap.String("availabilityZone", metadata.AvailabilityZone),
+		zap.Float64("cpuLimit", metadata.Limits.CPU),
+		zap.Int64("memoryLimitMiB", metadata.Limits.Memory))
+
+	deps = append(deps, metadata)
+
+	if service.Type() != HTTPServiceType {
+		return e.vm.Start(ctx, service, deps...)
+	}
+
+	httpService, ok := service.(HTTPService)
+	if !ok {
+		return errors.New("service claims to be HTTP but does not implement HTTPService interface")
+	}
+
+	return e.startHTTPService(ctx, httpService, deps...)
+}
+
+// startHTTPService serves an HTTP service until SIGTERM, then drains it within the stop timeout
+func (e *ECSServiceStarter) startHTTPService(ctx context.Context, service HTTPService, deps ...interface{}) error {
+	fn, err := NewHTTPFunction(ctx, service, e.logger, deps...)
+	if err != nil {
+		return err
+	}
+
+	stopHooks, err := startLifecycle(ctx, service, e.logger)
+	if err != nil {
+		return err
+	}
+	defer stopHooks()
+
+	port := os.Getenv("PORT")
+	if port == "" {
+		port = "8080"
+	}
+
+	server := &http.Server{Addr: ":" + port, Handler: fn}
+
+	drainTimeout := e.drainTimeout()
+
+	ctx = e.vm.setupSignalHandling(ctx)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		e.logger.Info("Draining HTTP server before ECS stop timeout", zap.Duration("drainTimeout", drainTimeout))
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), drainTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			e.logger.Warn("HTTP server shutdown did not complete", zap.Error(err))
+		}
+	}()
+
+	e.logger.Info("Starting HTTP server", zap.String("port", port))
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		e.logger.Error("HTTP server failed", zap.Error(err))
+		return fmt.Errorf("http server failed: %w", err)
+	}
+
+	// Return once in-flight requests are drained, before the stop hooks run
+	<-shutdownDone
+
+	return nil
+}
+
+// drainTimeout returns how long to drain for, leaving a margin before ECS sends SIGKILL
+func (e *ECSServiceStarter) drainTimeout() time.Duration {
+	stopTimeout := e.StopTimeout
+	if stopTimeout <= 0 {
+		stopTimeout = DefaultECSStopTimeout
+	}
+
+	if stopTimeout <= 2*ecsShutdownMargin {
+		return stopTimeout / 2
+	}
+
+	return stopTimeout - ecsShutdownMargin
+}
+
+var _ ServiceStarter = (*ECSServiceStarter)(nil)
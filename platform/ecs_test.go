@@ -0,0 +1,149 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const ecsTaskJSON = `{
+	"Cluster": "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+	"TaskARN": "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c",
+	"Family": "orders",
+	"Revision": "7",
+	"AvailabilityZone": "us-west-2a",
+	"LaunchType": "FARGATE",
+	"Limits": {"CPU": 0.25, "Memory": 512}
+}`
+
+func newECSMetadataServer(t *testing.T, status int) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/task" {
+			http.NotFound(w, r)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(ecsTaskJSON))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestFetchECSTaskMetadata(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     func(t *testing.T)
+		want    ECSTaskMetadata
+		wantErr string
+	}{
+		{
+			name: "reads task metadata",
+			env: func(t *testing.T) {
+				t.Setenv(ECSMetadataURIEnv, newECSMetadataServer(t, http.StatusOK).URL)
+			},
+			want: ECSTaskMetadata{
+				Cluster:          "arn:aws:ecs:us-west-2:111122223333:cluster/default",
+				TaskARN:          "arn:aws:ecs:us-west-2:111122223333:task/default/158d1c8083dd49d6b527399fd6414f5c",
+				Family:           "orders",
+				Revision:         "7",
+				AvailabilityZone: "us-west-2a",
+				LaunchType:       "FARGATE",
+				Limits:           ECSLimits{CPU: 0.25, Memory: 512},
+			},
+		},
+		{
+			name:    "not on ECS",
+			env:     func(t *testing.T) { t.Setenv(ECSMetadataURIEnv, "") },
+			wantErr: "ECS_CONTAINER_METADATA_URI_V4 is not set, not running on ECS",
+		},
+		{
+			name: "endpoint error",
+			env: func(t *testing.T) {
+				t.Setenv(ECSMetadataURIEnv, newECSMetadataServer(t, http.StatusInternalServerError).URL)
+			},
+			wantErr: "task metadata endpoint returned status 500",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			tt.env(t)
+
+			got, err := FetchECSTaskMetadata(context.Background(), nil)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestECSHealthHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/healthz", ECSHealthHandler(ECSTaskMetadata{Cluster: "default", TaskARN: "task-1", Family: "orders", Revision: "7"}))
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "ok", body["status"])
+	assert.Equal(t, "task-1", body["taskArn"])
+	assert.Equal(t, "orders", body["family"])
+}
+
+func TestECSServiceStarter_drainTimeout(t *testing.T) {
+	tests := []struct {
+		stopTimeout time.Duration
+		want        time.Duration
+	}{
+		{stopTimeout: 0, want: 25 * time.Second},
+		{stopTimeout: 30 * time.Second, want: 25 * time.Second},
+		{stopTimeout: 120 * time.Second, want: 115 * time.Second},
+		{stopTimeout: 6 * time.Second, want: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		starter := &ECSServiceStarter{StopTimeout: tt.stopTimeout}
+		assert.Equal(t, tt.want, starter.drainTimeout(), "stop timeout %s", tt.stopTimeout)
+	}
+}
+
+func TestECSServiceStarter_Start(t *testing.T) {
+	t.Setenv(ECSMetadataURIEnv, newECSMetadataServer(t, http.StatusOK).URL)
+
+	starter := NewECSServiceStarter(zaptest.NewLogger(t))
+
+	service := new(MockService)
+	service.On("Initialize", mock.Anything, mock.MatchedBy(func(deps []interface{}) bool {
+		if len(deps) != 1 {
+			return false
+		}
+		metadata, ok := deps[0].(ECSTaskMetadata)
+		return ok && metadata.Family == "orders"
+	})).Return(nil)
+	service.On("Type").Return(ServiceType("unknown"))
+
+	err := starter.Start(context.Background(), service)
+
+	// The metadata reaches Initialize before the VM starter rejects the service type
+	assert.EqualError(t, err, "unsupported service type for VM platform: unknown")
+	service.AssertExpectations(t)
+}
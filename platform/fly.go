@@ -0,0 +1,115 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Fly.io headers
+const (
+	// FlyReplayHeader asks the Fly proxy to replay the request elsewhere, e.g. "region=ord"
+	FlyReplayHeader = "Fly-Replay"
+
+	// FlyRegionHeader is set on responses with the region that served the request
+	FlyRegionHeader = "X-Fly-Region"
+)
+
+// FlyMetadata describes where a service runs on Fly.io, the Fly starter passes it as a dependency
+// to Service.Initialize
+type FlyMetadata struct {
+	AppName       string
+	Region        string
+	PrimaryRegion string
+	MachineID     string
+}
+
+// FlyMetadataFromEnv reads the metadata from the environment Fly.io sets on every machine
+func FlyMetadataFromEnv() FlyMetadata {
+	return FlyMetadata{
+		AppName:       os.Getenv("FLY_APP_NAME"),
+		Region:        os.Getenv("FLY_REGION"),
+		PrimaryRegion: os.Getenv("PRIMARY_REGION"),
+		MachineID:     os.Getenv("FLY_MACHINE_ID"),
+	}
+}
+
+// IsPrimary reports whether the service runs in the primary region, it is true when no primary
+// region is configured
+func (m FlyMetadata) IsPrimary() bool {
+	return m.PrimaryRegion == "" || m.Region == m.PrimaryRegion
+}
+
+// ReplayInPrimary asks the Fly proxy to replay the request in the primary region and aborts it.
+// It returns false, leaving the request untouched, when already running in the primary region.
+func ReplayInPrimary(c *gin.Context, m FlyMetadata) bool {
+	if m.IsPrimary() {
+		return false
+	}
+
+	c.Header(FlyReplayHeader, "region="+m.PrimaryRegion)
+	c.AbortWithStatus(http.StatusConflict)
+	return true
+}
+
+// FlyRegionHeaders returns middleware adding the serving region to every response
+func FlyRegionHeaders(m FlyMetadata) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header(FlyRegionHeader, m.Region)
+		c.Next()
+	}
+}
+
+// FlyWritesToPrimary returns middleware replaying every non-read request in the primary region,
+// for services whose database only accepts writes there
+func FlyWritesToPrimary(m FlyMetadata) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+		default:
+			if !ReplayInPrimary(c, m) {
+				c.Next()
+			}
+		}
+	}
+}
+
+// FlyServiceStarter starts services on Fly.io machines, it behaves like the VM starter and adds
+// region awareness: FlyMetadata is passed to Service.Initialize and HTTP responses carry the
+// serving region
+type FlyServiceStarter struct {
+	logger   *zap.Logger
+	vm       *VMServiceStarter
+	metadata FlyMetadata
+}
+
+// NewFlyServiceStarter creates a new Fly.io service starter reading the metadata from the environment
+func NewFlyServiceStarter(logger *zap.Logger) *FlyServiceStarter {
+	vm := NewVMServiceStarter(logger)
+	return &FlyServiceStarter{
+		logger:   vm.logger,
+		vm:       vm,
+		metadata: FlyMetadataFromEnv(),
+	}
+}
+
+// Start starts the service with the Fly metadata added to its dependencies
+func (f *FlyServiceStarter) Start(ctx context.Context, service Service, deps ...interface{}) error {
+	f.logger.Info("Starting service on Fly.io",
+		zap.String("app", f.metadata.AppName),
+		zap.String("region", f.metadata.Region),
+		zap.String("primaryRegion", f.metadata.PrimaryRegion),
+		zap.Bool("primary", f.metadata.IsPrimary()))
+
+	if router, ok := DepOf[middlewareEngine](deps...); ok {
+		router.Use(FlyRegionHeaders(f.metadata))
+	}
+
+	return f.vm.Start(ctx, service, append(deps, f.metadata)...)
+}
+
+var _ ServiceStarter = (*FlyServiceStarter)(nil)
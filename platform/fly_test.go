@@ -0,0 +1,80 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestFlyMetadataFromEnv(t *testing.T) {
+	t.Setenv("FLY_APP_NAME", "orders")
+	t.Setenv("FLY_REGION", "ams")
+	t.Setenv("PRIMARY_REGION", "ord")
+	t.Setenv("FLY_MACHINE_ID", "abc123")
+
+	m := FlyMetadataFromEnv()
+
+	assert.Equal(t, FlyMetadata{AppName: "orders", Region: "ams", PrimaryRegion: "ord", MachineID: "abc123"}, m)
+	assert.False(t, m.IsPrimary())
+}
+
+func TestFlyMetadata_IsPrimary(t *testing.T) {
+	assert.True(t, FlyMetadata{Region: "ord", PrimaryRegion: "ord"}.IsPrimary())
+	assert.True(t, FlyMetadata{Region: "ams"}.IsPrimary())
+	assert.False(t, FlyMetadata{Region: "ams", PrimaryRegion: "ord"}.IsPrimary())
+}
+
+func TestFlyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		metadata   FlyMetadata
+		method     string
+		wantStatus int
+		wantReplay string
+	}{
+		{name: "read in replica", metadata: FlyMetadata{Region: "ams", PrimaryRegion: "ord"}, method: http.MethodGet, wantStatus: http.StatusOK},
+		{name: "write in replica", metadata: FlyMetadata{Region: "ams", PrimaryRegion: "ord"}, method: http.MethodPost, wantStatus: http.StatusConflict, wantReplay: "region=ord"},
+		{name: "write in primary", metadata: FlyMetadata{Region: "ord", PrimaryRegion: "ord"}, method: http.MethodPost, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			engine := gin.New()
+			engine.Use(FlyRegionHeaders(tt.metadata), FlyWritesToPrimary(tt.metadata))
+			engine.Handle(tt.method, "/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(tt.method, "/orders", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, tt.wantReplay, rec.Header().Get(FlyReplayHeader))
+			assert.Equal(t, tt.metadata.Region, rec.Header().Get(FlyRegionHeader))
+		})
+	}
+}
+
+func TestFlyServiceStarter_Start(t *testing.T) {
+	t.Setenv("FLY_REGION", "ams")
+	t.Setenv("PRIMARY_REGION", "ord")
+
+	starter := NewFlyServiceStarter(zaptest.NewLogger(t))
+
+	service := new(MockService)
+	service.On("Initialize", mock.Anything, []interface{}{FlyMetadata{Region: "ams", PrimaryRegion: "ord"}}).Return(nil)
+	service.On("Type").Return(ServiceType("unknown"))
+
+	err := starter.Start(context.Background(), service)
+
+	// The metadata reaches Initialize before the VM starter rejects the service type
+	assert.EqualError(t, err, "unsupported service type for VM platform: unknown")
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,63 @@
+package platform
+
+import (
+	"net/http"
+
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/jjmaturino/bootstrapper/gdpr"
+	"go.uber.org/zap"
+)
+
+// mountDataSubjectsEngine exposes the data subject requests of the gdpr registry found in deps on
+// the engine. The routes require the gdpr permissions of the authorizer found in deps and are not
+// exposed without one, the authentication middleware setting the subject must be installed on the
+// engine.
+func mountDataSubjectsEngine(engine Engine, logger *zap.Logger, deps ...interface{}) {
+	registry, authorizer, ok := dataSubjectDeps(logger, deps...)
+	if !ok {
+		return
+	}
+
+	engine.Handle(http.MethodPost, gdpr.ExportPath,
+		authorizer.RequirePermission(gdpr.ExportPermission), registry.ExportHandler())
+	engine.Handle(http.MethodPost, gdpr.ErasePath,
+		authorizer.RequirePermission(gdpr.ErasePermission), registry.EraseHandler())
+}
+
+// mountDataSubjectsRouter exposes the data subject requests on the router like
+// mountDataSubjectsEngine, the authentication middleware must set the subject on the request
+// context with authz.WithSubject
+func mountDataSubjectsRouter(router Router, logger *zap.Logger, deps ...interface{}) {
+	registry, authorizer, ok := dataSubjectDeps(logger, deps...)
+	if !ok {
+		return
+	}
+
+	// Patterns use the syntax of the underlying router
+	exportPath, erasePath := gdpr.ExportPattern, gdpr.ErasePattern
+	if _, ok := router.(*GinRouter); ok {
+		exportPath, erasePath = gdpr.ExportPath, gdpr.ErasePath
+	}
+
+	router.Handle(http.MethodPost, exportPath,
+		authorizer.RequirePermissionHandler(gdpr.ExportPermission, registry.ExportHTTPHandler()))
+	router.Handle(http.MethodPost, erasePath,
+		authorizer.RequirePermissionHandler(gdpr.ErasePermission, registry.EraseHTTPHandler()))
+}
+
+// dataSubjectDeps returns the gdpr registry and authorizer found in deps, false when the data
+// subject endpoints must not be exposed
+func dataSubjectDeps(logger *zap.Logger, deps ...interface{}) (*gdpr.Registry, *authz.Authorizer, bool) {
+	registry, ok := DepOf[*gdpr.Registry](deps...)
+	if !ok || registry.Len() == 0 {
+		return nil, nil, false
+	}
+
+	authorizer, ok := DepOf[*authz.Authorizer](deps...)
+	if !ok {
+		logger.Warn("Data subject endpoints not exposed, no authorizer in the dependencies")
+		return nil, nil, false
+	}
+
+	return registry, authorizer, true
+}
@@ -0,0 +1,144 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/jjmaturino/bootstrapper/gdpr"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMountDataSubjectsEngine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	registry := gdpr.NewRegistry(nil, logger)
+	registry.Register(gdpr.Category{
+		Name: "profile",
+		Export: func(ctx context.Context, subject string) (interface{}, error) {
+			return subject, nil
+		},
+	})
+	authorizer := authz.NewAuthorizer(authz.Config{
+		Roles:    map[string][]string{"dpo": {gdpr.ExportPermission}},
+		Bindings: map[string][]string{"alice": {"dpo"}},
+	}, nil, logger)
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			authz.SetSubject(c, subject)
+		}
+	})
+	mountDataSubjectsEngine(engine, logger, registry, authorizer)
+
+	tests := []struct {
+		name    string
+		path    string
+		subject string
+		status  int
+	}{
+		{name: "permitted export", path: "/admin/data-subjects/bob/export", subject: "alice", status: http.StatusOK},
+		{name: "unauthenticated", path: "/admin/data-subjects/bob/export", status: http.StatusUnauthorized},
+		{name: "missing permission", path: "/admin/data-subjects/bob/export", subject: "bob", status: http.StatusForbidden},
+		{name: "erase requires its own permission", path: "/admin/data-subjects/bob/erase", subject: "alice", status: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+			if tt.subject != "" {
+				req.Header.Set("X-Subject", tt.subject)
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestMountDataSubjectsEngine_RequiresAuthorizer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	registry := gdpr.NewRegistry(nil, logger)
+	registry.Register(gdpr.Category{Name: "profile"})
+
+	engine := gin.New()
+	mountDataSubjectsEngine(engine, logger, registry)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/data-subjects/bob/export", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMountDataSubjectsRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := zaptest.NewLogger(t)
+
+	registry := gdpr.NewRegistry(nil, logger)
+	registry.Register(gdpr.Category{
+		Name: "profile",
+		Export: func(ctx context.Context, subject string) (interface{}, error) {
+			return subject, nil
+		},
+	})
+	authorizer := authz.NewAuthorizer(authz.Config{
+		Roles:    map[string][]string{"dpo": {gdpr.ExportPermission}},
+		Bindings: map[string][]string{"alice": {"dpo"}},
+	}, nil, logger)
+
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if subject := r.Header.Get("X-Subject"); subject != "" {
+				r = r.WithContext(authz.WithSubject(r.Context(), subject))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	std := NewStdEngine()
+	std.Use(authenticate)
+	ginRouter := NewGinRouter(gin.New())
+	ginRouter.Use(func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			authz.SetSubject(c, subject)
+		}
+	})
+
+	routers := map[string]Router{"std": std, "gin": ginRouter}
+	for name, router := range routers {
+		router := router
+		t.Run(name, func(t *testing.T) {
+			mountDataSubjectsRouter(router, logger, registry, authorizer)
+
+			tests := []struct {
+				path    string
+				subject string
+				status  int
+			}{
+				{path: "/admin/data-subjects/bob/export", subject: "alice", status: http.StatusOK},
+				{path: "/admin/data-subjects/bob/export", status: http.StatusUnauthorized},
+				{path: "/admin/data-subjects/bob/export", subject: "bob", status: http.StatusForbidden},
+				{path: "/admin/data-subjects/bob/erase", subject: "alice", status: http.StatusForbidden},
+			}
+			for _, tt := range tests {
+				req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+				if tt.subject != "" {
+					req.Header.Set("X-Subject", tt.subject)
+				}
+				rec := httptest.NewRecorder()
+				router.ServeHTTP(rec, req)
+
+				assert.Equal(t, tt.status, rec.Code, "%s as %q", tt.path, tt.subject)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+)
+
+// Default gRPC server settings
+const (
+	DefaultGRPCAddr         = ":50051"
+	DefaultGRPCDrainTimeout = 30 * time.Second
+)
+
+// GRPCConfig configures the gRPC server, pass it as a dependency to override the defaults
+type GRPCConfig struct {
+	// Addr is the address the server listens on, defaults to DefaultGRPCAddr
+	Addr string
+
+	// DrainTimeout bounds how long shutdown waits for in-flight RPCs, defaults to DefaultGRPCDrainTimeout
+	DrainTimeout time.Duration
+
+	// ServerOptions are used to create the server, e.g. interceptors or credentials. They are
+	// ignored when a *grpc.Server is passed as a dependency.
+	ServerOptions []grpc.ServerOption
+}
+
+// startGRPCService starts a gRPC service on the VM runtime platform
+func (v *VMServiceStarter) startGRPCService(ctx context.Context, service GRPCService, deps ...interface{}) error {
+	v.logger.Info("Setting up gRPC service")
+
+	config, _ := DepOf[GRPCConfig](deps...)
+	if config.Addr == "" {
+		config.Addr = DefaultGRPCAddr
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = DefaultGRPCDrainTimeout
+	}
+
+	// Use the server from the dependencies when provided
+	server, _ := DepOf[*grpc.Server](deps...)
+	if server == nil {
+		server = grpc.NewServer(config.ServerOptions...)
+	}
+
+	v.logger.Info("Registering gRPC services")
+	endRegister := TimelineFromContext(ctx).Span("register grpc services")
+	err := service.RegisterServices(ctx, server)
+	endRegister(err)
+	if err != nil {
+		v.logger.Error("Failed to register gRPC services", zap.Error(err))
+		return fmt.Errorf("failed to register grpc services: %w", err)
+	}
+
+	endBind := TimelineFromContext(ctx).Span("bind listener")
+	ln, err := v.tcpListener(config.Addr)
+	endBind(err)
+	if err != nil {
+		return err
+	}
+
+	// Stop the signal and drain goroutines on every return path
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Stop the server once a shutdown signal is received or the context is done, in-flight RPCs
+	// get the drain timeout to complete before being cancelled
+	ctx = v.setupSignalHandling(ctx)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+
+		v.logger.Info("Draining gRPC server", zap.Duration("drainTimeout", config.DrainTimeout))
+		drained := make(chan struct{})
+		go func() {
+			server.GracefulStop()
+			close(drained)
+		}()
+
+		select {
+		case <-drained:
+		case <-time.After(config.DrainTimeout):
+			v.logger.Warn("gRPC server drain did not complete, cancelling remaining RPCs")
+			server.Stop()
+		}
+	}()
+
+	v.logger.Info("Starting gRPC server", zap.String("addr", ln.Addr().String()))
+
+	v.ready(ctx)
+
+	// Serve RPCs (this is blocking), it returns nil once the server is stopped
+	if err := server.Serve(ln); err != nil {
+		v.logger.Error("gRPC server failed", zap.Error(err))
+		return fmt.Errorf("grpc server failed: %w", err)
+	}
+
+	<-stopped
+	v.logger.Info("gRPC server stopped")
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// MockGRPCService is a mock implementation of the GRPCService interface
+type MockGRPCService struct {
+	MockService
+}
+
+func (m *MockGRPCService) RegisterServices(ctx context.Context, server *grpc.Server) error {
+	args := m.Called(ctx, server)
+	return args.Error(0)
+}
+
+func TestVMServiceStarter_startGRPCService(t *testing.T) {
+	t.Run("serves registered services until the context is done", func(t *testing.T) {
+		addr := freeAddr(t)
+
+		service := new(MockGRPCService)
+		service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		service.On("Type").Return(GRPCServiceType)
+		service.On("RegisterServices", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			healthpb.RegisterHealthServer(args.Get(1).(*grpc.Server), health.NewServer())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, GRPCConfig{Addr: addr, DrainTimeout: time.Second})
+		}()
+
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		var resp *healthpb.HealthCheckResponse
+		assert.Eventually(t, func() bool {
+			resp, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+			return err == nil
+		}, 5*time.Second, 20*time.Millisecond)
+		require.NotNil(t, resp)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+
+		cancel()
+		assert.NoError(t, <-done)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("register error", func(t *testing.T) {
+		service := new(MockGRPCService)
+		service.On("RegisterServices", mock.Anything, mock.Anything).Return(errors.New("duplicate service"))
+
+		err := NewVMServiceStarter(zaptest.NewLogger(t)).startGRPCService(context.Background(), service)
+
+		assert.EqualError(t, err, "failed to register grpc services: duplicate service")
+	})
+
+	t.Run("service without GRPCService interface", func(t *testing.T) {
+		service := new(MockService)
+		service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		service.On("Type").Return(GRPCServiceType)
+
+		err := NewVMServiceStarter(zaptest.NewLogger(t)).Start(context.Background(), service)
+
+		assert.EqualError(t, err, "service claims to be gRPC but does not implement GRPCService interface")
+	})
+}
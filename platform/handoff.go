@@ -0,0 +1,173 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// HandoffEnv is set on processes started by a listener handoff. The listener is passed as file
+// descriptor 3 and the new process reports it serves by writing to file descriptor 4.
+const HandoffEnv = "BOOTSTRAP_HANDOFF"
+
+// DefaultHandoffTimeout bounds how long a handoff waits for the new process to serve
+const DefaultHandoffTimeout = 30 * time.Second
+
+// File descriptors of a handoff, after stdin, stdout and stderr
+const (
+	handoffListenerFD = 3
+	handoffReadyFD    = 4
+)
+
+// inherited holds the listener passed by the process handing off to this one
+var inherited struct {
+	// mu protects the fields, the listener is taken by the first starter asking for one
+	mu     sync.Mutex
+	loaded bool
+	ln     net.Listener
+	ready  *os.File
+}
+
+// handoffCommand returns the command starting the process taking over, it is replaced in tests
+var handoffCommand = func() (*exec.Cmd, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd, nil
+}
+
+// inheritedListener returns the listener passed by a handoff, nil when the process was not
+// started by one or the listener was already taken
+func inheritedListener() (net.Listener, error) {
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	if !inherited.loaded {
+		inherited.loaded = true
+		if os.Getenv(HandoffEnv) == "" {
+			return nil, nil
+		}
+		// Processes started by this one must not take the descriptors for theirs
+		os.Unsetenv(HandoffEnv)
+
+		file := os.NewFile(handoffListenerFD, "handoff-listener")
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to use handed off listener: %w", err)
+		}
+		inherited.ln = ln
+		inherited.ready = os.NewFile(handoffReadyFD, "handoff-ready")
+	}
+
+	ln := inherited.ln
+	inherited.ln = nil
+	return ln, nil
+}
+
+// reportHandoffReady tells the process handing off that this one serves, so it can drain
+func reportHandoffReady() {
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	if inherited.ready == nil {
+		return
+	}
+	_, _ = inherited.ready.Write([]byte{1})
+	inherited.ready.Close()
+	inherited.ready = nil
+}
+
+// awaitHandoff hands the listener off to a new process of the executable each time the signal is
+// received, and calls drain once the new process serves
+func (v *VMServiceStarter) awaitHandoff(ctx context.Context, ln net.Listener, sig os.Signal, timeout time.Duration, drain func()) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, sig)
+	defer signal.Stop(signals)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-signals:
+		}
+
+		v.logger.Info("Handing off listener to a new process", zap.String("addr", ln.Addr().String()))
+		pid, err := v.handoff(ln, timeout)
+		if err != nil {
+			// The listener is still ours, keep serving
+			v.logger.Error("Listener handoff failed", zap.Error(err))
+			continue
+		}
+
+		v.logger.Info("Listener handed off, draining", zap.Int("pid", pid))
+		drain()
+		return
+	}
+}
+
+// handoff starts a new process serving on a copy of the listener and waits for it to report it
+// serves, the process is killed when it does not within the timeout
+func (v *VMServiceStarter) handoff(ln net.Listener, timeout time.Duration) (int, error) {
+	if runtime.GOOS == "windows" {
+		return 0, errors.New("listener handoff is not supported on windows")
+	}
+
+	filer, ok := ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return 0, fmt.Errorf("listener %T cannot be handed off", ln)
+	}
+	file, err := filer.File()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get listener file: %w", err)
+	}
+	defer file.Close()
+
+	readyReader, readyWriter, err := os.Pipe()
+	if err != nil {
+		return 0, fmt.Errorf("failed to create handoff pipe: %w", err)
+	}
+	defer readyReader.Close()
+
+	cmd, err := handoffCommand()
+	if err != nil {
+		readyWriter.Close()
+		return 0, err
+	}
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, HandoffEnv+"=1")
+	cmd.ExtraFiles = []*os.File{file, readyWriter}
+
+	err = cmd.Start()
+	readyWriter.Close()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start process: %w", err)
+	}
+
+	// The read fails when the process exits or closes the pipe without reporting
+	_ = readyReader.SetReadDeadline(time.Now().Add(timeout))
+	if n, err := readyReader.Read(make([]byte, 1)); n != 1 {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return 0, fmt.Errorf("process %d did not report it serves: %w", cmd.Process.Pid, err)
+	}
+
+	// The new process outlives this one
+	_ = cmd.Process.Release()
+	return cmd.Process.Pid, nil
+}
@@ -0,0 +1,128 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// handoffHelperEnv makes TestHandoffHelperProcess act as the process taking over
+const handoffHelperEnv = "BOOTSTRAP_HANDOFF_HELPER"
+
+// TestHandoffHelperProcess is the process started by the handoff tests, it serves on the handed
+// off listener for a while
+func TestHandoffHelperProcess(t *testing.T) {
+	if os.Getenv(handoffHelperEnv) == "" {
+		t.Skip("helper process of the handoff tests")
+	}
+
+	ln, err := inheritedListener()
+	if err != nil || ln == nil {
+		os.Exit(1)
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "new process")
+	})}
+	go server.Serve(ln)
+	reportHandoffReady()
+
+	time.Sleep(2 * time.Second)
+	os.Exit(0)
+}
+
+// useHandoffHelper makes handoffs start the helper process, or a process exiting immediately
+func useHandoffHelper(t *testing.T, serves bool) {
+	command := handoffCommand
+	t.Cleanup(func() { handoffCommand = command })
+
+	handoffCommand = func() (*exec.Cmd, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestHandoffHelperProcess$")
+		if serves {
+			cmd.Env = append(os.Environ(), handoffHelperEnv+"=1")
+		}
+		return cmd, nil
+	}
+}
+
+func TestVMServiceStarter_handoff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("listener handoff is not supported on windows")
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	// A process that does not serve keeps the listener with this one
+	useHandoffHelper(t, false)
+	_, err = starter.handoff(ln, 5*time.Second)
+	assert.Error(t, err)
+
+	useHandoffHelper(t, true)
+	pid, err := starter.handoff(ln, 5*time.Second)
+	require.NoError(t, err)
+	assert.NotZero(t, pid)
+
+	// Stop accepting here, the new process serves on the same socket
+	ln.Close()
+	resp, err := http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "new process", string(body))
+}
+
+func TestVMServiceStarter_serveHTTPUsesHandedOffListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	inherited.mu.Lock()
+	loaded := inherited.loaded
+	inherited.loaded, inherited.ln = true, ln
+	inherited.mu.Unlock()
+	t.Cleanup(func() {
+		inherited.mu.Lock()
+		inherited.loaded, inherited.ln = loaded, nil
+		inherited.mu.Unlock()
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		// The configured address is ignored in favour of the handed off listener
+		done <- NewVMServiceStarter(zaptest.NewLogger(t)).serveHTTP(ctx, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}), HTTPConfig{Addr: "256.0.0.1:0", DrainTimeout: time.Second, HijackedDrainTimeout: time.Second})
+	}()
+
+	resp, err := http.Get("http://" + ln.Addr().String())
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestVMServiceStarter_serveHTTPListenFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer taken.Close()
+
+	err = NewVMServiceStarter(zaptest.NewLogger(t)).serveHTTP(context.Background(), http.NotFoundHandler(), HTTPConfig{Addr: taken.Addr().String()})
+	assert.ErrorContains(t, err, "failed to listen")
+}
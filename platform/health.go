@@ -0,0 +1,96 @@
+package platform
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/health"
+)
+
+// mountHealthEngine exposes the health registry found in deps on the engine, skipping the health
+// routes the service already defines
+func mountHealthEngine(engine Engine, deps ...interface{}) {
+	registry, ok := healthRegistry(deps...)
+	if !ok {
+		return
+	}
+
+	routes := map[string]http.Handler{
+		health.LivePath:  registry.LiveHandler(),
+		health.ReadyPath: registry.ReadyHandler(),
+	}
+	for _, path := range []string{health.LivePath, health.ReadyPath} {
+		if engineHandles(engine, http.MethodGet, path) {
+			continue
+		}
+		engine.Handle(http.MethodGet, path, gin.WrapH(routes[path]))
+	}
+}
+
+// mountHealthRouter exposes the health registry found in deps on the router, skipping the health
+// routes the service already defines
+func mountHealthRouter(router Router, deps ...interface{}) {
+	registry, ok := healthRegistry(deps...)
+	if !ok {
+		return
+	}
+
+	routes := map[string]http.Handler{
+		health.LivePath:  registry.LiveHandler(),
+		health.ReadyPath: registry.ReadyHandler(),
+	}
+	for _, path := range []string{health.LivePath, health.ReadyPath} {
+		if routerHandles(router, http.MethodGet, path) {
+			continue
+		}
+		router.Handle(http.MethodGet, path, routes[path])
+	}
+}
+
+// healthRegistry returns the health registry found in deps, with a readiness check for each lazy
+// dependency so one report covers both
+func healthRegistry(deps ...interface{}) (*health.Registry, bool) {
+	registry, ok := DepOf[*health.Registry](deps...)
+	if !ok {
+		return nil, false
+	}
+
+	// Checks replace those of the same name, mounting again on restart adds nothing
+	lazy, names := lazyDeps(deps...)
+	for i, l := range lazy {
+		registry.Register(health.CheckFunc("lazy "+names[i], func(ctx context.Context) error {
+			return l.Ready()
+		}))
+	}
+
+	return registry, true
+}
+
+// engineHandles reports whether the engine has a route for the method and path, false for engines
+// that cannot list their routes
+func engineHandles(engine Engine, method, path string) bool {
+	lister, ok := engine.(interface{ Routes() gin.RoutesInfo })
+	if !ok {
+		return false
+	}
+	for _, route := range lister.Routes() {
+		if route.Method == method && route.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// routerHandles reports whether the router has a route for the method and path, false for
+// routers that cannot be inspected, like those of NewMethodRouter
+func routerHandles(router Router, method, path string) bool {
+	switch r := router.(type) {
+	case *GinRouter:
+		return engineHandles(r.engine, method, path)
+	case *StdEngine:
+		return r.handles(method, path)
+	default:
+		return false
+	}
+}
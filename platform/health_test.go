@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPFunctionExposesHealthChecks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := health.NewRegistry()
+	registry.Register(health.CheckFunc("cache", func(ctx context.Context) error { return errors.New("connection refused") }))
+
+	fn, err := NewHTTPFunction(context.Background(), newRoutedHTTPService(), nil, gin.New(), registry)
+	require.NoError(t, err)
+
+	tests := []struct {
+		path         string
+		expectedCode int
+	}{
+		{path: health.LivePath, expectedCode: http.StatusOK},
+		{path: health.ReadyPath, expectedCode: http.StatusServiceUnavailable},
+		{path: "/hello", expectedCode: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		fn(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		assert.Equal(t, tt.expectedCode, rec.Code, tt.path)
+	}
+}
+
+func TestMountHealthRouter(t *testing.T) {
+	router := NewStdEngine()
+	mountHealthRouter(router, health.NewRegistry())
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// Nothing is mounted without a registry
+	router = NewStdEngine()
+	mountHealthRouter(router)
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestMountHealth_SkipsServiceRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	custom := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusTeapot) }
+
+	engine := gin.New()
+	engine.GET(health.LivePath, gin.WrapF(custom))
+	require.NotPanics(t, func() { mountHealthEngine(engine, health.NewRegistry()) })
+
+	std := NewStdEngine()
+	std.Handle(http.MethodGet, health.LivePath, http.HandlerFunc(custom))
+	std.Handle("", "/", http.NotFoundHandler())
+	require.NotPanics(t, func() { mountHealthRouter(std, health.NewRegistry()) })
+
+	ginRouter := NewGinRouter(gin.New())
+	ginRouter.Handle(http.MethodGet, health.LivePath, http.HandlerFunc(custom))
+	require.NotPanics(t, func() { mountHealthRouter(ginRouter, health.NewRegistry()) })
+
+	for name, handler := range map[string]http.Handler{"engine": engine, "std": std, "gin router": ginRouter} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.LivePath, nil))
+		assert.Equal(t, http.StatusTeapot, rec.Code, name)
+
+		// The route the service left is still mounted
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, name)
+	}
+}
+
+// failingInitializer fails to initialize
+type failingInitializer struct{}
+
+func (failingInitializer) Init(ctx context.Context) error { return errors.New("search unreachable") }
+
+func TestMountHealth_ReportsLazyDependencies(t *testing.T) {
+	ctx := context.Background()
+	lazy := Lazy(failingInitializer{})
+	unused := Lazy(failingInitializer{})
+
+	// Lazy dependencies of the same type each have their own check
+	router := NewStdEngine()
+	mountHealthRouter(router, health.NewRegistry(), lazy, unused)
+
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, health.ReadyPath, nil))
+		return rec.Code
+	}
+
+	// A dependency not used yet does not hold readiness back
+	assert.Equal(t, http.StatusOK, ready())
+
+	_, err := lazy.Get(ctx)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, ready())
+}
@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+	"time"
+)
+
+// Default HTTP server settings
+const (
+	DefaultHTTPAddr         = ":8080"
+	DefaultHTTPDrainTimeout = 30 * time.Second
+)
+
+// HTTPAddrEnv is the environment variable overriding the default listen address of HTTP services
+const HTTPAddrEnv = "BOOTSTRAP_HTTP_ADDR"
+
+// HTTPConfig configures the HTTP server of the VM starter, pass it as a dependency to override
+// the defaults
+type HTTPConfig struct {
+	// Addr is the address the server listens on, defaults to $BOOTSTRAP_HTTP_ADDR, then to
+	// ":$PORT" when PORT is set and to DefaultHTTPAddr otherwise
+	Addr string
+
+	// DrainTimeout bounds how long shutdown waits for in-flight requests, defaults to DefaultHTTPDrainTimeout
+	DrainTimeout time.Duration
+
+	// HijackedDrainTimeout bounds how long shutdown then waits for hijacked connections like
+	// WebSockets before closing them, defaults to DefaultHijackedDrainTimeout
+	HijackedDrainTimeout time.Duration
+
+	// Handoff hands the listener off to a new process of the executable when the signal is
+	// received (e.g. syscall.SIGUSR2), then drains, so a new binary takes over without dropping
+	// connections. The handoff is cancelled when the new process does not serve within
+	// HandoffTimeout, DefaultHandoffTimeout when zero. It is not supported on Windows.
+	Handoff        os.Signal
+	HandoffTimeout time.Duration
+
+	// TLSConfig serves HTTPS when set, defaults to the *tls.Config dependency when there is one
+	TLSConfig *tls.Config
+
+	// CertFile and KeyFile serve HTTPS with the certificate, reloaded when the files change
+	CertFile string
+	KeyFile  string
+
+	// ClientCAs requires clients to present a certificate signed by one of the CAs, handlers
+	// read the verified identity with ClientIdentityFromContext. It requires TLS.
+	ClientCAs *x509.CertPool
+}
+
+// HTTPOption overrides a setting of the HTTP server, pass it as a dependency:
+//
+//	launcher.Start(ctx, service, platform.VM, engine, platform.WithAddr("0.0.0.0:9090"))
+type HTTPOption func(*HTTPConfig)
+
+// WithAddr sets the address the HTTP server listens on
+func WithAddr(addr string) HTTPOption {
+	return func(config *HTTPConfig) {
+		config.Addr = addr
+	}
+}
+
+// WithTLS serves HTTPS with the certificate and key files, the certificate is reloaded when the
+// files change
+func WithTLS(certFile, keyFile string) HTTPOption {
+	return func(config *HTTPConfig) {
+		config.CertFile, config.KeyFile = certFile, keyFile
+	}
+}
+
+// WithTLSConfig serves HTTPS with the TLS config
+func WithTLSConfig(tlsConfig *tls.Config) HTTPOption {
+	return func(config *HTTPConfig) {
+		config.TLSConfig = tlsConfig
+	}
+}
+
+// WithClientCA requires clients to present a certificate signed by a CA of the pool (mutual TLS)
+func WithClientCA(pool *x509.CertPool) HTTPOption {
+	return func(config *HTTPConfig) {
+		config.ClientCAs = pool
+	}
+}
+
+// tls reports whether the server serves HTTPS
+func (c HTTPConfig) tls() bool {
+	return c.TLSConfig != nil || c.CertFile != ""
+}
+
+// httpConfigFrom returns the HTTPConfig found in deps with the HTTPOptions in deps and the
+// defaults applied
+func httpConfigFrom(deps []interface{}) HTTPConfig {
+	config, _ := DepOf[HTTPConfig](deps...)
+	for _, dep := range deps {
+		if option, ok := dep.(HTTPOption); ok {
+			option(&config)
+		}
+	}
+
+	if config.Addr == "" {
+		config.Addr = DefaultHTTPAddr
+		if addr := os.Getenv(HTTPAddrEnv); addr != "" {
+			config.Addr = addr
+		} else if port := os.Getenv("PORT"); port != "" {
+			config.Addr = ":" + port
+		}
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = DefaultHTTPDrainTimeout
+	}
+	if config.HijackedDrainTimeout <= 0 {
+		config.HijackedDrainTimeout = DefaultHijackedDrainTimeout
+	}
+	if config.HandoffTimeout <= 0 {
+		config.HandoffTimeout = DefaultHandoffTimeout
+	}
+	if config.TLSConfig == nil {
+		config.TLSConfig, _ = DepOf[*tls.Config](deps...)
+	}
+
+	return config
+}
@@ -0,0 +1,131 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func freeAddr(t *testing.T) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+	return "127.0.0.1:" + strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func TestHTTPConfigFrom(t *testing.T) {
+	t.Setenv(HTTPAddrEnv, "")
+	t.Setenv("PORT", "")
+	assert.Equal(t, HTTPConfig{
+		Addr:                 DefaultHTTPAddr,
+		DrainTimeout:         DefaultHTTPDrainTimeout,
+		HijackedDrainTimeout: DefaultHijackedDrainTimeout,
+		HandoffTimeout:       DefaultHandoffTimeout,
+	}, httpConfigFrom(nil))
+
+	t.Setenv("PORT", "9090")
+	assert.Equal(t, ":9090", httpConfigFrom(nil).Addr)
+
+	config := httpConfigFrom([]interface{}{"other", HTTPConfig{Addr: ":7000", DrainTimeout: time.Second}})
+	assert.Equal(t, ":7000", config.Addr)
+	assert.Equal(t, time.Second, config.DrainTimeout)
+
+	t.Setenv(HTTPAddrEnv, "127.0.0.1:9091")
+	assert.Equal(t, "127.0.0.1:9091", httpConfigFrom(nil).Addr)
+
+	// Options override the config and the environment
+	config = httpConfigFrom([]interface{}{HTTPConfig{Addr: ":7000", DrainTimeout: time.Second}, WithAddr("0.0.0.0:9090")})
+	assert.Equal(t, "0.0.0.0:9090", config.Addr)
+	assert.Equal(t, time.Second, config.DrainTimeout)
+}
+
+func TestVMServiceStarter_startHTTPServiceGracefulShutdown(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		drainTimeout time.Duration
+		wantBody     bool
+	}{
+		{name: "drains in-flight requests", drainTimeout: 5 * time.Second, wantBody: true},
+		{name: "closes requests exceeding the drain timeout", drainTimeout: 50 * time.Millisecond, wantBody: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := freeAddr(t)
+			started := make(chan struct{})
+			release := make(chan struct{})
+
+			service := new(MockHTTPService)
+			service.On("ConfigureRoutes", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+				args.Get(1).(Engine).Handle(http.MethodGet, "/slow", func(c *gin.Context) {
+					close(started)
+					select {
+					case <-release:
+					case <-c.Request.Context().Done():
+						return
+					}
+					c.String(http.StatusOK, "done")
+				})
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() {
+				done <- NewVMServiceStarter(zaptest.NewLogger(t)).startHTTPService(ctx, service, gin.New(), HTTPConfig{Addr: addr, DrainTimeout: tt.drainTimeout})
+			}()
+
+			type result struct {
+				body string
+				err  error
+			}
+			responses := make(chan result, 1)
+			go func() {
+				var resp *http.Response
+				var err error
+				for i := 0; i < 100; i++ {
+					if resp, err = http.Get("http://" + addr + "/slow"); err == nil {
+						break
+					}
+					time.Sleep(10 * time.Millisecond)
+				}
+				if err != nil {
+					responses <- result{err: err}
+					return
+				}
+				defer resp.Body.Close()
+				body, err := io.ReadAll(resp.Body)
+				responses <- result{body: string(body), err: err}
+			}()
+
+			<-started
+			cancel()
+
+			// The request is still in flight while the server drains
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+
+			assert.NoError(t, <-done)
+
+			got := <-responses
+			if tt.wantBody {
+				require.NoError(t, got.err)
+				assert.Equal(t, "done", got.body)
+			} else {
+				assert.Error(t, got.err)
+			}
+		})
+	}
+}
@@ -3,9 +3,14 @@ package platform
 import (
 	"context"
 	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+	"net"
 )
 
-// Engine is an interface for HTTP engines like Gin
+// Engine is an interface for HTTP engines like Gin, it remains bound to gin handlers. Services that
+// should not depend on gin implement RouterService instead, whose Router takes net/http handlers
+// and is adapted from gin (NewGinRouter), routers registering per method like chi
+// (NewMethodRouter) and the standard library (NewStdEngine).
 type Engine interface {
 	Run(addr ...string) (err error)
 	Handle(method, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes // TODO: Generalize this, Only currently allows for gin
@@ -19,10 +24,79 @@ type HTTPService interface {
 	ConfigureRoutes(ctx context.Context, engine Engine) error
 }
 
+// RouterService defines the interface for HTTP services registering standard net/http handlers,
+// independent of the router serving them
+type RouterService interface {
+	Service
+
+	// RegisterRoutes registers the handlers of the http service on a router
+	RegisterRoutes(ctx context.Context, router Router) error
+}
+
+// TemporalWorkerService defines the interface for services that run a Temporal worker
+type TemporalWorkerService interface {
+	Service
+
+	// RegisterWorkflows registers the workflows and activities of the service on the worker
+	RegisterWorkflows(ctx context.Context, registry TemporalRegistry) error
+}
+
+// MQTTService defines the interface for services that subscribe and publish to MQTT topics
+type MQTTService interface {
+	Service
+
+	// RegisterHandlers registers the topic handlers of the service on the router
+	RegisterHandlers(ctx context.Context, router MQTTRouter) error
+}
+
+// TCPService defines the interface for services that serve a raw TCP protocol
+type TCPService interface {
+	Service
+
+	// HandleConn serves a single connection, the connection is closed when it returns
+	HandleConn(ctx context.Context, conn net.Conn) error
+}
+
+// GRPCService defines the interface for services that serve gRPC
+type GRPCService interface {
+	Service
+
+	// RegisterServices registers the service implementations on the server
+	RegisterServices(ctx context.Context, server *grpc.Server) error
+}
+
+// PausableService is implemented by services that can pause and resume work without stopping,
+// platforms with a pause control (like the Windows Service Control Manager) use it
+type PausableService interface {
+	// Pause temporarily stops the service from taking on new work
+	Pause(ctx context.Context) error
+
+	// Continue resumes a paused service
+	Continue(ctx context.Context) error
+}
+
+// Reloadable is implemented by components that can refresh themselves in place, like TLS
+// certificates, configuration or templates. The launcher reloads them on SIGHUP.
+type Reloadable interface {
+	// Reload re-reads the component's source, the current state is kept when it fails
+	Reload(ctx context.Context) error
+}
+
+// ReloadableService is implemented by services applying configuration changes without
+// restarting. While the service runs, OnConfigChange is called from the starter's signal handling
+// goroutine with the reloaded configuration, a pointer of the same type as the one passed to
+// ServiceLauncher.WatchConfig, so it must be safe to call concurrently with requests.
+type ReloadableService interface {
+	Service
+
+	// OnConfigChange applies the new configuration, the service keeps the current one when it fails
+	OnConfigChange(ctx context.Context, newCfg interface{}) error
+}
+
 // Service is the base interface for all service types
 type Service interface {
 	// Initialize sets up the service with dependencies
-	Initialize(ctx context.Context, deps ...interface{}) error // TODO: Potentially add ability to pass something like deps injector from do'samber
+	Initialize(ctx context.Context, deps ...interface{}) error
 
 	// Type returns the service type
 	Type() ServiceType
@@ -0,0 +1,178 @@
+package platform
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/singleflight"
+)
+
+// LazyState is the initialization state of a lazy dependency
+type LazyState int32
+
+const (
+	// LazyPending is the state of a lazy dependency that was never used
+	LazyPending LazyState = iota
+	// LazyInitializing is the state of a lazy dependency initializing on its first use
+	LazyInitializing
+	// LazyReady is the state of an initialized lazy dependency
+	LazyReady
+	// LazyFailed is the state of a lazy dependency whose last initialization failed, the next
+	// use retries it
+	LazyFailed
+)
+
+// String returns the name of the state
+func (s LazyState) String() string {
+	switch s {
+	case LazyPending:
+		return "pending"
+	case LazyInitializing:
+		return "initializing"
+	case LazyReady:
+		return "ready"
+	case LazyFailed:
+		return "failed"
+	default:
+		return fmt.Sprintf("LazyState(%d)", int32(s))
+	}
+}
+
+// LazyDependency is implemented by dependencies initialized on first use rather than at boot,
+// readiness checks report them through it
+type LazyDependency interface {
+	// State returns the initialization state of the dependency
+	State() LazyState
+
+	// Ready returns the error of the last failed initialization, nil otherwise
+	Ready() error
+}
+
+// LazyDep wraps an Initializer so it initializes on first use instead of before the service
+// starts, for rarely used integrations that should not delay startup. Concurrent first uses
+// share a single initialization.
+type LazyDep[T Initializer] struct {
+	dep   T
+	group singleflight.Group
+
+	mu    sync.Mutex
+	state LazyState
+	err   error
+}
+
+// Lazy marks an Initializer as lazy, the launcher skips it at boot and services get it with
+// Get:
+//
+//	search := platform.MustDep[*platform.LazyDep[*SearchClient]](deps...)
+//	client, err := search.Get(ctx)
+func Lazy[T Initializer](dep T) *LazyDep[T] {
+	return &LazyDep[T]{dep: dep}
+}
+
+// Get initializes the dependency on first use and returns it. The context of the call running
+// the initialization bounds it for every concurrent caller. A failed initialization is retried
+// by the next call.
+func (l *LazyDep[T]) Get(ctx context.Context) (T, error) {
+	l.mu.Lock()
+	if l.state == LazyReady {
+		l.mu.Unlock()
+		return l.dep, nil
+	}
+	l.mu.Unlock()
+
+	_, err, _ := l.group.Do("init", func() (interface{}, error) {
+		l.mu.Lock()
+		if l.state == LazyReady {
+			l.mu.Unlock()
+			return nil, nil
+		}
+		l.state = LazyInitializing
+		l.mu.Unlock()
+
+		err := l.dep.Init(ctx)
+
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if err != nil {
+			l.state, l.err = LazyFailed, err
+			return nil, err
+		}
+		l.state, l.err = LazyReady, nil
+		return nil, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("failed to initialize lazy dependency %T: %w", l.dep, err)
+	}
+
+	return l.dep, nil
+}
+
+// State returns the initialization state of the dependency
+func (l *LazyDep[T]) State() LazyState {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.state
+}
+
+// Ready returns the error of the last failed initialization. A dependency that was not used yet
+// does not hold readiness back, that is the point of initializing it lazily.
+func (l *LazyDep[T]) Ready() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.state == LazyFailed {
+		return l.err
+	}
+	return nil
+}
+
+var _ LazyDependency = (*LazyDep[Initializer])(nil)
+
+// ReadinessHandler returns a handler reporting the state of the lazy dependencies among deps.
+// It responds 503 when the last initialization of one of them failed, 200 otherwise.
+func ReadinessHandler(deps ...interface{}) gin.HandlerFunc {
+	lazy, names := lazyDeps(deps...)
+
+	return func(c *gin.Context) {
+		status, ready := http.StatusOK, "ready"
+		states := gin.H{}
+		for i, l := range lazy {
+			state := gin.H{"state": l.State().String()}
+			if err := l.Ready(); err != nil {
+				state["error"] = err.Error()
+				status, ready = http.StatusServiceUnavailable, "not ready"
+			}
+			states[names[i]] = state
+		}
+
+		c.JSON(status, gin.H{"status": ready, "dependencies": states})
+	}
+}
+
+// lazyDeps returns the lazy dependencies among deps with their names: the type of the dependency,
+// suffixed with its position among those of the same type when several share it
+func lazyDeps(deps ...interface{}) ([]LazyDependency, []string) {
+	var lazy []LazyDependency
+	count := make(map[string]int)
+	for _, dep := range deps {
+		if l, ok := dep.(LazyDependency); ok {
+			lazy = append(lazy, l)
+			count[fmt.Sprintf("%T", l)]++
+		}
+	}
+
+	names := make([]string, len(lazy))
+	seen := make(map[string]int)
+	for i, l := range lazy {
+		name := fmt.Sprintf("%T", l)
+		names[i] = name
+		if count[name] > 1 {
+			seen[name]++
+			names[i] = fmt.Sprintf("%s#%d", name, seen[name])
+		}
+	}
+	return lazy, names
+}
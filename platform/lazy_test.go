@@ -0,0 +1,114 @@
+package platform
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingInitializer struct {
+	calls atomic.Int32
+	delay time.Duration
+	err   error
+}
+
+func (i *countingInitializer) Init(ctx context.Context) error {
+	i.calls.Add(1)
+	time.Sleep(i.delay)
+	return i.err
+}
+
+func TestLazyDep_Get(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("concurrent first uses share one initialization", func(t *testing.T) {
+		dep := &countingInitializer{delay: 50 * time.Millisecond}
+		lazy := Lazy(dep)
+		assert.Equal(t, LazyPending, lazy.State())
+
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				got, err := lazy.Get(ctx)
+				assert.NoError(t, err)
+				assert.Same(t, dep, got)
+			}()
+		}
+		wg.Wait()
+
+		_, err := lazy.Get(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), dep.calls.Load())
+		assert.Equal(t, LazyReady, lazy.State())
+		assert.NoError(t, lazy.Ready())
+	})
+
+	t.Run("failed initialization is retried", func(t *testing.T) {
+		dep := &countingInitializer{err: errors.New("connection refused")}
+		lazy := Lazy(dep)
+
+		got, err := lazy.Get(ctx)
+		assert.Nil(t, got)
+		assert.EqualError(t, err, "failed to initialize lazy dependency *platform.countingInitializer: connection refused")
+		assert.Equal(t, LazyFailed, lazy.State())
+		assert.EqualError(t, lazy.Ready(), "connection refused")
+
+		dep.err = nil
+		_, err = lazy.Get(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), dep.calls.Load())
+		assert.Equal(t, LazyReady, lazy.State())
+	})
+}
+
+func TestReadinessHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	pending := Lazy(&countingInitializer{})
+	failing := Lazy(&countingInitializer{err: errors.New("connection refused")})
+
+	serve := func() (int, map[string]interface{}) {
+		engine := gin.New()
+		engine.GET("/readyz", ReadinessHandler("other", pending, failing))
+
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		return rec.Code, body
+	}
+
+	code, body := serve()
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "ready", body["status"])
+
+	_, err := failing.Get(ctx)
+	require.Error(t, err)
+
+	code, body = serve()
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "not ready", body["status"])
+
+	// Dependencies of the same type are told apart by their position
+	dependencies := body["dependencies"].(map[string]interface{})
+	name := "*platform.LazyDep[*github.com/jjmaturino/bootstrapper/platform.countingInitializer]"
+	assert.Equal(t, map[string]interface{}{"state": "pending"}, dependencies[name+"#1"])
+	assert.Equal(t, map[string]interface{}{
+		"state": "failed",
+		"error": "connection refused",
+	}, dependencies[name+"#2"])
+}
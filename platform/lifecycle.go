@@ -0,0 +1,171 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultHookTimeout bounds each callback of a hook registered without a timeout
+const DefaultHookTimeout = 15 * time.Second
+
+// Hook is a pair of callbacks run when the service starts and stops, like opening and closing a
+// connection pool. Either callback may be nil.
+type Hook struct {
+	// Name identifies the hook in logs and errors
+	Name string
+
+	// OnStart runs once the service is initialized, before it serves
+	OnStart func(ctx context.Context) error
+
+	// OnStop runs during graceful shutdown, once the service stopped serving
+	OnStop func(ctx context.Context) error
+
+	// Timeout bounds each callback, DefaultHookTimeout when zero
+	Timeout time.Duration
+}
+
+// LifecycleHooks is implemented by services registering start and stop hooks, the starters call
+// RegisterHooks after Initialize
+type LifecycleHooks interface {
+	// RegisterHooks appends the hooks of the service to the lifecycle
+	RegisterHooks(lifecycle *Lifecycle)
+}
+
+// Lifecycle runs hooks in registration order on start and in reverse order on stop
+type Lifecycle struct {
+	// mu protects hooks and started
+	mu    sync.Mutex
+	hooks []Hook
+
+	// started is the number of hooks whose OnStart succeeded
+	started int
+
+	logger *zap.Logger
+}
+
+// NewLifecycle creates an empty lifecycle
+func NewLifecycle(logger *zap.Logger) *Lifecycle {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	return &Lifecycle{logger: logger}
+}
+
+// Append registers a hook
+func (l *Lifecycle) Append(hook Hook) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.hooks = append(l.hooks, hook)
+}
+
+// Start runs the OnStart callbacks in registration order. When one fails, the hooks already
+// started are stopped in reverse order and the error is returned.
+func (l *Lifecycle) Start(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks[l.started:]...)
+	l.mu.Unlock()
+
+	for _, hook := range hooks {
+		if hook.OnStart != nil {
+			if err := runHook(ctx, hook, hook.OnStart); err != nil {
+				l.logger.Error("Start hook failed", zap.String("hook", hook.Name), zap.Error(err))
+				if stopErr := l.Stop(context.WithoutCancel(ctx)); stopErr != nil {
+					err = errors.Join(err, stopErr)
+				}
+				return fmt.Errorf("start hook %s failed: %w", hook.Name, err)
+			}
+			l.logger.Info("Ran start hook", zap.String("hook", hook.Name))
+		}
+
+		l.mu.Lock()
+		l.started++
+		l.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Stop runs the OnStop callbacks of the started hooks in reverse order, each under its timeout.
+// A failing hook does not prevent the others from stopping, the returned error joins the failures.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	l.mu.Lock()
+	hooks := append([]Hook(nil), l.hooks[:l.started]...)
+	l.started = 0
+	l.mu.Unlock()
+
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		hook := hooks[i]
+		if hook.OnStop == nil {
+			continue
+		}
+
+		if err := runHook(ctx, hook, hook.OnStop); err != nil {
+			l.logger.Error("Stop hook failed", zap.String("hook", hook.Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("stop hook %s failed: %w", hook.Name, err))
+			continue
+		}
+		l.logger.Info("Ran stop hook", zap.String("hook", hook.Name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// runHook runs a callback under the timeout of the hook, callbacks ignoring the context are
+// abandoned once it is done
+func runHook(ctx context.Context, hook Hook, fn func(ctx context.Context) error) error {
+	timeout := hook.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHookTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("hook did not complete: %w", ctx.Err())
+	}
+}
+
+// startLifecycle runs the start hooks of a service implementing LifecycleHooks and returns the
+// function running its stop hooks, to call once the service stopped serving
+func startLifecycle(ctx context.Context, service Service, logger *zap.Logger) (stop func(), err error) {
+	hooked, ok := service.(LifecycleHooks)
+	if !ok {
+		return func() {}, nil
+	}
+
+	lifecycle := NewLifecycle(logger)
+	hooked.RegisterHooks(lifecycle)
+
+	endStart := TimelineFromContext(ctx).Span("start hooks")
+	err = lifecycle.Start(ctx)
+	endStart(err)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		// The service context is cancelled by then, the hooks get their own timeouts
+		if err := lifecycle.Stop(context.WithoutCancel(ctx)); err != nil {
+			logger.Warn("Stop hooks completed with errors", zap.Error(err))
+		}
+	}, nil
+}
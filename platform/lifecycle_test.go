@@ -0,0 +1,135 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// hookRecorder records the callbacks run by hooks in order
+type hookRecorder struct {
+	mu    sync.Mutex
+	calls []string
+}
+
+func (r *hookRecorder) hook(name string, startErr, stopErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(ctx context.Context) error {
+			r.record("start " + name)
+			return startErr
+		},
+		OnStop: func(ctx context.Context) error {
+			r.record("stop " + name)
+			return stopErr
+		},
+	}
+}
+
+func (r *hookRecorder) record(call string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls = append(r.calls, call)
+}
+
+func (r *hookRecorder) recorded() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.calls...)
+}
+
+func TestLifecycle_StartStop(t *testing.T) {
+	ctx := context.Background()
+	recorder := &hookRecorder{}
+	lifecycle := NewLifecycle(zaptest.NewLogger(t))
+
+	lifecycle.Append(recorder.hook("database", nil, nil))
+	lifecycle.Append(Hook{Name: "cache", OnStop: func(ctx context.Context) error {
+		recorder.record("stop cache")
+		return nil
+	}})
+	lifecycle.Append(recorder.hook("consumer", nil, errors.New("broker gone")))
+
+	require.NoError(t, lifecycle.Start(ctx))
+	err := lifecycle.Stop(ctx)
+
+	// Every hook stops in reverse order even when one fails
+	assert.EqualError(t, err, "stop hook consumer failed: broker gone")
+	assert.Equal(t, []string{
+		"start database", "start consumer",
+		"stop consumer", "stop cache", "stop database",
+	}, recorder.recorded())
+
+	// Hooks are only stopped once
+	assert.NoError(t, lifecycle.Stop(ctx))
+}
+
+func TestLifecycle_StartFailureStopsStartedHooks(t *testing.T) {
+	ctx := context.Background()
+	recorder := &hookRecorder{}
+	lifecycle := NewLifecycle(zaptest.NewLogger(t))
+
+	lifecycle.Append(recorder.hook("database", nil, nil))
+	lifecycle.Append(recorder.hook("cache", errors.New("connection refused"), nil))
+	lifecycle.Append(recorder.hook("consumer", nil, nil))
+
+	err := lifecycle.Start(ctx)
+
+	assert.EqualError(t, err, "start hook cache failed: connection refused")
+	assert.Equal(t, []string{"start database", "start cache", "stop database"}, recorder.recorded())
+}
+
+func TestLifecycle_HookTimeout(t *testing.T) {
+	lifecycle := NewLifecycle(zaptest.NewLogger(t))
+
+	// A hook ignoring its context is abandoned
+	block := make(chan struct{})
+	defer close(block)
+	lifecycle.Append(Hook{
+		Name:    "flush",
+		Timeout: 10 * time.Millisecond,
+		OnStop: func(ctx context.Context) error {
+			<-block
+			return nil
+		},
+	})
+
+	require.NoError(t, lifecycle.Start(context.Background()))
+	err := lifecycle.Stop(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// hookedService registers the hooks of a recorder, its type is not served by the VM starter
+type hookedService struct {
+	recorder *hookRecorder
+	startErr error
+}
+
+func (s *hookedService) Initialize(ctx context.Context, deps ...interface{}) error { return nil }
+func (s *hookedService) Type() ServiceType                                         { return "custom" }
+func (s *hookedService) RegisterHooks(lifecycle *Lifecycle) {
+	lifecycle.Append(s.recorder.hook("database", s.startErr, nil))
+}
+
+func TestVMServiceStarter_RunsLifecycleHooks(t *testing.T) {
+	ctx := context.Background()
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	recorder := &hookRecorder{}
+	err := starter.Start(ctx, &hookedService{recorder: recorder})
+
+	// The stop hooks run when the service returns
+	assert.EqualError(t, err, "unsupported service type for VM platform: custom")
+	assert.Equal(t, []string{"start database", "stop database"}, recorder.recorded())
+
+	recorder = &hookRecorder{}
+	err = starter.Start(ctx, &hookedService{recorder: recorder, startErr: errors.New("connection refused")})
+	assert.EqualError(t, err, "start hook database failed: connection refused")
+	assert.Equal(t, []string{"start database"}, recorder.recorded())
+}
@@ -0,0 +1,19 @@
+package platform
+
+import "time"
+
+// ServiceMetadata describes the service being started, the launcher passes it to
+// Service.Initialize along with the other dependencies
+type ServiceMetadata struct {
+	// Platform is the platform the service runs on
+	Platform Type
+
+	// ServiceType is the type reported by the service
+	ServiceType ServiceType
+
+	// Hostname is the host the service runs on
+	Hostname string
+
+	// StartedAt is when the launcher started the service
+	StartedAt time.Time
+}
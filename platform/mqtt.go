@@ -0,0 +1,174 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"go.uber.org/zap"
+)
+
+// MQTTRouter registers topic handlers and publishes messages for an MQTT service
+type MQTTRouter interface {
+	// Handle registers a handler for a topic filter at the requested QoS, subscriptions are
+	// restored automatically after a reconnect
+	Handle(topic string, qos byte, handler mqtt.MessageHandler)
+
+	// Publish sends a message on a topic and waits for it to be acknowledged according to its QoS
+	Publish(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) error
+}
+
+// mqttDisconnectQuiesce is how long the client waits for in-flight work before disconnecting, in milliseconds
+const mqttDisconnectQuiesce = 250
+
+// mqttRouter tracks topic subscriptions and (re)subscribes them on every connection
+type mqttRouter struct {
+	client mqtt.Client
+	logger *zap.Logger
+
+	// subscriptionsMu protects the subscriptions
+	subscriptionsMu sync.RWMutex
+	subscriptions   map[string]mqttSubscription
+}
+
+// mqttSubscription is a topic handler with its requested QoS
+type mqttSubscription struct {
+	qos     byte
+	handler mqtt.MessageHandler
+}
+
+// Handle registers a handler for a topic filter at the requested QoS
+func (r *mqttRouter) Handle(topic string, qos byte, handler mqtt.MessageHandler) {
+	r.subscriptionsMu.Lock()
+	defer r.subscriptionsMu.Unlock()
+
+	if _, exists := r.subscriptions[topic]; exists {
+		r.logger.Warn("Overriding existing MQTT topic handler", zap.String("topic", topic))
+	}
+	r.subscriptions[topic] = mqttSubscription{qos: qos, handler: handler}
+}
+
+// Publish sends a message on a topic and waits for it to be acknowledged according to its QoS
+func (r *mqttRouter) Publish(ctx context.Context, topic string, qos byte, retained bool, payload interface{}) error {
+	return waitMQTTToken(ctx, r.client.Publish(topic, qos, retained, payload))
+}
+
+// subscribeAll subscribes every registered topic, it is used as the client's OnConnect handler so
+// subscriptions are restored after a reconnect
+func (r *mqttRouter) subscribeAll(client mqtt.Client) {
+	r.subscriptionsMu.RLock()
+	defer r.subscriptionsMu.RUnlock()
+
+	for topic, sub := range r.subscriptions {
+		token := client.Subscribe(topic, sub.qos, sub.handler)
+		token.Wait()
+		if err := token.Error(); err != nil {
+			r.logger.Error("Failed to subscribe to MQTT topic", zap.String("topic", topic), zap.Error(err))
+			continue
+		}
+		r.logger.Info("Subscribed to MQTT topic", zap.String("topic", topic), zap.Uint8("qos", sub.qos))
+	}
+}
+
+// topics returns the registered topic filters
+func (r *mqttRouter) topics() []string {
+	r.subscriptionsMu.RLock()
+	defer r.subscriptionsMu.RUnlock()
+
+	topics := make([]string, 0, len(r.subscriptions))
+	for topic := range r.subscriptions {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+var _ MQTTRouter = (*mqttRouter)(nil)
+
+// startMQTTService starts an MQTT service on the VM runtime platform. The client options found in
+// deps are copied, so the caller's handlers are not wrapped again by a restart.
+func (v *VMServiceStarter) startMQTTService(ctx context.Context, service MQTTService, deps ...interface{}) error {
+	v.logger.Info("Setting up MQTT service")
+
+	// Find the client options in the dependencies
+	depOpts, _ := DepOf[*mqtt.ClientOptions](deps...)
+	if depOpts == nil {
+		return errors.New("mqtt client options not found in dependencies for MQTT service")
+	}
+	clientOpts := *depOpts
+	opts := &clientOpts
+
+	router := &mqttRouter{
+		logger:        v.logger,
+		subscriptions: make(map[string]mqttSubscription),
+	}
+
+	// Subscriptions are (re)established on every connection, preserving any user callback
+	onConnect := opts.OnConnect
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		v.logger.Info("Connected to MQTT broker")
+		router.subscribeAll(client)
+		if onConnect != nil {
+			onConnect(client)
+		}
+	})
+	onConnectionLost := opts.OnConnectionLost
+	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) {
+		v.logger.Warn("Lost connection to MQTT broker", zap.Error(err))
+		if onConnectionLost != nil {
+			onConnectionLost(client, err)
+		}
+	})
+	opts.SetAutoReconnect(true)
+
+	router.client = mqtt.NewClient(opts)
+
+	// Register topic handlers before connecting so the first OnConnect subscribes them
+	v.logger.Info("Registering MQTT topic handlers")
+	endRegister := TimelineFromContext(ctx).Span("register mqtt handlers")
+	err := service.RegisterHandlers(ctx, router)
+	endRegister(err)
+	if err != nil {
+		v.logger.Error("Failed to register MQTT handlers", zap.Error(err))
+		return fmt.Errorf("failed to register mqtt handlers: %w", err)
+	}
+
+	v.logger.Info("Connecting to MQTT broker")
+	endConnect := TimelineFromContext(ctx).Span("connect mqtt broker")
+	err = waitMQTTToken(ctx, router.client.Connect())
+	endConnect(err)
+	if err != nil {
+		// Stops the connection retries of a client still connecting
+		router.client.Disconnect(0)
+		v.logger.Error("Failed to connect to MQTT broker", zap.Error(err))
+		return fmt.Errorf("failed to connect to mqtt broker: %w", err)
+	}
+	v.ready(ctx)
+
+	// Block until a shutdown signal is received or the context is done
+	<-v.setupSignalHandling(ctx).Done()
+
+	v.logger.Info("Stopping MQTT service")
+	if topics := router.topics(); len(topics) > 0 {
+		token := router.client.Unsubscribe(topics...)
+		if !token.WaitTimeout(time.Duration(mqttDisconnectQuiesce) * time.Millisecond) {
+			v.logger.Warn("Timed out unsubscribing from MQTT topics")
+		}
+	}
+	router.client.Disconnect(mqttDisconnectQuiesce)
+	v.logger.Info("MQTT service stopped")
+
+	return nil
+}
+
+// waitMQTTToken waits for a token to complete or the context to be done
+func waitMQTTToken(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-token.Done():
+		return token.Error()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,175 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap/zaptest"
+)
+
+// MockMQTTService is a mock implementation of the MQTTService interface
+type MockMQTTService struct {
+	MockService
+}
+
+func (m *MockMQTTService) RegisterHandlers(ctx context.Context, router MQTTRouter) error {
+	args := m.Called(ctx, router)
+	return args.Error(0)
+}
+
+// fakeMQTTToken is a completed token carrying an optional error
+type fakeMQTTToken struct {
+	err  error
+	done chan struct{}
+}
+
+func newFakeMQTTToken(err error) *fakeMQTTToken {
+	done := make(chan struct{})
+	close(done)
+	return &fakeMQTTToken{err: err, done: done}
+}
+
+func (t *fakeMQTTToken) Wait() bool                     { return true }
+func (t *fakeMQTTToken) WaitTimeout(time.Duration) bool { return true }
+func (t *fakeMQTTToken) Done() <-chan struct{}          { return t.done }
+func (t *fakeMQTTToken) Error() error                   { return t.err }
+
+// fakeMQTTClient records subscriptions, unimplemented methods panic through the nil embedded client
+type fakeMQTTClient struct {
+	mqtt.Client
+	subscribed map[string]byte
+	failTopic  string
+}
+
+func (c *fakeMQTTClient) Subscribe(topic string, qos byte, callback mqtt.MessageHandler) mqtt.Token {
+	if topic == c.failTopic {
+		return newFakeMQTTToken(errors.New("not authorized"))
+	}
+	c.subscribed[topic] = qos
+	return newFakeMQTTToken(nil)
+}
+
+func TestMQTTRouter_subscribeAll(t *testing.T) {
+	router := &mqttRouter{
+		logger:        zaptest.NewLogger(t),
+		subscriptions: make(map[string]mqttSubscription),
+	}
+	handler := func(mqtt.Client, mqtt.Message) {}
+
+	router.Handle("sensors/+/temperature", 1, handler)
+	router.Handle("sensors/+/humidity", 0, handler)
+	router.Handle("admin/#", 2, handler)
+
+	client := &fakeMQTTClient{subscribed: map[string]byte{}, failTopic: "admin/#"}
+	router.subscribeAll(client)
+
+	// A failed subscription does not prevent the others
+	assert.Equal(t, map[string]byte{
+		"sensors/+/temperature": 1,
+		"sensors/+/humidity":    0,
+	}, client.subscribed)
+	assert.ElementsMatch(t, []string{"sensors/+/temperature", "sensors/+/humidity", "admin/#"}, router.topics())
+}
+
+func TestWaitMQTTToken(t *testing.T) {
+	assert.NoError(t, waitMQTTToken(context.Background(), newFakeMQTTToken(nil)))
+	assert.EqualError(t, waitMQTTToken(context.Background(), newFakeMQTTToken(errors.New("boom"))), "boom")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pending := &fakeMQTTToken{done: make(chan struct{})}
+	assert.ErrorIs(t, waitMQTTToken(ctx, pending), context.Canceled)
+}
+
+func TestVMServiceStarter_startMQTTService(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name        string
+		service     func() MQTTService
+		deps        []interface{}
+		expectedErr string
+	}{
+		{
+			name: "no client options provided",
+			service: func() MQTTService {
+				return new(MockMQTTService)
+			},
+			deps:        []interface{}{},
+			expectedErr: "mqtt client options not found in dependencies for MQTT service",
+		},
+		{
+			name: "register handlers error",
+			service: func() MQTTService {
+				mockService := new(MockMQTTService)
+				mockService.On("RegisterHandlers", mock.Anything, mock.Anything).Return(errors.New("register error"))
+				return mockService
+			},
+			deps:        []interface{}{mqtt.NewClientOptions().AddBroker("tcp://127.0.0.1:1")},
+			expectedErr: "failed to register mqtt handlers: register error",
+		},
+		{
+			name: "broker unreachable",
+			service: func() MQTTService {
+				mockService := new(MockMQTTService)
+				mockService.On("RegisterHandlers", mock.Anything, mock.Anything).Return(nil)
+				return mockService
+			},
+			deps:        []interface{}{mqtt.NewClientOptions().AddBroker("tcp://127.0.0.1:1").SetConnectTimeout(time.Second)},
+			expectedErr: "failed to connect to mqtt broker",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			starter := NewVMServiceStarter(logger)
+			service := tt.service()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := starter.startMQTTService(ctx, service, tt.deps...)
+
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.expectedErr)
+			service.(*MockMQTTService).AssertExpectations(t)
+		})
+	}
+}
+
+func TestVMServiceStarter_startMQTTServiceKeepsOptions(t *testing.T) {
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	mockService := new(MockMQTTService)
+	mockService.On("RegisterHandlers", mock.Anything, mock.Anything).Return(nil)
+
+	opts := mqtt.NewClientOptions().
+		AddBroker("tcp://127.0.0.1:1").
+		SetConnectRetry(true).
+		SetConnectRetryInterval(10 * time.Millisecond).
+		SetOnConnectHandler(func(mqtt.Client) {}).
+		SetConnectionLostHandler(func(mqtt.Client, error) {}).
+		SetAutoReconnect(false)
+	onConnect := reflect.ValueOf(opts.OnConnect).Pointer()
+	onConnectionLost := reflect.ValueOf(opts.OnConnectionLost).Pointer()
+
+	// The connect retries until the context is done, then the client is disconnected
+	for i := 0; i < 2; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		err := starter.startMQTTService(ctx, mockService, opts)
+		cancel()
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}
+
+	// The caller's handlers are not replaced by the starter's
+	assert.Equal(t, onConnect, reflect.ValueOf(opts.OnConnect).Pointer())
+	assert.Equal(t, onConnectionLost, reflect.ValueOf(opts.OnConnectionLost).Pointer())
+	assert.False(t, opts.AutoReconnect)
+}
@@ -0,0 +1,33 @@
+package platform
+
+import (
+	"context"
+	"time"
+)
+
+// ConfigReloader reloads the configuration of a running service. The launcher carries one in the
+// context passed to starters when it watches a config, and the starter's signal handling
+// goroutine drives it: on SIGHUP and every PollInterval.
+type ConfigReloader interface {
+	// ReloadConfig loads the configuration again and delivers it to the service
+	ReloadConfig(ctx context.Context)
+
+	// PollInterval is how often ReloadIfChanged runs, zero when there is no file to watch
+	PollInterval() time.Duration
+
+	// ReloadIfChanged reloads the configuration when its file changed since it was last seen
+	ReloadIfChanged(ctx context.Context)
+}
+
+type configReloaderKey struct{}
+
+// WithConfigReloader returns a context carrying the config reloader
+func WithConfigReloader(ctx context.Context, r ConfigReloader) context.Context {
+	return context.WithValue(ctx, configReloaderKey{}, r)
+}
+
+// ConfigReloaderFromContext returns the config reloader carried by the context, if any
+func ConfigReloaderFromContext(ctx context.Context) (ConfigReloader, bool) {
+	r, ok := ctx.Value(configReloaderKey{}).(ConfigReloader)
+	return r, ok
+}
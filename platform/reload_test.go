@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"context"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// countingReloader counts the reloads run by the signal handling goroutine
+type countingReloader struct {
+	interval time.Duration
+	reloads  atomic.Int32
+	polls    atomic.Int32
+}
+
+func (r *countingReloader) ReloadConfig(ctx context.Context)    { r.reloads.Add(1) }
+func (r *countingReloader) PollInterval() time.Duration         { return r.interval }
+func (r *countingReloader) ReloadIfChanged(ctx context.Context) { r.polls.Add(1) }
+
+func TestSetupSignalHandling_ReloadsConfig(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not delivered on Windows")
+	}
+
+	reloader := &countingReloader{interval: 5 * time.Millisecond}
+	ctx, cancel := context.WithCancel(WithConfigReloader(context.Background(), reloader))
+	defer cancel()
+
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+	signalCtx := starter.setupSignalHandling(ctx)
+
+	require.Eventually(t, func() bool { return reloader.polls.Load() > 0 }, time.Second, time.Millisecond)
+
+	// SIGHUP reloads the config without stopping the service
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGHUP))
+	require.Eventually(t, func() bool { return reloader.reloads.Load() == 1 }, time.Second, time.Millisecond)
+	assert.NoError(t, signalCtx.Err())
+}
+
+func TestConfigReloaderFromContext(t *testing.T) {
+	_, ok := ConfigReloaderFromContext(context.Background())
+	assert.False(t, ok)
+
+	reloader := &countingReloader{}
+	got, ok := ConfigReloaderFromContext(WithConfigReloader(context.Background(), reloader))
+	assert.True(t, ok)
+	assert.Same(t, reloader, got)
+}
@@ -0,0 +1,127 @@
+package platform
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"unsafe"
+
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
+)
+
+// RestartDeps prepares deps for starting a service more than once. Starters register routes,
+// middleware and services on the engines and servers found in deps, so a restarted service needs
+// fresh ones: the returned function replaces gin engines, GinRouters and StdEngines with new ones
+// carrying the settings, trusted proxies, middleware and NoRoute and NoMethod handlers they had
+// when RestartDeps was called. Routes must be registered by the service, as they are not carried
+// over.
+//
+// It returns an error when deps hold state that cannot be renewed, like a *grpc.Server (pass
+// GRPCConfig.ServerOptions instead so the starter creates a server on each start), a Temporal
+// worker (pass a TemporalConfig instead) or an Engine or Router implementation unknown to the
+// platform.
+func RestartDeps(deps ...interface{}) (func() []interface{}, error) {
+	engines := make(map[*gin.Engine]ginHandlers)
+	stdEngines := make(map[*StdEngine][]func(http.Handler) http.Handler)
+
+	for _, dep := range deps {
+		switch d := dep.(type) {
+		case *gin.Engine:
+			engines[d] = ginHandlersOf(d)
+		case *GinRouter:
+			engines[d.engine] = ginHandlersOf(d.engine)
+		case *StdEngine:
+			stdEngines[d] = append([]func(http.Handler) http.Handler(nil), d.middleware...)
+		case *grpc.Server:
+			return nil, fmt.Errorf("a %T cannot be served more than once, pass GRPCConfig.ServerOptions instead", d)
+		case TemporalWorker:
+			return nil, fmt.Errorf("a %T cannot be started more than once, pass a TemporalConfig instead", d)
+		case Engine:
+			return nil, fmt.Errorf("engine %T cannot be renewed for a restart", d)
+		case Router:
+			return nil, fmt.Errorf("router %T cannot be renewed for a restart", d)
+		}
+	}
+
+	return func() []interface{} {
+		// Engines shared by several deps, like an engine and its GinRouter, stay shared
+		renewed := make(map[*gin.Engine]*gin.Engine, len(engines))
+		renewEngine := func(engine *gin.Engine) *gin.Engine {
+			if fresh, ok := renewed[engine]; ok {
+				return fresh
+			}
+			fresh := newEngineLike(engine, engines[engine])
+			renewed[engine] = fresh
+			return fresh
+		}
+
+		fresh := make([]interface{}, len(deps))
+		for i, dep := range deps {
+			switch d := dep.(type) {
+			case *gin.Engine:
+				fresh[i] = renewEngine(d)
+			case *GinRouter:
+				fresh[i] = NewGinRouter(renewEngine(d.engine))
+			case *StdEngine:
+				engine := NewStdEngine()
+				engine.Use(stdEngines[d]...)
+				fresh[i] = engine
+			default:
+				fresh[i] = dep
+			}
+		}
+		return fresh
+	}, nil
+}
+
+// ginHandlers are the handlers of a gin engine that are not routes
+type ginHandlers struct {
+	middleware gin.HandlersChain
+	noRoute    gin.HandlersChain
+	noMethod   gin.HandlersChain
+}
+
+// ginHandlersOf copies the middleware and the NoRoute and NoMethod handlers of the engine
+func ginHandlersOf(engine *gin.Engine) ginHandlers {
+	return ginHandlers{
+		middleware: append(gin.HandlersChain(nil), engine.Handlers...),
+		noRoute:    append(gin.HandlersChain(nil), *ginField[gin.HandlersChain](engine, "noRoute")...),
+		noMethod:   append(gin.HandlersChain(nil), *ginField[gin.HandlersChain](engine, "noMethod")...),
+	}
+}
+
+// ginField points at an unexported field of the engine, gin sets the trusted proxies and the
+// NoRoute and NoMethod handlers without exposing them
+func ginField[T any](engine *gin.Engine, name string) *T {
+	field := reflect.ValueOf(engine).Elem().FieldByName(name)
+	return (*T)(unsafe.Pointer(field.UnsafeAddr()))
+}
+
+// newEngineLike creates a gin engine with the settings and trusted proxies of engine and the
+// handlers
+func newEngineLike(engine *gin.Engine, handlers ginHandlers) *gin.Engine {
+	fresh := gin.New()
+	fresh.RedirectTrailingSlash = engine.RedirectTrailingSlash
+	fresh.RedirectFixedPath = engine.RedirectFixedPath
+	fresh.HandleMethodNotAllowed = engine.HandleMethodNotAllowed
+	fresh.ForwardedByClientIP = engine.ForwardedByClientIP
+	fresh.RemoteIPHeaders = engine.RemoteIPHeaders
+	fresh.TrustedPlatform = engine.TrustedPlatform
+	fresh.UseRawPath = engine.UseRawPath
+	fresh.UnescapePathValues = engine.UnescapePathValues
+	fresh.RemoveExtraSlash = engine.RemoveExtraSlash
+	fresh.MaxMultipartMemory = engine.MaxMultipartMemory
+	fresh.UseH2C = engine.UseH2C
+	fresh.ContextWithFallback = engine.ContextWithFallback
+	fresh.HTMLRender = engine.HTMLRender
+	fresh.FuncMap = engine.FuncMap
+
+	// Invalid proxies leave the fresh engine trusting none, as they left engine
+	_ = fresh.SetTrustedProxies(append([]string(nil), *ginField[[]string](engine, "trustedProxies")...))
+
+	fresh.Use(handlers.middleware...)
+	fresh.NoRoute(handlers.noRoute...)
+	fresh.NoMethod(handlers.noMethod...)
+	return fresh
+}
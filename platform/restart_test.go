@@ -0,0 +1,67 @@
+package platform
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartDeps_GinEngine(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.HandleMethodNotAllowed = true
+	require.NoError(t, engine.SetTrustedProxies([]string{"10.0.0.1"}))
+	engine.Use(func(c *gin.Context) {
+		c.Header("X-Middleware", "yes")
+	})
+	engine.NoRoute(func(c *gin.Context) {
+		c.String(http.StatusNotFound, "no route")
+	})
+	engine.NoMethod(func(c *gin.Context) {
+		c.String(http.StatusMethodNotAllowed, "no method")
+	})
+
+	renew, err := RestartDeps(engine)
+	require.NoError(t, err)
+
+	fresh := renew()[0].(*gin.Engine)
+	require.NotSame(t, engine, fresh)
+	fresh.GET("/ip", func(c *gin.Context) {
+		c.String(http.StatusOK, c.ClientIP())
+	})
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		remoteAddr   string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "no route", method: http.MethodGet, path: "/missing", expectedCode: http.StatusNotFound, expectedBody: "no route"},
+		{name: "no method", method: http.MethodPost, path: "/ip", expectedCode: http.StatusMethodNotAllowed, expectedBody: "no method"},
+		{name: "trusted proxy", method: http.MethodGet, path: "/ip", remoteAddr: "10.0.0.1:1234", expectedCode: http.StatusOK, expectedBody: "203.0.113.7"},
+		{name: "untrusted proxy", method: http.MethodGet, path: "/ip", remoteAddr: "10.0.0.2:1234", expectedCode: http.StatusOK, expectedBody: "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			if tt.remoteAddr != "" {
+				req.RemoteAddr = tt.remoteAddr
+			}
+			rec := httptest.NewRecorder()
+			fresh.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			assert.Equal(t, tt.expectedBody, rec.Body.String())
+			assert.Equal(t, "yes", rec.Header().Get("X-Middleware"))
+		})
+	}
+}
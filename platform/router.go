@@ -0,0 +1,79 @@
+package platform
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Router is a router-agnostic HTTP engine, services register standard net/http handlers on it
+// without depending on the router implementation
+type Router interface {
+	http.Handler
+
+	// Handle registers the handler for requests with the method and path pattern, the pattern
+	// uses the syntax of the underlying router. Path parameters are available through
+	// http.Request.PathValue.
+	Handle(method, pattern string, handler http.Handler)
+}
+
+// GinRouter adapts a gin engine to Router, path parameters use gin's syntax ("/orders/:id")
+type GinRouter struct {
+	engine *gin.Engine
+}
+
+// NewGinRouter creates a Router registering handlers on the gin engine
+func NewGinRouter(engine *gin.Engine) *GinRouter {
+	return &GinRouter{engine: engine}
+}
+
+// Handle registers the handler on the gin engine
+func (r *GinRouter) Handle(method, pattern string, handler http.Handler) {
+	r.engine.Handle(method, pattern, func(c *gin.Context) {
+		for _, param := range c.Params {
+			c.Request.SetPathValue(param.Key, param.Value)
+		}
+		handler.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+// Use adds global middleware to the gin engine
+func (r *GinRouter) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	return r.engine.Use(middleware...)
+}
+
+// ServeHTTP serves the request with the gin engine
+func (r *GinRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.engine.ServeHTTP(w, req)
+}
+
+// MethodRouter is implemented by routers registering handlers per method, like chi
+type MethodRouter interface {
+	http.Handler
+	Method(method, pattern string, handler http.Handler)
+}
+
+// methodRouter adapts a MethodRouter to Router
+type methodRouter struct {
+	MethodRouter
+}
+
+// NewMethodRouter creates a Router over a router registering handlers per method, like chi:
+//
+//	router := platform.NewMethodRouter(chi.NewRouter())
+//
+// chi is not a dependency of this module, the adapter relies on the shape of its Method method
+// only. Routers whose handlers are not net/http handlers, like echo, are not supported.
+func NewMethodRouter(router MethodRouter) Router {
+	return methodRouter{MethodRouter: router}
+}
+
+// Handle registers the handler with the underlying router
+func (r methodRouter) Handle(method, pattern string, handler http.Handler) {
+	r.Method(method, pattern, handler)
+}
+
+var (
+	_ Router = (*GinRouter)(nil)
+	_ Router = methodRouter{}
+)
@@ -0,0 +1,124 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// MockRouterService is a mock implementation of the RouterService interface
+type MockRouterService struct {
+	MockService
+}
+
+func (m *MockRouterService) RegisterRoutes(ctx context.Context, router Router) error {
+	args := m.Called(ctx, router)
+	return args.Error(0)
+}
+
+// fakeMethodRouter registers handlers per method like chi, over a ServeMux
+type fakeMethodRouter struct {
+	*http.ServeMux
+}
+
+func (r fakeMethodRouter) Method(method, pattern string, handler http.Handler) {
+	r.ServeMux.Handle(method+" "+pattern, handler)
+}
+
+func orderHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "order "+r.PathValue("id"))
+	})
+}
+
+func TestRouters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name    string
+		router  Router
+		pattern string
+	}{
+		{name: "gin", router: NewGinRouter(gin.New()), pattern: "/orders/:id"},
+		{name: "method router", router: NewMethodRouter(fakeMethodRouter{http.NewServeMux()}), pattern: "/orders/{id}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.router.Handle(http.MethodGet, tt.pattern, orderHandler())
+
+			rec := httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/orders/42", nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "order 42", rec.Body.String())
+
+			rec = httptest.NewRecorder()
+			tt.router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/orders/42", nil))
+			assert.NotEqual(t, http.StatusOK, rec.Code)
+		})
+	}
+}
+
+func TestVMServiceStarter_startRouterService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("serves routes on an adapted gin engine", func(t *testing.T) {
+		addr := freeAddr(t)
+
+		service := new(MockRouterService)
+		service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+		service.On("Type").Return(HTTPServiceType)
+		service.On("RegisterRoutes", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+			args.Get(1).(Router).Handle(http.MethodGet, "/orders/:id", orderHandler())
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, gin.New(), HTTPConfig{Addr: addr})
+		}()
+
+		var resp *http.Response
+		var err error
+		assert.Eventually(t, func() bool {
+			resp, err = http.Get("http://" + addr + "/orders/7")
+			return err == nil
+		}, 5*time.Second, 20*time.Millisecond)
+		require.NotNil(t, resp)
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Equal(t, "order 7", string(body))
+
+		cancel()
+		assert.NoError(t, <-done)
+		service.AssertExpectations(t)
+	})
+
+	t.Run("no router provided", func(t *testing.T) {
+		service := new(MockRouterService)
+
+		err := NewVMServiceStarter(zaptest.NewLogger(t)).startRouterService(context.Background(), service)
+
+		assert.EqualError(t, err, "router not found in dependencies for HTTP service")
+	})
+
+	t.Run("register routes error", func(t *testing.T) {
+		service := new(MockRouterService)
+		service.On("RegisterRoutes", mock.Anything, mock.Anything).Return(errors.New("duplicate route"))
+
+		err := NewVMServiceStarter(zaptest.NewLogger(t)).startRouterService(context.Background(), service, NewMethodRouter(fakeMethodRouter{http.NewServeMux()}))
+
+		assert.EqualError(t, err, "failed to register routes: duplicate route")
+	})
+}
@@ -0,0 +1,20 @@
+package platform
+
+import "context"
+
+type shutdownKey struct{}
+
+// WithShutdown returns a context carrying a function called when the starter receives a shutdown
+// signal. The launcher uses it to tell a service stopped on purpose from one that returned on its
+// own, so supervised services are not restarted on SIGTERM.
+func WithShutdown(ctx context.Context, shutdown func()) context.Context {
+	return context.WithValue(ctx, shutdownKey{}, shutdown)
+}
+
+// NotifyShutdown calls the shutdown function carried by the context, if any. Starters with their
+// own signal handling call it when they receive a shutdown signal.
+func NotifyShutdown(ctx context.Context) {
+	if shutdown, ok := ctx.Value(shutdownKey{}).(func()); ok {
+		shutdown()
+	}
+}
@@ -0,0 +1,58 @@
+package platform
+
+import (
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// StdEngine is a Router over the standard library http.ServeMux, for small services that use
+// the bootstrapper lifecycle without a third-party router. Patterns use the Go 1.22 ServeMux
+// syntax ("/orders/{id}", "/static/{path...}").
+type StdEngine struct {
+	mux        *http.ServeMux
+	middleware []func(http.Handler) http.Handler
+
+	once    sync.Once
+	handler http.Handler
+}
+
+// NewStdEngine creates a Router over a new http.ServeMux
+func NewStdEngine() *StdEngine {
+	return &StdEngine{mux: http.NewServeMux()}
+}
+
+// Handle registers the handler for the method and pattern, an empty method matches every
+// method. It panics when the pattern conflicts with a registered one, like http.ServeMux.
+func (e *StdEngine) Handle(method, pattern string, handler http.Handler) {
+	if method != "" {
+		pattern = method + " " + pattern
+	}
+	e.mux.Handle(pattern, handler)
+}
+
+// handles reports whether a pattern registered for the method, or for every method, is exactly
+// the path. Wider patterns matching it, like "/", do not conflict with registering the path.
+func (e *StdEngine) handles(method, path string) bool {
+	_, pattern := e.mux.Handler(&http.Request{Method: method, URL: &url.URL{Path: path}})
+	return pattern == path || pattern == method+" "+path
+}
+
+// Use adds middleware wrapping every request, the first middleware added runs first. It must be
+// called before the engine serves its first request.
+func (e *StdEngine) Use(middleware ...func(http.Handler) http.Handler) {
+	e.middleware = append(e.middleware, middleware...)
+}
+
+// ServeHTTP serves the request with the matching handler
+func (e *StdEngine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	e.once.Do(func() {
+		e.handler = e.mux
+		for i := len(e.middleware) - 1; i >= 0; i-- {
+			e.handler = e.middleware[i](e.handler)
+		}
+	})
+	e.handler.ServeHTTP(w, req)
+}
+
+var _ Router = (*StdEngine)(nil)
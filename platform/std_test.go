@@ -0,0 +1,104 @@
+package platform
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/accesslog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestStdEngine(t *testing.T) {
+	engine := NewStdEngine()
+	engine.Handle(http.MethodGet, "/orders/{id}", orderHandler())
+	engine.Handle("", "/static/{path...}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.Method+" "+r.PathValue("path"))
+	}))
+
+	var calls []string
+	for _, name := range []string{"first", "second"} {
+		name := name
+		engine.Use(func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name)
+				next.ServeHTTP(w, r)
+			})
+		})
+	}
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		expectedCode int
+		expectedBody string
+	}{
+		{name: "path value", method: http.MethodGet, path: "/orders/42", expectedCode: http.StatusOK, expectedBody: "order 42"},
+		{name: "method not allowed", method: http.MethodPost, path: "/orders/42", expectedCode: http.StatusMethodNotAllowed},
+		{name: "any method", method: http.MethodPut, path: "/static/css/site.css", expectedCode: http.StatusOK, expectedBody: "PUT css/site.css"},
+		{name: "not found", method: http.MethodGet, path: "/missing", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls = nil
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, rec.Body.String())
+			}
+			assert.Equal(t, []string{"first", "second"}, calls)
+		})
+	}
+}
+
+func TestVMServiceStarter_StartStdEngine(t *testing.T) {
+	addr := freeAddr(t)
+
+	service := new(MockRouterService)
+	service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+	service.On("Type").Return(HTTPServiceType)
+	service.On("RegisterRoutes", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(Router).Handle(http.MethodGet, "/orders/{id}", orderHandler())
+	})
+
+	logPath := filepath.Join(t.TempDir(), "access.log")
+	accessLogger, err := accesslog.New(accesslog.Config{Sink: accesslog.FileSink, Path: logPath})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, NewStdEngine(), HTTPConfig{Addr: addr}, accessLogger)
+	}()
+
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = http.Get("http://" + addr + "/orders/7")
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	require.NotNil(t, resp)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "order 7", string(body))
+
+	cancel()
+	assert.NoError(t, <-done)
+
+	// The access log middleware wraps the standard library router
+	require.NoError(t, accessLogger.Close())
+	logged, err := os.ReadFile(logPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(logged), `"path":"/orders/7"`)
+}
@@ -0,0 +1,299 @@
+package platform
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/systemd"
+	"go.uber.org/zap"
+)
+
+// Default TCP server settings
+const (
+	DefaultTCPAddr         = ":9000"
+	DefaultTCPDrainTimeout = 30 * time.Second
+)
+
+// TCPConfig configures the TCP server, pass it as a dependency to override the defaults
+type TCPConfig struct {
+	// Addr is the address the server listens on, defaults to DefaultTCPAddr
+	Addr string
+
+	// MaxConns limits the number of concurrently handled connections, 0 means unlimited
+	MaxConns int
+
+	// IdleTimeout closes connections without reads or writes for this long, 0 disables it
+	IdleTimeout time.Duration
+
+	// TLSConfig serves TLS when set
+	TLSConfig *tls.Config
+
+	// DrainTimeout bounds how long shutdown waits for open connections, defaults to DefaultTCPDrainTimeout
+	DrainTimeout time.Duration
+}
+
+// tcpServer tracks the listener and open connections of a TCP service
+type tcpServer struct {
+	service TCPService
+	config  TCPConfig
+	logger  *zap.Logger
+
+	// slots limits concurrent connections when MaxConns is set
+	slots chan struct{}
+
+	// connsMu protects conns
+	connsMu sync.Mutex
+	conns   map[net.Conn]struct{}
+
+	wg sync.WaitGroup
+}
+
+// serve accepts connections until the listener is closed
+func (s *tcpServer) serve(ctx context.Context, ln net.Listener) error {
+	var backoff time.Duration
+	for {
+		if s.slots != nil {
+			select {
+			case s.slots <- struct{}{}:
+			case <-ctx.Done():
+				return nil
+			}
+		}
+
+		conn, err := ln.Accept()
+		if err != nil {
+			s.releaseSlot()
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+
+			// Back off on temporary errors such as running out of file descriptors
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if backoff == 0 {
+					backoff = 5 * time.Millisecond
+				} else if backoff *= 2; backoff > time.Second {
+					backoff = time.Second
+				}
+				s.logger.Warn("Accept error, retrying", zap.Error(err), zap.Duration("backoff", backoff))
+				time.Sleep(backoff)
+				continue
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		backoff = 0
+
+		if s.config.IdleTimeout > 0 {
+			conn = &idleTimeoutConn{Conn: conn, timeout: s.config.IdleTimeout}
+		}
+
+		s.track(conn, true)
+		s.wg.Add(1)
+		go s.handle(ctx, conn)
+	}
+}
+
+// handle runs the service handler for a single connection
+func (s *tcpServer) handle(ctx context.Context, conn net.Conn) {
+	defer s.wg.Done()
+	defer s.releaseSlot()
+	defer s.track(conn, false)
+	defer conn.Close()
+
+	// A panicking handler only loses its own connection
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			s.logger.Error("Connection handler panicked",
+				zap.String("remote", conn.RemoteAddr().String()),
+				zap.Any("panic", recovered),
+				zap.ByteString("stack", debug.Stack()))
+		}
+	}()
+
+	if err := s.service.HandleConn(ctx, conn); err != nil {
+		s.logger.Warn("Connection handler returned error",
+			zap.String("remote", conn.RemoteAddr().String()),
+			zap.Error(err))
+	}
+}
+
+// track adds or removes an open connection
+func (s *tcpServer) track(conn net.Conn, add bool) {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	if add {
+		s.conns[conn] = struct{}{}
+	} else {
+		delete(s.conns, conn)
+	}
+}
+
+// releaseSlot frees a connection slot when MaxConns is set
+func (s *tcpServer) releaseSlot() {
+	if s.slots != nil {
+		<-s.slots
+	}
+}
+
+// closeConns force closes every open connection and returns how many were closed
+func (s *tcpServer) closeConns() int {
+	s.connsMu.Lock()
+	defer s.connsMu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+	}
+	return len(s.conns)
+}
+
+// drain waits for open connections to finish, force closing them once the timeout expires
+func (s *tcpServer) drain(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		s.logger.Info("All TCP connections drained")
+	case <-time.After(timeout):
+		s.logger.Warn("Drain timeout exceeded, closing remaining TCP connections", zap.Int("connections", s.closeConns()))
+		<-done
+	}
+}
+
+// idleTimeoutConn extends the connection deadline on every read and write
+type idleTimeoutConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *idleTimeoutConn) Read(b []byte) (int, error) {
+	if err := c.Conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Read(b)
+}
+
+func (c *idleTimeoutConn) Write(b []byte) (int, error) {
+	if err := c.Conn.SetDeadline(time.Now().Add(c.timeout)); err != nil {
+		return 0, err
+	}
+	return c.Conn.Write(b)
+}
+
+// tcpListener returns the socket passed by systemd socket activation, or listens on addr when the
+// process was not socket activated
+func (v *VMServiceStarter) tcpListener(addr string) (net.Listener, error) {
+	handedOff, err := inheritedListener()
+	if err != nil {
+		v.logger.Error("Failed to use handed off listener", zap.Error(err))
+		return nil, err
+	}
+	if handedOff != nil {
+		v.logger.Info("Using handed off listener", zap.String("addr", handedOff.Addr().String()))
+		return handedOff, nil
+	}
+
+	activated, err := systemd.Listeners()
+	if err != nil {
+		v.logger.Error("Failed to use socket activation", zap.Error(err))
+		return nil, fmt.Errorf("failed to use socket activation: %w", err)
+	}
+
+	if len(activated) > 0 {
+		for _, extra := range activated[1:] {
+			extra.Close()
+		}
+		v.logger.Info("Using socket activated listener", zap.String("addr", activated[0].Addr().String()))
+		return activated[0], nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		v.logger.Error("Failed to listen", zap.String("addr", addr), zap.Error(err))
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return ln, nil
+}
+
+// startTCPService starts a TCP service on the VM runtime platform
+func (v *VMServiceStarter) startTCPService(ctx context.Context, service TCPService, deps ...interface{}) error {
+	v.logger.Info("Setting up TCP service")
+
+	// Find the config in the dependencies
+	var config TCPConfig
+	for _, dep := range deps {
+		switch d := dep.(type) {
+		case TCPConfig:
+			config = d
+		case *TCPConfig:
+			config = *d
+		}
+	}
+
+	if config.Addr == "" {
+		config.Addr = DefaultTCPAddr
+	}
+	if config.DrainTimeout <= 0 {
+		config.DrainTimeout = DefaultTCPDrainTimeout
+	}
+
+	endBind := TimelineFromContext(ctx).Span("bind listener")
+	ln, err := v.tcpListener(config.Addr)
+	endBind(err)
+	if err != nil {
+		return err
+	}
+	if config.TLSConfig != nil {
+		ln = tls.NewListener(ln, config.TLSConfig)
+	}
+
+	server := &tcpServer{
+		service: service,
+		config:  config,
+		logger:  v.logger,
+		conns:   make(map[net.Conn]struct{}),
+	}
+	if config.MaxConns > 0 {
+		server.slots = make(chan struct{}, config.MaxConns)
+	}
+
+	// Stop the signal and listener goroutines on every return path
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Close the listener once a shutdown signal is received or the context is done
+	ctx = v.setupSignalHandling(ctx)
+	go func() {
+		<-ctx.Done()
+		v.logger.Info("Stopping TCP listener")
+		ln.Close()
+	}()
+
+	v.logger.Info("Starting TCP server",
+		zap.String("addr", ln.Addr().String()),
+		zap.Bool("tls", config.TLSConfig != nil),
+		zap.Int("maxConns", config.MaxConns))
+
+	v.ready(ctx)
+
+	// Accept connections (this is blocking)
+	serveErr := server.serve(ctx, ln)
+	if serveErr != nil {
+		ln.Close()
+	}
+
+	v.logger.Info("Draining TCP connections", zap.Duration("timeout", config.DrainTimeout))
+	server.drain(config.DrainTimeout)
+
+	return serveErr
+}
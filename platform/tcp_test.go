@@ -0,0 +1,193 @@
+package platform
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// echoTCPService echoes every line back to the client
+type echoTCPService struct {
+	MockService
+	handled chan struct{}
+}
+
+func (s *echoTCPService) HandleConn(ctx context.Context, conn net.Conn) error {
+	if s.handled != nil {
+		s.handled <- struct{}{}
+	}
+	_, err := io.Copy(conn, conn)
+	return err
+}
+
+func newTestTCPServer(t *testing.T, service TCPService, config TCPConfig) (*tcpServer, net.Listener) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := &tcpServer{
+		service: service,
+		config:  config,
+		logger:  zaptest.NewLogger(t),
+		conns:   make(map[net.Conn]struct{}),
+	}
+	if config.MaxConns > 0 {
+		server.slots = make(chan struct{}, config.MaxConns)
+	}
+	return server, ln
+}
+
+func TestTCPServer_serveEcho(t *testing.T) {
+	server, ln := newTestTCPServer(t, &echoTCPService{}, TCPConfig{})
+
+	done := make(chan error, 1)
+	go func() { done <- server.serve(context.Background(), ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+
+	_, err = conn.Write([]byte("ping\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ping\n", line)
+	conn.Close()
+
+	ln.Close()
+	assert.NoError(t, <-done)
+	server.drain(time.Second)
+}
+
+func TestTCPServer_maxConns(t *testing.T) {
+	service := &echoTCPService{handled: make(chan struct{}, 2)}
+	server, ln := newTestTCPServer(t, service, TCPConfig{MaxConns: 1})
+
+	go server.serve(context.Background(), ln)
+	defer ln.Close()
+
+	first, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	<-service.handled
+
+	// The second connection is not handled while the first holds the only slot
+	second, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+
+	select {
+	case <-service.handled:
+		t.Fatal("second connection handled while at the connection limit")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	first.Close()
+	select {
+	case <-service.handled:
+	case <-time.After(time.Second):
+		t.Fatal("second connection not handled after a slot was released")
+	}
+}
+
+func TestTCPServer_idleTimeout(t *testing.T) {
+	server, ln := newTestTCPServer(t, &echoTCPService{}, TCPConfig{IdleTimeout: 50 * time.Millisecond})
+
+	go server.serve(context.Background(), ln)
+	defer ln.Close()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	// The server closes the idle connection, which the client observes as EOF
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestTCPServer_drainForceCloses(t *testing.T) {
+	service := &echoTCPService{handled: make(chan struct{}, 1)}
+	server, ln := newTestTCPServer(t, service, TCPConfig{})
+
+	go server.serve(context.Background(), ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	<-service.handled
+
+	ln.Close()
+	start := time.Now()
+	server.drain(50 * time.Millisecond)
+
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Empty(t, server.conns)
+}
+
+// panickingTCPService panics on its first connection and echoes the others
+type panickingTCPService struct {
+	echoTCPService
+	conns atomic.Int32
+}
+
+func (s *panickingTCPService) HandleConn(ctx context.Context, conn net.Conn) error {
+	if s.conns.Add(1) == 1 {
+		panic("nil map write")
+	}
+	return s.echoTCPService.HandleConn(ctx, conn)
+}
+
+func TestTCPServer_recoversHandlerPanics(t *testing.T) {
+	server, ln := newTestTCPServer(t, &panickingTCPService{}, TCPConfig{})
+
+	go server.serve(context.Background(), ln)
+	defer ln.Close()
+
+	// The panicking connection is closed
+	first, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer first.Close()
+	first.SetReadDeadline(time.Now().Add(time.Second))
+	_, err = first.Read(make([]byte, 1))
+	assert.ErrorIs(t, err, io.EOF)
+
+	// The server keeps serving
+	second, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	defer second.Close()
+	_, err = second.Write([]byte("ping\n"))
+	require.NoError(t, err)
+	line, err := bufio.NewReader(second).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ping\n", line)
+}
+
+func TestVMServiceStarter_startTCPService(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	t.Run("listen error", func(t *testing.T) {
+		starter := NewVMServiceStarter(logger)
+
+		err := starter.startTCPService(context.Background(), &echoTCPService{}, TCPConfig{Addr: "256.0.0.1:0"})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to listen on 256.0.0.1:0")
+	})
+
+	t.Run("stops when context is done", func(t *testing.T) {
+		starter := NewVMServiceStarter(logger)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		err := starter.startTCPService(ctx, &echoTCPService{}, &TCPConfig{Addr: "127.0.0.1:0", DrainTimeout: time.Second})
+
+		assert.NoError(t, err)
+	})
+}
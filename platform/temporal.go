@@ -0,0 +1,174 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jjmaturino/bootstrapper/health"
+	"go.uber.org/zap"
+)
+
+// TemporalHealthCheck is the name of the readiness check the starter registers
+const TemporalHealthCheck = "temporal"
+
+// TemporalRegistry registers workflows and activities on a Temporal worker, it is satisfied by
+// the Temporal SDK's worker.Worker
+type TemporalRegistry interface {
+	RegisterWorkflow(w interface{})
+	RegisterActivity(a interface{})
+}
+
+// TemporalWorker is the subset of the Temporal SDK's worker.Worker the starter manages
+type TemporalWorker interface {
+	TemporalRegistry
+
+	// Start begins polling the task queue without blocking
+	Start() error
+
+	// Stop waits for in-flight tasks to finish, bounded by the worker's stop timeout
+	Stop()
+}
+
+// TemporalClient is the subset of the Temporal SDK's client.Client the starter manages, when
+// provided as a dependency the starter closes it once the worker has stopped. CancelWorkflow is
+// not called, it tells the client apart from other dependencies with a Close method.
+type TemporalClient interface {
+	CancelWorkflow(ctx context.Context, workflowID string, runID string) error
+	Close()
+}
+
+// TemporalConfig lets the starter own the Temporal connection: on each start it dials the client
+// and creates the worker, and it closes the client once the worker stopped. Pass it as a
+// dependency instead of a worker and client, with the SDK calls:
+//
+//	platform.TemporalConfig{
+//		Dial: func(ctx context.Context) (platform.TemporalClient, error) {
+//			return client.DialContext(ctx, client.Options{HostPort: "temporal:7233"})
+//		},
+//		NewWorker: func(c platform.TemporalClient) (platform.TemporalWorker, error) {
+//			return worker.New(c.(client.Client), "orders", worker.Options{}), nil
+//		},
+//		CheckHealth: func(ctx context.Context, c platform.TemporalClient) error {
+//			_, err := c.(client.Client).CheckHealth(ctx, &client.CheckHealthRequest{})
+//			return err
+//		},
+//	}
+type TemporalConfig struct {
+	// Dial connects the client
+	Dial func(ctx context.Context) (TemporalClient, error)
+
+	// NewWorker creates the worker polling with the client
+	NewWorker func(client TemporalClient) (TemporalWorker, error)
+
+	// CheckHealth checks the connection to the Temporal server for the readiness check, which
+	// only reports whether the worker runs when nil
+	CheckHealth func(ctx context.Context, client TemporalClient) error
+}
+
+// startTemporalWorker starts a Temporal worker service on the VM runtime platform. The worker is
+// reported on the health registry found in deps by a readiness check failing while the worker
+// does not run.
+func (v *VMServiceStarter) startTemporalWorker(ctx context.Context, service TemporalWorkerService, deps ...interface{}) error {
+	v.logger.Info("Setting up Temporal worker service")
+
+	w, temporalClient, cfg, err := v.temporalConnection(ctx, deps...)
+	if err != nil {
+		return err
+	}
+
+	if temporalClient != nil {
+		defer func() {
+			v.logger.Info("Closing Temporal client")
+			temporalClient.Close()
+		}()
+	}
+
+	var running atomic.Bool
+	if registry, ok := DepOf[*health.Registry](deps...); ok {
+		registry.Register(health.CheckFunc(TemporalHealthCheck, func(ctx context.Context) error {
+			if !running.Load() {
+				return errors.New("temporal worker not running")
+			}
+			if cfg.CheckHealth != nil {
+				return cfg.CheckHealth(ctx, temporalClient)
+			}
+			return nil
+		}))
+	}
+
+	// Register workflows and activities
+	v.logger.Info("Registering Temporal workflows and activities")
+	endRegister := TimelineFromContext(ctx).Span("register temporal workflows")
+	err = service.RegisterWorkflows(ctx, w)
+	endRegister(err)
+	if err != nil {
+		v.logger.Error("Failed to register workflows", zap.Error(err))
+		return fmt.Errorf("failed to register workflows: %w", err)
+	}
+
+	// Start polling the task queue
+	v.logger.Info("Starting Temporal worker")
+	endStart := TimelineFromContext(ctx).Span("start temporal worker")
+	err = w.Start()
+	endStart(err)
+	if err != nil {
+		v.logger.Error("Failed to start Temporal worker", zap.Error(err))
+		return fmt.Errorf("failed to start temporal worker: %w", err)
+	}
+	running.Store(true)
+	v.ready(ctx)
+
+	// Block until a shutdown signal is received or the context is done, then stop gracefully
+	<-v.setupSignalHandling(ctx).Done()
+
+	v.logger.Info("Stopping Temporal worker")
+	running.Store(false)
+	w.Stop()
+	v.logger.Info("Temporal worker stopped")
+
+	return nil
+}
+
+// temporalConnection returns the worker and optional client of the service: created from the
+// TemporalConfig found in deps, or the worker and client found in deps
+func (v *VMServiceStarter) temporalConnection(ctx context.Context, deps ...interface{}) (TemporalWorker, TemporalClient, TemporalConfig, error) {
+	if cfg, ok := DepOf[TemporalConfig](deps...); ok && cfg.Dial != nil && cfg.NewWorker != nil {
+		v.logger.Info("Dialing Temporal")
+		endDial := TimelineFromContext(ctx).Span("dial temporal")
+		temporalClient, err := cfg.Dial(ctx)
+		endDial(err)
+		if err != nil {
+			v.logger.Error("Failed to dial Temporal", zap.Error(err))
+			return nil, nil, cfg, fmt.Errorf("failed to dial temporal: %w", err)
+		}
+
+		w, err := cfg.NewWorker(temporalClient)
+		if err != nil {
+			temporalClient.Close()
+			v.logger.Error("Failed to create Temporal worker", zap.Error(err))
+			return nil, nil, cfg, fmt.Errorf("failed to create temporal worker: %w", err)
+		}
+		return w, temporalClient, cfg, nil
+	}
+
+	var (
+		w              TemporalWorker
+		temporalClient TemporalClient
+	)
+	for _, dep := range deps {
+		if d, ok := dep.(TemporalWorker); ok && w == nil {
+			w = d
+			continue
+		}
+		if d, ok := dep.(TemporalClient); ok && temporalClient == nil {
+			temporalClient = d
+		}
+	}
+
+	if w == nil {
+		return nil, nil, TemporalConfig{}, errors.New("temporal worker not found in dependencies for Temporal worker service")
+	}
+	return w, temporalClient, TemporalConfig{}, nil
+}
@@ -0,0 +1,306 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/worker"
+	"go.uber.org/zap/zaptest"
+)
+
+// The SDK's worker and client satisfy the subsets the starter manages
+var (
+	_ TemporalWorker = worker.Worker(nil)
+	_ TemporalClient = client.Client(nil)
+)
+
+
+// MockTemporalService is a mock implementation of the TemporalWorkerService interface
+type MockTemporalService struct {
+	MockService
+}
+
+func (m *MockTemporalService) RegisterWorkflows(ctx context.Context, registry TemporalRegistry) error {
+	args := m.Called(ctx, registry)
+	return args.Error(0)
+}
+
+// MockTemporalWorker is a mock implementation of the TemporalWorker interface
+type MockTemporalWorker struct {
+	mock.Mock
+}
+
+func (m *MockTemporalWorker) RegisterWorkflow(w interface{}) {
+	m.Called(w)
+}
+
+func (m *MockTemporalWorker) RegisterActivity(a interface{}) {
+	m.Called(a)
+}
+
+func (m *MockTemporalWorker) Start() error {
+	args := m.Called()
+	return args.Error(0)
+}
+
+func (m *MockTemporalWorker) Stop() {
+	m.Called()
+}
+
+// MockTemporalClient is a mock implementation of the TemporalClient interface
+type MockTemporalClient struct {
+	mock.Mock
+}
+
+func (m *MockTemporalClient) CancelWorkflow(ctx context.Context, workflowID string, runID string) error {
+	args := m.Called(ctx, workflowID, runID)
+	return args.Error(0)
+}
+
+func (m *MockTemporalClient) Close() {
+	m.Called()
+}
+
+// mockCloser is a dependency with a Close method that is not a Temporal client
+type mockCloser struct {
+	closed bool
+}
+
+func (c *mockCloser) Close() {
+	c.closed = true
+}
+
+func TestVMServiceStarter_startTemporalWorker(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name        string
+		service     func() TemporalWorkerService
+		worker      func() *MockTemporalWorker
+		client      func() *MockTemporalClient
+		wantErr     bool
+		expectedErr string
+	}{
+		{
+			name: "success stops worker and closes client",
+			service: func() TemporalWorkerService {
+				mockService := new(MockTemporalService)
+				mockService.On("RegisterWorkflows", mock.Anything, mock.Anything).Return(nil)
+				return mockService
+			},
+			worker: func() *MockTemporalWorker {
+				mockWorker := new(MockTemporalWorker)
+				mockWorker.On("Start").Return(nil)
+				mockWorker.On("Stop").Return()
+				return mockWorker
+			},
+			client: func() *MockTemporalClient {
+				mockClient := new(MockTemporalClient)
+				mockClient.On("Close").Return()
+				return mockClient
+			},
+			wantErr: false,
+		},
+		{
+			name: "no worker provided",
+			service: func() TemporalWorkerService {
+				return new(MockTemporalService)
+			},
+			wantErr:     true,
+			expectedErr: "temporal worker not found in dependencies for Temporal worker service",
+		},
+		{
+			name: "register workflows error",
+			service: func() TemporalWorkerService {
+				mockService := new(MockTemporalService)
+				mockService.On("RegisterWorkflows", mock.Anything, mock.Anything).Return(errors.New("register error"))
+				return mockService
+			},
+			worker: func() *MockTemporalWorker {
+				return new(MockTemporalWorker)
+			},
+			wantErr:     true,
+			expectedErr: "failed to register workflows: register error",
+		},
+		{
+			name: "worker start error",
+			service: func() TemporalWorkerService {
+				mockService := new(MockTemporalService)
+				mockService.On("RegisterWorkflows", mock.Anything, mock.Anything).Return(nil)
+				return mockService
+			},
+			worker: func() *MockTemporalWorker {
+				mockWorker := new(MockTemporalWorker)
+				mockWorker.On("Start").Return(errors.New("start error"))
+				return mockWorker
+			},
+			client: func() *MockTemporalClient {
+				mockClient := new(MockTemporalClient)
+				mockClient.On("Close").Return()
+				return mockClient
+			},
+			wantErr:     true,
+			expectedErr: "failed to start temporal worker: start error",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			starter := NewVMServiceStarter(logger)
+			service := tt.service()
+
+			var deps []interface{}
+			var mockWorker *MockTemporalWorker
+			var mockClient *MockTemporalClient
+			if tt.worker != nil {
+				mockWorker = tt.worker()
+				deps = append(deps, mockWorker)
+			}
+			if tt.client != nil {
+				mockClient = tt.client()
+				deps = append(deps, mockClient)
+			}
+
+			// The worker blocks until the context is done
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+
+			err := starter.startTemporalWorker(ctx, service, deps...)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			service.(*MockTemporalService).AssertExpectations(t)
+			if mockWorker != nil {
+				mockWorker.AssertExpectations(t)
+			}
+			if mockClient != nil {
+				mockClient.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestVMServiceStarter_startTemporalWorkerClosesOnlyTheClient(t *testing.T) {
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	mockService := new(MockTemporalService)
+	mockService.On("RegisterWorkflows", mock.Anything, mock.Anything).Return(nil)
+	mockWorker := new(MockTemporalWorker)
+	mockWorker.On("Start").Return(nil)
+	mockWorker.On("Stop").Return()
+	mockClient := new(MockTemporalClient)
+	mockClient.On("Close").Return()
+	closer := &mockCloser{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := starter.startTemporalWorker(ctx, mockService, closer, mockWorker, mockClient)
+
+	require.NoError(t, err)
+	mockClient.AssertExpectations(t)
+	assert.False(t, closer.closed, "Expected a closer that is not a Temporal client to be left open")
+}
+
+func TestVMServiceStarter_StartTemporalWrongInterface(t *testing.T) {
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	mockService := new(MockService)
+	mockService.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+	mockService.On("Type").Return(TemporalServiceType)
+
+	err := starter.Start(context.Background(), mockService)
+
+	assert.EqualError(t, err, "service claims to be Temporal but does not implement TemporalWorkerService interface")
+}
+
+func TestVMServiceStarter_startTemporalWorkerDials(t *testing.T) {
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	mockService := new(MockTemporalService)
+	mockService.On("RegisterWorkflows", mock.Anything, mock.Anything).Return(nil)
+
+	mockClient := new(MockTemporalClient)
+	mockClient.On("Close").Return()
+
+	registry := health.NewRegistry()
+	started := make(chan struct{})
+	mockWorker := new(MockTemporalWorker)
+	mockWorker.On("Start").Run(func(mock.Arguments) { close(started) }).Return(nil)
+	mockWorker.On("Stop").Return()
+
+	serverErr := errors.New("frontend unavailable")
+	var checkErr error
+	cfg := TemporalConfig{
+		Dial: func(ctx context.Context) (TemporalClient, error) { return mockClient, nil },
+		NewWorker: func(client TemporalClient) (TemporalWorker, error) {
+			assert.Same(t, mockClient, client)
+			return mockWorker, nil
+		},
+		CheckHealth: func(ctx context.Context, client TemporalClient) error { return checkErr },
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- starter.startTemporalWorker(ctx, mockService, cfg, registry) }()
+
+	<-started
+	require.Eventually(t, func() bool {
+		return registry.Ready(context.Background()).Status == health.StatusPass
+	}, time.Second, 5*time.Millisecond)
+	checkErr = serverErr
+	assert.Equal(t, health.StatusFail, registry.Ready(context.Background()).Status)
+
+	cancel()
+	require.NoError(t, <-done)
+
+	// A stopped worker is not ready
+	checkErr = nil
+	report := registry.Ready(context.Background())
+	assert.Equal(t, health.StatusFail, report.Status)
+	assert.Equal(t, TemporalHealthCheck, report.Checks[0].Name)
+
+	mockWorker.AssertExpectations(t)
+	mockClient.AssertExpectations(t)
+}
+
+func TestVMServiceStarter_startTemporalWorkerDialErrors(t *testing.T) {
+	starter := NewVMServiceStarter(zaptest.NewLogger(t))
+
+	_, _, _, err := starter.temporalConnection(context.Background(), TemporalConfig{
+		Dial:      func(ctx context.Context) (TemporalClient, error) { return nil, errors.New("connection refused") },
+		NewWorker: func(client TemporalClient) (TemporalWorker, error) { return nil, nil },
+	})
+	assert.EqualError(t, err, "failed to dial temporal: connection refused")
+
+	// The dialed client is closed when the worker cannot be created
+	mockClient := new(MockTemporalClient)
+	mockClient.On("Close").Return()
+	_, _, _, err = starter.temporalConnection(context.Background(), TemporalConfig{
+		Dial:      func(ctx context.Context) (TemporalClient, error) { return mockClient, nil },
+		NewWorker: func(client TemporalClient) (TemporalWorker, error) { return nil, errors.New("invalid task queue") },
+	})
+	assert.EqualError(t, err, "failed to create temporal worker: invalid task queue")
+	mockClient.AssertExpectations(t)
+}
+
+func TestRestartDeps_TemporalWorker(t *testing.T) {
+	_, err := RestartDeps(new(MockTemporalWorker))
+	assert.ErrorContains(t, err, "pass a TemporalConfig instead")
+
+	_, err = RestartDeps(TemporalConfig{})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,123 @@
+package platform
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// TimelineSpan is a phase of the boot sequence
+type TimelineSpan struct {
+	// Name identifies the phase, like "initialize service" or "bind listener"
+	Name string
+
+	// Offset is the time between the start of the boot and the start of the phase
+	Offset time.Duration
+
+	// Duration is how long the phase took
+	Duration time.Duration
+
+	// Err is the error the phase failed with
+	Err error
+}
+
+// MarshalLogObject encodes the span in the timeline log
+func (s TimelineSpan) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", s.Name)
+	enc.AddDuration("offset", s.Offset)
+	enc.AddDuration("duration", s.Duration)
+	if s.Err != nil {
+		enc.AddString("error", s.Err.Error())
+	}
+	return nil
+}
+
+// Timeline records the phases of the boot sequence of a service, from dependency resolution to
+// the listener bind, and logs them as a single entry once the service is ready so slow startups
+// can be diagnosed. The launcher passes it to starters and services through the context. A nil
+// Timeline records nothing.
+type Timeline struct {
+	logger *zap.Logger
+	start  time.Time
+
+	mu       sync.Mutex
+	spans    []TimelineSpan
+	finished bool
+}
+
+// NewTimeline creates a timeline starting now
+func NewTimeline(logger *zap.Logger) *Timeline {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+
+	return &Timeline{logger: logger, start: time.Now()}
+}
+
+// Span starts a phase and returns the function ending it with the error it failed with, if any:
+//
+//	end := platform.TimelineFromContext(ctx).Span("load config")
+//	err := loadConfig()
+//	end(err)
+func (t *Timeline) Span(name string) (end func(err error)) {
+	if t == nil {
+		return func(error) {}
+	}
+
+	start := time.Now()
+	return func(err error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.spans = append(t.spans, TimelineSpan{
+			Name:     name,
+			Offset:   start.Sub(t.start),
+			Duration: time.Since(start),
+			Err:      err,
+		})
+	}
+}
+
+// Spans returns the phases recorded so far, in the order they ended
+func (t *Timeline) Spans() []TimelineSpan {
+	if t == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]TimelineSpan(nil), t.spans...)
+}
+
+// Finish logs the timeline, only the first call logs
+func (t *Timeline) Finish() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	if t.finished {
+		t.mu.Unlock()
+		return
+	}
+	t.finished = true
+	spans := append([]TimelineSpan(nil), t.spans...)
+	t.mu.Unlock()
+
+	t.logger.Info("Startup timeline", zap.Duration("total", time.Since(t.start)), zap.Objects("spans", spans))
+}
+
+type timelineKey struct{}
+
+// WithTimeline returns a context carrying the timeline
+func WithTimeline(ctx context.Context, t *Timeline) context.Context {
+	return context.WithValue(ctx, timelineKey{}, t)
+}
+
+// TimelineFromContext returns the timeline carried by the context, nil when there is none
+func TimelineFromContext(ctx context.Context) *Timeline {
+	t, _ := ctx.Value(timelineKey{}).(*Timeline)
+	return t
+}
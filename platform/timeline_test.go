@@ -0,0 +1,85 @@
+package platform
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func spanNames(spans []TimelineSpan) []string {
+	var names []string
+	for _, span := range spans {
+		names = append(names, span.Name)
+	}
+	return names
+}
+
+func TestTimeline(t *testing.T) {
+	core, logs := observer.New(zap.InfoLevel)
+	timeline := NewTimeline(zap.New(core))
+
+	end := timeline.Span("load config")
+	time.Sleep(10 * time.Millisecond)
+	end(nil)
+	timeline.Span("connect database")(errors.New("connection refused"))
+
+	spans := timeline.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "load config", spans[0].Name)
+	assert.GreaterOrEqual(t, spans[0].Duration, 10*time.Millisecond)
+	assert.NoError(t, spans[0].Err)
+	assert.GreaterOrEqual(t, spans[1].Offset, spans[0].Duration)
+	assert.EqualError(t, spans[1].Err, "connection refused")
+
+	// Only the first call logs
+	timeline.Finish()
+	timeline.Finish()
+	entries := logs.FilterMessage("Startup timeline").All()
+	require.Len(t, entries, 1)
+	assert.Len(t, entries[0].ContextMap()["spans"], 2)
+
+	// A missing timeline records nothing
+	var missing *Timeline
+	assert.Nil(t, TimelineFromContext(context.Background()))
+	missing.Span("ignored")(nil)
+	missing.Finish()
+	assert.Empty(t, missing.Spans())
+}
+
+func TestVMServiceStarter_StartRecordsTimeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	addr := freeAddr(t)
+
+	service := newRoutedHTTPService()
+	service.On("Type").Return(HTTPServiceType)
+
+	timeline := NewTimeline(zaptest.NewLogger(t))
+	ctx, cancel := context.WithCancel(WithTimeline(context.Background(), timeline))
+	done := make(chan error, 1)
+	go func() {
+		done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, gin.New(), HTTPConfig{Addr: addr})
+	}()
+
+	assert.Eventually(t, func() bool {
+		resp, err := http.Get("http://" + addr + "/hello")
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return true
+	}, 5*time.Second, 20*time.Millisecond)
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, []string{"initialize service", "configure routes", "bind listener"}, spanNames(timeline.Spans()))
+	service.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package platform
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultCertReloadInterval is how often the VM starter checks certificate files for changes
+const DefaultCertReloadInterval = 30 * time.Second
+
+// CertReloader serves a certificate loaded from files and reloads it when they change, so
+// renewed certificates are picked up without a restart. It is Reloadable, register it with the
+// launcher to also reload on SIGHUP.
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	logger   *zap.Logger
+
+	// mu protects cert and modTime
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// NewCertReloader loads the certificate and key, it fails when they cannot be loaded
+func NewCertReloader(certFile, keyFile string, logger *zap.Logger) (*CertReloader, error) {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	r := &CertReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	if err := r.Reload(context.Background()); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// Reload loads the certificate and key again, the current certificate is kept when it fails
+func (r *CertReloader) Reload(ctx context.Context) error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	r.cert, r.modTime = &cert, modTime
+	r.mu.Unlock()
+
+	r.logger.Info("Loaded TLS certificate", zap.String("certFile", r.certFile))
+	return nil
+}
+
+// GetCertificate returns the current certificate, set it as tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Watch reloads the certificate every interval when the files changed, until the context is done
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		modTime, err := r.latestModTime()
+		if err != nil {
+			r.logger.Warn("Failed to check TLS certificate files", zap.Error(err))
+			continue
+		}
+
+		r.mu.RLock()
+		changed := modTime.After(r.modTime)
+		r.mu.RUnlock()
+		if !changed {
+			continue
+		}
+
+		if err := r.Reload(ctx); err != nil {
+			r.logger.Error("Failed to reload TLS certificate, keeping the current one", zap.Error(err))
+		}
+	}
+}
+
+// latestModTime returns the latest modification time of the certificate and key files
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to stat certificate file: %w", err)
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return latest, nil
+}
+
+// serverTLSConfig returns the TLS config of the HTTP server, certificate files are watched for
+// changes until the context is done
+func (v *VMServiceStarter) serverTLSConfig(ctx context.Context, config HTTPConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if config.TLSConfig != nil {
+		tlsConfig = config.TLSConfig.Clone()
+	}
+
+	if config.CertFile != "" {
+		reloader, err := NewCertReloader(config.CertFile, config.KeyFile, v.logger)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.GetCertificate = reloader.GetCertificate
+		go reloader.Watch(ctx, DefaultCertReloadInterval)
+	}
+
+	if config.ClientCAs != nil {
+		tlsConfig.ClientCAs = config.ClientCAs
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+// ClientIdentity is the identity of a client verified with mutual TLS
+type ClientIdentity struct {
+	// CommonName is the subject common name of the client certificate
+	CommonName string
+
+	// DNSNames and URIs are the subject alternative names of the client certificate, URIs hold
+	// SPIFFE IDs
+	DNSNames []string
+	URIs     []string
+
+	// Certificate is the verified client certificate
+	Certificate *x509.Certificate
+}
+
+type clientIdentityKey struct{}
+
+// ClientIdentityFromContext returns the verified client identity of the request context, it is
+// only set when the server requires client certificates (WithClientCA)
+func ClientIdentityFromContext(ctx context.Context) (ClientIdentity, bool) {
+	identity, ok := ctx.Value(clientIdentityKey{}).(ClientIdentity)
+	return identity, ok
+}
+
+// withClientIdentity adds the verified client identity to the context of the requests
+func withClientIdentity(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS != nil && len(r.TLS.VerifiedChains) > 0 && len(r.TLS.VerifiedChains[0]) > 0 {
+			cert := r.TLS.VerifiedChains[0][0]
+			identity := ClientIdentity{
+				CommonName:  cert.Subject.CommonName,
+				DNSNames:    cert.DNSNames,
+				Certificate: cert,
+			}
+			for _, uri := range cert.URIs {
+				identity.URIs = append(identity.URIs, uri.String())
+			}
+			r = r.WithContext(context.WithValue(r.Context(), clientIdentityKey{}, identity))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+var _ Reloadable = (*CertReloader)(nil)
@@ -0,0 +1,198 @@
+package platform
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// writeCert writes a self-signed certificate for 127.0.0.1 and its key to dir, it returns the
+// file paths and the parsed certificate
+func writeCert(t *testing.T, dir, commonName string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err = x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile, cert
+}
+
+// currentCommonName returns the common name of the certificate served by the reloader
+func currentCommonName(t *testing.T, r *CertReloader) string {
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, _ := writeCert(t, dir, "first")
+
+	reloader, err := NewCertReloader(certFile, keyFile, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	assert.Equal(t, "first", currentCommonName(t, reloader))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	// Renewed files are picked up
+	writeCert(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	assert.Eventually(t, func() bool {
+		return currentCommonName(t, reloader) == "second"
+	}, time.Second, 10*time.Millisecond)
+
+	// A broken certificate keeps the current one
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o600))
+	assert.Error(t, reloader.Reload(ctx))
+	assert.Equal(t, "second", currentCommonName(t, reloader))
+
+	_, err = NewCertReloader(filepath.Join(dir, "missing.pem"), keyFile, zaptest.NewLogger(t))
+	assert.Error(t, err)
+}
+
+func TestVMServiceStarter_startHTTPServiceTLS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	certFile, keyFile, cert := writeCert(t, t.TempDir(), "localhost")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+
+	addr := freeAddr(t)
+	service := newRoutedHTTPService()
+	service.On("Type").Return(HTTPServiceType)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, gin.New(), WithAddr(addr), WithTLS(certFile, keyFile))
+	}()
+
+	var resp *http.Response
+	var err error
+	assert.Eventually(t, func() bool {
+		resp, err = client.Get("https://" + addr + "/hello")
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	require.NotNil(t, resp)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, resp.TLS)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestVMServiceStarter_startHTTPServiceMutualTLS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	certFile, keyFile, serverCert := writeCert(t, t.TempDir(), "server")
+	clientCertFile, clientKeyFile, clientCert := writeCert(t, t.TempDir(), "orders-service")
+
+	serverCAs := x509.NewCertPool()
+	serverCAs.AddCert(serverCert)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+	keyPair, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	require.NoError(t, err)
+
+	service := new(MockHTTPService)
+	service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+	service.On("Type").Return(HTTPServiceType)
+	service.On("ConfigureRoutes", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		args.Get(1).(Engine).Handle(http.MethodGet, "/whoami", func(c *gin.Context) {
+			identity, ok := ClientIdentityFromContext(c.Request.Context())
+			if !ok {
+				c.Status(http.StatusUnauthorized)
+				return
+			}
+			c.String(http.StatusOK, identity.CommonName)
+		})
+	})
+
+	addr := freeAddr(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- NewVMServiceStarter(zaptest.NewLogger(t)).Start(ctx, service, gin.New(),
+			WithAddr(addr), WithTLS(certFile, keyFile), WithClientCA(clientCAs))
+	}()
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      serverCAs,
+		Certificates: []tls.Certificate{keyPair},
+	}}}
+
+	var resp *http.Response
+	assert.Eventually(t, func() bool {
+		resp, err = client.Get("https://" + addr + "/whoami")
+		return err == nil
+	}, 5*time.Second, 20*time.Millisecond)
+	require.NotNil(t, resp)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "orders-service", string(body))
+
+	// Clients without a certificate are rejected during the handshake
+	anonymous := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: serverCAs}}}
+	_, err = anonymous.Get("https://" + addr + "/whoami")
+	assert.Error(t, err)
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestVMServiceStarter_serveHTTPClientCAWithoutTLS(t *testing.T) {
+	err := NewVMServiceStarter(zaptest.NewLogger(t)).serveHTTP(context.Background(), http.NotFoundHandler(),
+		HTTPConfig{Addr: freeAddr(t), ClientCAs: x509.NewCertPool()})
+
+	assert.EqualError(t, err, "client certificate verification requires TLS")
+}
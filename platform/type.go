@@ -4,7 +4,11 @@ type Type string
 
 // Platform environment constants
 const (
-	VM Type = "virtual_machine" // Only implementing VM for now
+	VM             Type = "virtual_machine"
+	WindowsService Type = "windows_service" // Only available on Windows
+	CloudFunction  Type = "cloud_function"
+	Fly            Type = "fly_io"
+	ECS            Type = "aws_ecs"
 
 	// Future platform types (placeholders)
 	// Docker      Type = "docker"
@@ -21,10 +25,13 @@ type ServiceType string
 
 // Service type constants
 const (
-	HTTPServiceType ServiceType = "http" // Only implementing HTTP service for now
+	HTTPServiceType     ServiceType = "http"
+	TemporalServiceType ServiceType = "temporal"
+	MQTTServiceType     ServiceType = "mqtt"
+	TCPServiceType      ServiceType = "tcp"
+	GRPCServiceType     ServiceType = "grpc"
 
 	// Future service types (placeholders)
-	// GRPCService	  ServiceType = "grcp"
 	// QueueService   ServiceType = "queue"
 	// WorkerService  ServiceType = "worker"
 	// ScheduledTask  ServiceType = "scheduled"
@@ -4,70 +4,317 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/accesslog"
+	"github.com/jjmaturino/bootstrapper/systemd"
 	"go.uber.org/zap"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
+// middlewareEngine is implemented by engines that accept global middleware, like *gin.Engine
+type middlewareEngine interface {
+	Use(middleware ...gin.HandlerFunc) gin.IRoutes
+}
+
+// handlerMiddlewareEngine is implemented by routers that accept standard library middleware,
+// like *StdEngine
+type handlerMiddlewareEngine interface {
+	Use(middleware ...func(http.Handler) http.Handler)
+}
+
 // startHTTPService starts an HTTP service on the VM runtime platform
 func (v *VMServiceStarter) startHTTPService(ctx context.Context, service HTTPService, deps ...interface{}) error {
 	v.logger.Info("Setting up HTTP service")
 
 	// Find the engine in the dependencies
-	var engine Engine
-	for _, dep := range deps {
-		if eng, ok := dep.(Engine); ok {
-			engine = eng
-			break
-		}
+	engine, ok := DepOf[Engine](deps...)
+	if !ok {
+		return errors.New("engine not found in dependencies for HTTP service")
 	}
 
-	if engine == nil {
-		return errors.New("engine not found in dependencies for HTTP service")
+	if err := v.installAccessLog(engine, deps...); err != nil {
+		return err
 	}
 
 	// Configure routes
 	v.logger.Info("Configuring HTTP routes")
-	if err := service.ConfigureRoutes(ctx, engine); err != nil {
+	endConfigure := TimelineFromContext(ctx).Span("configure routes")
+	err := service.ConfigureRoutes(ctx, engine)
+	endConfigure(err)
+	if err != nil {
 		v.logger.Error("Failed to configure routes", zap.Error(err))
 		return fmt.Errorf("failed to configure routes: %w", err)
 	}
+	mountHealthEngine(engine, deps...)
+	mountDataSubjectsEngine(engine, v.logger, deps...)
+
+	// Engines that are not http.Handlers only know how to run themselves and cannot be drained
+	handler, ok := engine.(http.Handler)
+	if !ok {
+		config := httpConfigFrom(deps)
+		if config.tls() {
+			return errors.New("engine does not implement http.Handler, cannot serve TLS")
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		v.setupSignalHandling(ctx)
+
+		addr := config.Addr
+		v.logger.Info("Starting HTTP server", zap.String("addr", addr))
+		v.ready(ctx)
+
+		// Run the engine (this is blocking)
+		return engine.Run(addr)
+	}
+
+	return v.serveHTTP(ctx, handler, httpConfigFrom(deps))
+}
+
+// startRouterService starts a router-agnostic HTTP service on the VM runtime platform
+func (v *VMServiceStarter) startRouterService(ctx context.Context, service RouterService, deps ...interface{}) error {
+	v.logger.Info("Setting up HTTP service")
+
+	// Find the router in the dependencies, a gin engine is adapted when no router is provided
+	router, ok := DepOf[Router](deps...)
+	if !ok {
+		engine, ok := DepOf[*gin.Engine](deps...)
+		if !ok {
+			return errors.New("router not found in dependencies for HTTP service")
+		}
+		router = NewGinRouter(engine)
+	}
+
+	if err := v.installAccessLog(router, deps...); err != nil {
+		return err
+	}
+
+	v.logger.Info("Registering HTTP routes")
+	endRegister := TimelineFromContext(ctx).Span("register routes")
+	err := service.RegisterRoutes(ctx, router)
+	endRegister(err)
+	if err != nil {
+		v.logger.Error("Failed to register routes", zap.Error(err))
+		return fmt.Errorf("failed to register routes: %w", err)
+	}
+	mountHealthRouter(router, deps...)
+	mountDataSubjectsRouter(router, v.logger, deps...)
+
+	return v.serveHTTP(ctx, router, httpConfigFrom(deps))
+}
+
+// installAccessLog routes access logs to their own sink when an access logger is provided
+func (v *VMServiceStarter) installAccessLog(engine interface{}, deps ...interface{}) error {
+	accessLogger, ok := DepOf[*accesslog.Logger](deps...)
+	if !ok {
+		return nil
+	}
+
+	switch router := engine.(type) {
+	case middlewareEngine:
+		v.logger.Info("Installing access log middleware")
+		router.Use(accessLogger.Middleware())
+	case handlerMiddlewareEngine:
+		v.logger.Info("Installing access log middleware")
+		router.Use(accessLogger.Handler)
+	default:
+		return errors.New("engine does not support middleware, cannot install access logger")
+	}
+	return nil
+}
+
+// serveHTTP serves the handler until the context is cancelled, a shutdown signal is received or
+// the listener is handed off, then shuts down in phases: it stops accepting connections and waits
+// for in-flight requests up to the drain timeout, then for hijacked connections like WebSockets up
+// to the hijacked drain timeout. The start hooks' stop counterparts run once it returns.
+func (v *VMServiceStarter) serveHTTP(ctx context.Context, handler http.Handler, config HTTPConfig) error {
+	// Stop the signal, drain and handoff goroutines on every return path
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	draining := make(chan struct{})
+	tracker := newConnTracker()
+	server := &http.Server{
+		Addr:      config.Addr,
+		Handler:   handler,
+		ConnState: tracker.connState,
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithValue(context.Background(), drainingKey{}, draining)
+		},
+	}
 
 	// Setup signal handling for graceful shutdown
-	v.setupSignalHandling(ctx)
+	ctx = v.setupSignalHandling(ctx)
+
+	if config.ClientCAs != nil && !config.tls() {
+		return errors.New("client certificate verification requires TLS")
+	}
+	if config.tls() {
+		tlsConfig, err := v.serverTLSConfig(ctx, config)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsConfig
+	}
+	if config.ClientCAs != nil {
+		server.Handler = withClientIdentity(handler)
+	}
+
+	endBind := TimelineFromContext(ctx).Span("bind listener")
+	ln, err := v.tcpListener(config.Addr)
+	endBind(err)
+	if err != nil {
+		return err
+	}
 
-	// Start the HTTP server
-	v.logger.Info("Starting HTTP server")
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		<-ctx.Done()
+		close(draining)
+		v.shutdownHTTP(context.WithoutCancel(ctx), server, tracker, config)
+	}()
+
+	if config.Handoff != nil {
+		go v.awaitHandoff(ctx, ln, config.Handoff, config.HandoffTimeout, cancel)
+	}
+
+	v.logger.Info("Starting HTTP server", zap.String("addr", ln.Addr().String()), zap.Bool("tls", config.tls()))
+	v.ready(ctx)
+
+	serve := server.Serve
+	if server.TLSConfig != nil {
+		// The certificates are in the TLS config
+		serve = func(ln net.Listener) error { return server.ServeTLS(ln, "", "") }
+	}
+
+	if err := serve(tracker.listener(ln)); !errors.Is(err, http.ErrServerClosed) {
+		v.logger.Error("HTTP server failed", zap.Error(err))
+		cancel()
+		<-shutdownDone
+		return fmt.Errorf("http server failed: %w", err)
+	}
 
-	// Run the engine (this is blocking)
-	// Start the Gin server on default port 8080
-	return engine.Run() // Default listens on :8080
+	// Return once in-flight requests are drained
+	<-shutdownDone
+	v.logger.Info("HTTP server stopped")
+
+	return nil
 }
 
-// setupSignalHandling sets up OS signal handlers  for graceful shutdown
-func (v *VMServiceStarter) setupSignalHandling(ctx context.Context) {
+// shutdownHTTP stops the server accepting connections and drains it, logging how many
+// connections were outstanding at each phase
+func (v *VMServiceStarter) shutdownHTTP(ctx context.Context, server *http.Server, tracker *connTracker, config HTTPConfig) {
+	open, hijacked := tracker.counts()
+	v.logger.Info("Draining HTTP server",
+		zap.String("phase", "drain http"),
+		zap.Int("connections", open-hijacked),
+		zap.Int("hijacked", hijacked),
+		zap.Duration("drainTimeout", config.DrainTimeout))
+
+	started := time.Now()
+	drainCtx, cancel := context.WithTimeout(ctx, config.DrainTimeout)
+	defer cancel()
+	if err := server.Shutdown(drainCtx); err != nil {
+		open, hijacked = tracker.counts()
+		v.logger.Warn("HTTP server drain did not complete, closing remaining connections",
+			zap.Int("connections", open-hijacked),
+			zap.Error(err))
+		_ = server.Close()
+	}
+
+	// Hijacked connections are not tracked by the server, their handlers watch DrainingFromContext
+	_, hijacked = tracker.counts()
+	v.logger.Info("Draining hijacked connections",
+		zap.String("phase", "drain hijacked"),
+		zap.Int("hijacked", hijacked),
+		zap.Duration("drainTimeout", config.HijackedDrainTimeout),
+		zap.Duration("elapsed", time.Since(started)))
+
+	hijackedCtx, cancelHijacked := context.WithTimeout(ctx, config.HijackedDrainTimeout)
+	defer cancelHijacked()
+	if closed := tracker.waitHijacked(hijackedCtx); closed > 0 {
+		v.logger.Warn("Hijacked connections drain did not complete, closed remaining connections", zap.Int("hijacked", closed))
+	}
+
+	v.logger.Info("HTTP server drained", zap.Duration("elapsed", time.Since(started)))
+}
+
+// setupSignalHandling sets up OS signal handlers  for graceful shutdown, the returned context is
+// cancelled when a shutdown signal is received. When the context carries a ConfigReloader, the
+// same goroutine reloads the config on SIGHUP and when its file changes, one reload at a time.
+func (v *VMServiceStarter) setupSignalHandling(ctx context.Context) context.Context {
 	// Create a cancellable context that we can pass to child goroutines
 	ctx, cancel := context.WithCancel(ctx)
 
+	// Register for SIGINT and SIGTERM, and SIGHUP when the config is reloaded
+	signals := []os.Signal{syscall.SIGINT, syscall.SIGTERM}
+	reloader, reloads := ConfigReloaderFromContext(ctx)
+	if reloads {
+		signals = append(signals, syscall.SIGHUP)
+	}
+
 	// Create channel to listen for signals
 	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, signals...)
 
-	// Register for SIGINT and SIGTERM
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// A nil channel never fires when there is no config file to poll
+	var poll <-chan time.Time
+	var ticker *time.Ticker
+	if reloads && reloader.PollInterval() > 0 {
+		ticker = time.NewTicker(reloader.PollInterval())
+		poll = ticker.C
+	}
 
 	// Handle signals in a separate goroutine
 	go func() {
-		select {
-		case sig := <-sigChan:
-			v.logger.Info("Received signal", zap.String("signal", sig.String()))
-			cancel() // Cancel context to notify all parts of the application
-		case <-ctx.Done():
-			// Context was cancelled elsewhere
-			v.logger.Info("Context done, exiting signal handler")
+		defer signal.Stop(sigChan)
+		if ticker != nil {
+			defer ticker.Stop()
+		}
+		for {
+			select {
+			case sig := <-sigChan:
+				if sig == syscall.SIGHUP {
+					v.logger.Info("Received SIGHUP, reloading config")
+					reloader.ReloadConfig(ctx)
+					continue
+				}
+				v.logger.Info("Received signal", zap.String("signal", sig.String()))
+				v.notifySystemd(systemd.Stopping)
+				NotifyShutdown(ctx)
+				cancel() // Cancel context to notify all parts of the application
+				return
+			case <-poll:
+				reloader.ReloadIfChanged(ctx)
+			case <-ctx.Done():
+				// Context was cancelled elsewhere, the starter may have returned so nothing is logged
+				return
+			}
 		}
 	}()
+
+	return ctx
+}
+
+// ready reports the service as ready to systemd and to the process handing off to it, and logs the startup timeline
+func (v *VMServiceStarter) ready(ctx context.Context) {
+	v.notifySystemd(systemd.Ready)
+	reportHandoffReady()
+	TimelineFromContext(ctx).Finish()
+}
+
+// notifySystemd sends a state notification when running as a systemd notify unit
+func (v *VMServiceStarter) notifySystemd(state string) {
+	if _, err := systemd.Notify(state); err != nil {
+		v.logger.Warn("Failed to notify systemd", zap.String("state", state), zap.Error(err))
+	}
 }
 
 // StartService starts a service on the VM platform based on service type
@@ -75,20 +322,67 @@ func (v *VMServiceStarter) Start(ctx context.Context, service Service, deps ...i
 	v.logger.Info("Starting service on VM platform", zap.String("type", string(service.Type())))
 
 	// Initialize the service first
-	if err := service.Initialize(ctx, deps...); err != nil {
+	endInitialize := TimelineFromContext(ctx).Span("initialize service")
+	err := service.Initialize(ctx, deps...)
+	endInitialize(err)
+	if err != nil {
 		v.logger.Error("Failed to initialize service", zap.Error(err))
 		return fmt.Errorf("failed to initialize service: %w", err)
 	}
 
+	// Run the start hooks, and the stop hooks once the service stopped serving
+	stopHooks, err := startLifecycle(ctx, service, v.logger)
+	if err != nil {
+		return err
+	}
+	defer stopHooks()
+
+	// Keep the systemd watchdog fed when it is enabled for the unit, for as long as the service runs
+	watchdogCtx, stopWatchdog := context.WithCancel(ctx)
+	defer stopWatchdog()
+	go systemd.RunWatchdog(watchdogCtx, v.logger)
+
 	// Handle based on service type
 	switch service.Type() {
 	case HTTPServiceType:
+		if routerService, ok := service.(RouterService); ok {
+			return v.startRouterService(ctx, routerService, deps...)
+		}
+
 		httpService, ok := service.(HTTPService)
 		if !ok {
 			return errors.New("service claims to be HTTP but does not implement HTTPService interface")
 		}
 		return v.startHTTPService(ctx, httpService, deps...)
 
+	case TemporalServiceType:
+		temporalService, ok := service.(TemporalWorkerService)
+		if !ok {
+			return errors.New("service claims to be Temporal but does not implement TemporalWorkerService interface")
+		}
+		return v.startTemporalWorker(ctx, temporalService, deps...)
+
+	case MQTTServiceType:
+		mqttService, ok := service.(MQTTService)
+		if !ok {
+			return errors.New("service claims to be MQTT but does not implement MQTTService interface")
+		}
+		return v.startMQTTService(ctx, mqttService, deps...)
+
+	case TCPServiceType:
+		tcpService, ok := service.(TCPService)
+		if !ok {
+			return errors.New("service claims to be TCP but does not implement TCPService interface")
+		}
+		return v.startTCPService(ctx, tcpService, deps...)
+
+	case GRPCServiceType:
+		grpcService, ok := service.(GRPCService)
+		if !ok {
+			return errors.New("service claims to be gRPC but does not implement GRPCService interface")
+		}
+		return v.startGRPCService(ctx, grpcService, deps...)
+
 	default:
 		return fmt.Errorf("unsupported service type for VM platform: %s", service.Type())
 	}
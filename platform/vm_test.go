@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/accesslog"
+	"net"
+	"path/filepath"
+	"runtime"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zaptest"
 )
@@ -127,6 +132,7 @@ func TestVMServiceStarter_Start(t *testing.T) {
 			service: func() Service {
 				mockHTTP := new(MockHTTPService)
 				mockHTTP.On("Initialize", mock.Anything, mock.Anything).Return(errors.New("initialize error"))
+				mockHTTP.On("Type").Return(HTTPServiceType)
 				return mockHTTP
 			},
 			deps:        []interface{}{},
@@ -189,28 +195,6 @@ func TestVMServiceStarter_Start(t *testing.T) {
 			ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
 			defer cancel()
 
-			// For tests with engine.Run(), which normally blocks, we need to cancel the context
-			// to allow the test to complete
-			if mockHTTP, ok := service.(*MockHTTPService); ok {
-				if mockHTTP.AssertExpectations(t) {
-					for _, dep := range tt.deps {
-						if mockEngine, ok := dep.(Engine); ok {
-							// Mock the Run method to return after a short delay or when context is done
-							mockEngine.(*MockEngine).On("Run", mock.Anything).Return(nil).Run(func(args mock.Arguments) {
-								// Wait for context to be done or a short timeout
-								select {
-								case <-ctx.Done():
-									return
-								case <-time.After(50 * time.Millisecond):
-									cancel() // Cancel context to allow test to complete
-									return
-								}
-							})
-						}
-					}
-				}
-			}
-
 			err := starter.Start(ctx, service, tt.deps...)
 
 			if tt.wantErr {
@@ -381,3 +365,80 @@ func TestVMServiceStarter_setupSignalHandling(t *testing.T) {
 	// Give the goroutine time to process the cancellation and exit
 	time.Sleep(50 * time.Millisecond)
 }
+
+// MockMiddlewareEngine is a mock engine that also accepts global middleware
+type MockMiddlewareEngine struct {
+	MockEngine
+}
+
+func (m *MockMiddlewareEngine) Use(middleware ...gin.HandlerFunc) gin.IRoutes {
+	args := m.Called(middleware)
+	return args.Get(0).(gin.IRoutes)
+}
+
+func TestVMServiceStarter_startHTTPServiceAccessLog(t *testing.T) {
+	logger := zaptest.NewLogger(t)
+	ctx := context.Background()
+
+	accessLogger, err := accesslog.New(accesslog.Config{Sink: accesslog.StderrSink})
+	assert.NoError(t, err)
+
+	t.Run("installs middleware", func(t *testing.T) {
+		starter := NewVMServiceStarter(logger)
+
+		service := new(MockHTTPService)
+		service.On("ConfigureRoutes", mock.Anything, mock.Anything).Return(nil)
+
+		engine := new(MockMiddlewareEngine)
+		engine.On("Use", mock.Anything).Return(gin.New())
+		engine.On("Run", mock.Anything).Return(nil)
+
+		err := starter.startHTTPService(ctx, service, engine, accessLogger)
+
+		assert.NoError(t, err)
+		engine.AssertExpectations(t)
+	})
+
+	t.Run("engine without middleware support", func(t *testing.T) {
+		starter := NewVMServiceStarter(logger)
+
+		err := starter.startHTTPService(ctx, new(MockHTTPService), new(MockEngine), accessLogger)
+
+		assert.EqualError(t, err, "engine does not support middleware, cannot install access logger")
+	})
+}
+
+func TestVMServiceStarter_StartStopsWatchdog(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unixgram sockets are not supported on windows")
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", path)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	service := new(MockService)
+	service.On("Initialize", mock.Anything, mock.Anything).Return(nil)
+	service.On("Type").Return(ServiceType("unsupported"))
+
+	// The service fails right away while the caller's context lives on
+	err = NewVMServiceStarter(zaptest.NewLogger(t)).Start(context.Background(), service)
+	require.Error(t, err)
+
+	// Pings sent before Start returned may still be queued, none follow them
+	time.Sleep(50 * time.Millisecond)
+	buf := make([]byte, 64)
+	for {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(10*time.Millisecond)))
+		if _, err := conn.Read(buf); err != nil {
+			break
+		}
+	}
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err = conn.Read(buf)
+	assert.Error(t, err, "Expected no watchdog pings once the service stopped")
+}
@@ -0,0 +1,212 @@
+//go:build windows
+
+package platform
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// windowsStopTimeout bounds how long a stop request waits for the service to return
+const windowsStopTimeout = 30 * time.Second
+
+// WindowsServiceStarter runs services under the Windows Service Control Manager, delegating the
+// service type handling to the VM starter. When the process is not started by the SCM, e.g. from
+// a console during development, it behaves like the VM starter.
+type WindowsServiceStarter struct {
+	name   string
+	logger *zap.Logger
+	vm     *VMServiceStarter
+}
+
+// NewWindowsServiceStarter creates a new Windows service starter for the named SCM service
+func NewWindowsServiceStarter(name string, logger *zap.Logger) *WindowsServiceStarter {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	return &WindowsServiceStarter{
+		name:   name,
+		logger: logger,
+		vm:     NewVMServiceStarter(logger),
+	}
+}
+
+// Start runs the service under the SCM, blocking until the SCM stops it
+func (w *WindowsServiceStarter) Start(ctx context.Context, service Service, deps ...interface{}) error {
+	isService, err := svc.IsWindowsService()
+	if err != nil {
+		return fmt.Errorf("failed to determine if running as a windows service: %w", err)
+	}
+
+	if !isService {
+		w.logger.Info("Not running under the Service Control Manager, starting on VM platform")
+		return w.vm.Start(ctx, service, deps...)
+	}
+
+	w.logger.Info("Starting Windows service", zap.String("name", w.name))
+	handler := &windowsServiceHandler{ctx: ctx, starter: w, service: service, deps: deps}
+	if err := svc.Run(w.name, handler); err != nil {
+		w.logger.Error("Windows service failed", zap.Error(err))
+		return fmt.Errorf("failed to run windows service %s: %w", w.name, err)
+	}
+
+	return handler.err
+}
+
+// windowsServiceHandler adapts a service to the SCM control protocol
+type windowsServiceHandler struct {
+	ctx     context.Context
+	starter *WindowsServiceStarter
+	service Service
+	deps    []interface{}
+
+	// err is the error the service returned, reported by Start once the SCM loop exits
+	err error
+}
+
+// Execute implements svc.Handler
+func (h *windowsServiceHandler) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	logger := h.starter.logger
+
+	accepts := svc.AcceptStop | svc.AcceptShutdown
+	pausable, canPause := h.service.(PausableService)
+	if canPause {
+		accepts |= svc.AcceptPauseAndContinue
+	}
+
+	status <- svc.Status{State: svc.StartPending}
+
+	ctx, cancel := context.WithCancel(h.ctx)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- h.starter.vm.Start(ctx, h.service, h.deps...)
+	}()
+
+	status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+	for {
+		select {
+		case err := <-done:
+			// The service returned on its own
+			h.err = err
+			if err != nil {
+				logger.Error("Service stopped with error", zap.Error(err))
+				return true, 1
+			}
+			return false, 0
+
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+
+			case svc.Stop, svc.Shutdown:
+				logger.Info("Received stop request from the Service Control Manager")
+				status <- svc.Status{State: svc.StopPending}
+				cancel()
+
+				select {
+				case h.err = <-done:
+				case <-time.After(windowsStopTimeout):
+					logger.Warn("Service did not stop in time", zap.Duration("timeout", windowsStopTimeout))
+				}
+				return false, 0
+
+			case svc.Pause:
+				if err := pausable.Pause(ctx); err != nil {
+					logger.Error("Failed to pause service", zap.Error(err))
+					continue
+				}
+				status <- svc.Status{State: svc.Paused, Accepts: accepts}
+
+			case svc.Continue:
+				if err := pausable.Continue(ctx); err != nil {
+					logger.Error("Failed to continue service", zap.Error(err))
+					continue
+				}
+				status <- svc.Status{State: svc.Running, Accepts: accepts}
+
+			default:
+				logger.Warn("Unexpected control request", zap.Uint32("cmd", uint32(req.Cmd)))
+			}
+		}
+	}
+}
+
+// NewEventLogCore creates a zap core writing entries to the Windows event log under source. The
+// source must already be registered, e.g. with eventlog.InstallAsEventCreate when the service is
+// installed.
+func NewEventLogCore(source string, level zapcore.LevelEnabler) (zapcore.Core, error) {
+	elog, err := eventlog.Open(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event log source %s: %w", source, err)
+	}
+
+	return &eventLogCore{
+		LevelEnabler: level,
+		encoder:      zapcore.NewConsoleEncoder(zap.NewProductionEncoderConfig()),
+		elog:         elog,
+	}, nil
+}
+
+// eventLogCore is a zapcore.Core reporting entries to the event log
+type eventLogCore struct {
+	zapcore.LevelEnabler
+	encoder zapcore.Encoder
+	elog    *eventlog.Log
+}
+
+func (c *eventLogCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.encoder = c.encoder.Clone()
+	for _, field := range fields {
+		field.AddTo(clone.encoder)
+	}
+	return &clone
+}
+
+func (c *eventLogCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+func (c *eventLogCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	buf, err := c.encoder.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+
+	// Event IDs are not used to categorize entries, the level is carried by the event type
+	const eventID = 1
+	switch {
+	case entry.Level >= zapcore.ErrorLevel:
+		return c.elog.Error(eventID, buf.String())
+	case entry.Level == zapcore.WarnLevel:
+		return c.elog.Warning(eventID, buf.String())
+	default:
+		return c.elog.Info(eventID, buf.String())
+	}
+}
+
+func (c *eventLogCore) Sync() error {
+	return nil
+}
+
+var _ ServiceStarter = (*WindowsServiceStarter)(nil)
@@ -0,0 +1,28 @@
+//go:build windows
+
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestNewWindowsServiceStarter(t *testing.T) {
+	tests := []struct {
+		name    string
+		starter *WindowsServiceStarter
+	}{
+		{name: "with logger", starter: NewWindowsServiceStarter("svc", zaptest.NewLogger(t))},
+		{name: "with nil logger", starter: NewWindowsServiceStarter("svc", nil)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, "svc", tt.starter.name)
+			assert.NotNil(t, tt.starter.logger)
+			assert.NotNil(t, tt.starter.vm)
+		})
+	}
+}
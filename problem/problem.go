@@ -0,0 +1,76 @@
+// Package problem writes RFC 7807 problem details responses. The middleware of the bootstrapper
+// embed Problem in their own problem types, adding their extension members, so every error
+// response shares one format.
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType is the RFC 7807 media type
+const ContentType = "application/problem+json"
+
+// Problem types shared by the middleware
+const (
+	// InternalProblemType is returned when the server failed to handle the request
+	InternalProblemType = "urn:bootstrapper:problem:internal"
+
+	// UnavailableProblemType is returned when a backend the request depends on is unavailable
+	UnavailableProblemType = "urn:bootstrapper:problem:unavailable"
+)
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Details is implemented by Problem and the types embedding it
+type Details interface {
+	// StatusCode returns the HTTP status of the response
+	StatusCode() int
+}
+
+// StatusCode returns the HTTP status of the problem
+func (p Problem) StatusCode() int {
+	return p.Status
+}
+
+// Internal creates the problem returned when the server failed to handle the request, the detail
+// is sent to the client so it must not carry internal errors
+func Internal(detail string) Problem {
+	return Problem{
+		Type:   InternalProblemType,
+		Title:  "Internal error",
+		Status: http.StatusInternalServerError,
+		Detail: detail,
+	}
+}
+
+// Unavailable creates the problem returned when a backend the request depends on is unavailable
+func Unavailable(detail string) Problem {
+	return Problem{
+		Type:   UnavailableProblemType,
+		Title:  "Service unavailable",
+		Status: http.StatusServiceUnavailable,
+		Detail: detail,
+	}
+}
+
+// Abort writes the problem as the response and stops the handler chain
+func Abort(c *gin.Context, problem Details) {
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(problem.StatusCode(), problem)
+}
+
+// Write writes the problem as the response of a net/http handler
+func Write(w http.ResponseWriter, problem Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(problem.StatusCode())
+	_ = json.NewEncoder(w).Encode(problem)
+}
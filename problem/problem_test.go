@@ -0,0 +1,52 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// extended embeds Problem with an extension member
+type extended struct {
+	Problem
+	Field string `json:"field"`
+}
+
+func TestAbort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.GET("/", func(c *gin.Context) {
+		Abort(c, extended{Problem: Unavailable("role store unavailable"), Field: "avatar"})
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{
+		"type": "urn:bootstrapper:problem:unavailable",
+		"title": "Service unavailable",
+		"status": 503,
+		"detail": "role store unavailable",
+		"field": "avatar"
+	}`, rec.Body.String())
+}
+
+func TestWrite(t *testing.T) {
+	rec := httptest.NewRecorder()
+	Write(rec, Internal("request failed"))
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, ContentType, rec.Header().Get("Content-Type"))
+
+	var problem Problem
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+	assert.Equal(t, Internal("request failed"), problem)
+}
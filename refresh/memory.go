@@ -0,0 +1,91 @@
+package refresh
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// memoryPruneInterval is how often Create prunes the families that can no longer be rotated
+const memoryPruneInterval = time.Minute
+
+// MemoryStore keeps refresh tokens in memory, for tests and single-instance services whose
+// sessions may be lost on restart. Families whose tokens are all expired or revoked are pruned as
+// new tokens are created, used tokens of live families are kept to detect their reuse.
+type MemoryStore struct {
+	// mu protects tokens and lastPrune
+	mu        sync.Mutex
+	tokens    map[string]Token
+	lastPrune time.Time
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tokens: make(map[string]Token), now: time.Now}
+}
+
+// Create stores a new token
+func (s *MemoryStore) Create(ctx context.Context, token Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now := s.now(); now.Sub(s.lastPrune) >= memoryPruneInterval {
+		s.pruneLocked(now)
+		s.lastPrune = now
+	}
+	s.tokens[token.ID] = token
+	return nil
+}
+
+// Consume marks the token as used and returns its state from before
+func (s *MemoryStore) Consume(ctx context.Context, id string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	token, ok := s.tokens[id]
+	if !ok {
+		return Token{}, ErrNotFound
+	}
+
+	used := token
+	used.Used = true
+	s.tokens[id] = used
+
+	return token, nil
+}
+
+// RevokeFamily revokes every token of the family
+func (s *MemoryStore) RevokeFamily(ctx context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, token := range s.tokens {
+		if token.Family == family {
+			token.Revoked = true
+			s.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+// pruneLocked removes the tokens of families without a token that can still be rotated, any of
+// their tokens would be rejected anyway
+func (s *MemoryStore) pruneLocked(now time.Time) {
+	live := make(map[string]bool)
+	for _, token := range s.tokens {
+		if !token.Revoked && now.Before(token.ExpiresAt) {
+			live[token.Family] = true
+		}
+	}
+
+	for id, token := range s.tokens {
+		if !live[token.Family] {
+			delete(s.tokens, id)
+		}
+	}
+}
+
+var _ Store = (*MemoryStore)(nil)
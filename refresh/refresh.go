@@ -0,0 +1,193 @@
+// Package refresh issues and rotates opaque refresh tokens for services that own their
+// authentication. Every rotation invalidates the presented token, and presenting a token twice
+// revokes its whole family, so a stolen refresh token is detected as soon as either the thief or
+// the legitimate client uses it after the other.
+package refresh
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultTTL is the lifetime of a refresh token when the manager has none
+const DefaultTTL = 30 * 24 * time.Hour
+
+// Token validation errors
+var (
+	ErrInvalidToken = errors.New("invalid refresh token")
+	ErrTokenExpired = errors.New("refresh token expired")
+	ErrTokenRevoked = errors.New("refresh token revoked")
+	ErrTokenReused  = errors.New("refresh token reused")
+)
+
+// ErrNotFound is returned by stores for unknown tokens
+var ErrNotFound = errors.New("refresh token not found")
+
+// Token is the stored state of a refresh token, the raw token itself is never stored
+type Token struct {
+	// ID is the SHA-256 of the raw token, hex encoded
+	ID string
+
+	// Family groups the tokens rotated from the same login
+	Family string
+
+	// Subject is the authenticated subject the token was issued to
+	Subject string
+
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+
+	// Used is set once the token was rotated
+	Used bool
+
+	// Revoked is set when the family was revoked
+	Revoked bool
+}
+
+// Store persists refresh tokens, in Redis, Postgres or memory. Consume must be atomic so only one
+// of concurrent rotations of a token sees it unused, in Postgres for instance:
+//
+//	UPDATE refresh_tokens SET used = true
+//	FROM (SELECT id, used FROM refresh_tokens WHERE id = $1 FOR UPDATE) AS previous
+//	WHERE refresh_tokens.id = previous.id
+//	RETURNING refresh_tokens.family, refresh_tokens.subject, ..., previous.used
+type Store interface {
+	// Create stores a new token
+	Create(ctx context.Context, token Token) error
+
+	// Consume marks the token as used and returns its state from before, ErrNotFound when the
+	// token is unknown
+	Consume(ctx context.Context, id string) (Token, error)
+
+	// RevokeFamily revokes every token of the family
+	RevokeFamily(ctx context.Context, family string) error
+}
+
+// Manager issues and rotates refresh tokens
+type Manager struct {
+	store  Store
+	ttl    time.Duration
+	logger *zap.Logger
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// NewManager creates a manager issuing tokens valid for ttl, DefaultTTL when zero
+func NewManager(store Store, ttl time.Duration, logger *zap.Logger) *Manager {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	return &Manager{store: store, ttl: ttl, logger: logger, now: time.Now}
+}
+
+// Issue creates the first token of a new family for the subject, on login. It returns the raw
+// token to hand to the client.
+func (m *Manager) Issue(ctx context.Context, subject string) (string, Token, error) {
+	family, err := randomString(16)
+	if err != nil {
+		return "", Token{}, err
+	}
+
+	return m.issue(ctx, subject, family)
+}
+
+// Rotate exchanges a raw token for a new one of the same family. A token presented after it was
+// rotated revokes its family and fails with ErrTokenReused.
+func (m *Manager) Rotate(ctx context.Context, raw string) (string, Token, error) {
+	previous, err := m.store.Consume(ctx, hashToken(raw))
+	if errors.Is(err, ErrNotFound) {
+		return "", Token{}, ErrInvalidToken
+	}
+	if err != nil {
+		return "", Token{}, fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+
+	switch {
+	case previous.Revoked:
+		return "", Token{}, ErrTokenRevoked
+	case previous.Used:
+		m.logger.Warn("Refresh token reuse detected, revoking the token family",
+			zap.String("subject", previous.Subject),
+			zap.String("family", previous.Family))
+		if err := m.store.RevokeFamily(ctx, previous.Family); err != nil {
+			return "", Token{}, fmt.Errorf("failed to revoke refresh token family: %w", err)
+		}
+		return "", Token{}, ErrTokenReused
+	case !m.now().Before(previous.ExpiresAt):
+		return "", Token{}, ErrTokenExpired
+	}
+
+	return m.issue(ctx, previous.Subject, previous.Family)
+}
+
+// Revoke revokes the family of the raw token, on logout
+func (m *Manager) Revoke(ctx context.Context, raw string) error {
+	token, err := m.store.Consume(ctx, hashToken(raw))
+	if errors.Is(err, ErrNotFound) {
+		return ErrInvalidToken
+	}
+	if err != nil {
+		return fmt.Errorf("failed to consume refresh token: %w", err)
+	}
+
+	if err := m.store.RevokeFamily(ctx, token.Family); err != nil {
+		return fmt.Errorf("failed to revoke refresh token family: %w", err)
+	}
+	return nil
+}
+
+// issue creates and stores a token of the family
+func (m *Manager) issue(ctx context.Context, subject, family string) (string, Token, error) {
+	raw, err := randomString(32)
+	if err != nil {
+		return "", Token{}, err
+	}
+
+	now := m.now().UTC()
+	token := Token{
+		ID:        hashToken(raw),
+		Family:    family,
+		Subject:   subject,
+		IssuedAt:  now,
+		ExpiresAt: now.Add(m.ttl),
+	}
+	if err := m.store.Create(ctx, token); err != nil {
+		return "", Token{}, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return raw, token, nil
+}
+
+// hashToken returns the ID of a raw token
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomString returns n random bytes, base64url encoded
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,149 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestManager_IssueAndRotate(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	m := NewManager(store, time.Hour, zaptest.NewLogger(t))
+
+	raw, token, err := m.Issue(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", token.Subject)
+	assert.Equal(t, hashToken(raw), token.ID)
+	assert.NotContains(t, store.tokens, raw, "raw tokens must not be stored")
+
+	rotated, next, err := m.Rotate(ctx, raw)
+	require.NoError(t, err)
+	assert.NotEqual(t, raw, rotated)
+	assert.Equal(t, token.Family, next.Family)
+	assert.Equal(t, "alice", next.Subject)
+
+	_, _, err = m.Rotate(ctx, rotated)
+	assert.NoError(t, err)
+}
+
+func TestManager_RotateReuseRevokesFamily(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewMemoryStore(), time.Hour, zaptest.NewLogger(t))
+
+	stolen, _, err := m.Issue(ctx, "alice")
+	require.NoError(t, err)
+	current, _, err := m.Rotate(ctx, stolen)
+	require.NoError(t, err)
+
+	_, _, err = m.Rotate(ctx, stolen)
+	assert.ErrorIs(t, err, ErrTokenReused)
+
+	// The legitimate client is logged out as well
+	_, _, err = m.Rotate(ctx, current)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+}
+
+func TestManager_RotateErrors(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewMemoryStore(), time.Hour, zaptest.NewLogger(t))
+
+	_, _, err := m.Rotate(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	raw, _, err := m.Issue(ctx, "alice")
+	require.NoError(t, err)
+	m.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, _, err = m.Rotate(ctx, raw)
+	assert.ErrorIs(t, err, ErrTokenExpired)
+}
+
+func TestManager_Revoke(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewMemoryStore(), 0, zaptest.NewLogger(t))
+	assert.Equal(t, DefaultTTL, m.ttl)
+
+	raw, _, err := m.Issue(ctx, "alice")
+	require.NoError(t, err)
+	current, _, err := m.Rotate(ctx, raw)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Revoke(ctx, current))
+	_, _, err = m.Rotate(ctx, current)
+	assert.ErrorIs(t, err, ErrTokenRevoked)
+
+	assert.ErrorIs(t, m.Revoke(ctx, "unknown"), ErrInvalidToken)
+}
+
+func TestManager_ConcurrentRotation(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(NewMemoryStore(), time.Hour, zaptest.NewLogger(t))
+
+	raw, _, err := m.Issue(ctx, "alice")
+	require.NoError(t, err)
+
+	const attempts = 8
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _, errs[i] = m.Rotate(ctx, raw)
+		}(i)
+	}
+	wg.Wait()
+
+	succeeded := 0
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+		assert.True(t, errors.Is(err, ErrTokenReused) || errors.Is(err, ErrTokenRevoked), err)
+	}
+	assert.Equal(t, 1, succeeded)
+}
+
+func TestMemoryStore_Prune(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(1700000000, 0)
+	now := start
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	create := func(id, family string, expiresAt time.Time, revoked bool) {
+		require.NoError(t, store.Create(ctx, Token{ID: id, Family: family, ExpiresAt: expiresAt, Revoked: revoked}))
+	}
+	create("expired", "logged-out", start.Add(time.Second), false)
+	create("revoked", "stolen", start.Add(time.Hour), true)
+	create("used", "active", start.Add(time.Second), false)
+	create("current", "active", start.Add(time.Hour), false)
+	_, err := store.Consume(ctx, "used")
+	require.NoError(t, err)
+
+	// Pruning waits for the interval
+	now = start.Add(memoryPruneInterval / 2)
+	create("new", "fresh", now.Add(time.Hour), false)
+	assert.Len(t, store.tokens, 5)
+
+	now = start.Add(memoryPruneInterval)
+	create("newer", "fresh", now.Add(time.Hour), false)
+
+	// The used token of a live family is kept to detect its reuse
+	assert.ElementsMatch(t, []string{"used", "current", "new", "newer"}, tokenIDs(store))
+}
+
+func tokenIDs(store *MemoryStore) []string {
+	var ids []string
+	for id := range store.tokens {
+		ids = append(ids, id)
+	}
+	return ids
+}
@@ -0,0 +1,146 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// StepFunc is the signature shared by step actions and compensations
+type StepFunc func(ctx context.Context) error
+
+// Step is a single unit of work in a saga along with the action that undoes it
+type Step struct {
+	// Name identifies the step in logs and errors
+	Name string
+
+	// Action performs the step
+	Action StepFunc
+
+	// Compensate undoes the step, it may be nil if the step has nothing to undo
+	Compensate StepFunc
+}
+
+// Saga coordinates a multi-step operation, running compensations in reverse order when a step fails
+type Saga struct {
+	name   string
+	steps  []Step
+	logger *zap.Logger
+
+	// compensationAttempts is the number of times a compensation is tried before giving up
+	compensationAttempts int
+
+	// compensationBackoff is the delay between compensation attempts
+	compensationBackoff time.Duration
+}
+
+// New creates a new saga with the provided name and logger
+func New(name string, logger *zap.Logger) *Saga {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	return &Saga{
+		name:                 name,
+		logger:               logger.With(zap.String("saga", name)),
+		compensationAttempts: 3,
+		compensationBackoff:  100 * time.Millisecond,
+	}
+}
+
+// AddStep registers a step and its compensating action, steps run in registration order
+func (s *Saga) AddStep(name string, action StepFunc, compensate StepFunc) *Saga {
+	s.steps = append(s.steps, Step{Name: name, Action: action, Compensate: compensate})
+	return s
+}
+
+// WithCompensationRetry sets how many times each compensation is attempted and the delay between attempts
+func (s *Saga) WithCompensationRetry(attempts int, backoff time.Duration) *Saga {
+	if attempts < 1 {
+		attempts = 1
+	}
+	s.compensationAttempts = attempts
+	s.compensationBackoff = backoff
+	return s
+}
+
+// Execute runs every step in order. If a step fails, the compensations of all previously
+// completed steps are run in reverse order and the step error is returned, joined with any
+// compensation failures.
+func (s *Saga) Execute(ctx context.Context) error {
+	// Reject invalid sagas before any step runs, so there is nothing to compensate
+	for _, step := range s.steps {
+		if step.Action == nil {
+			return fmt.Errorf("saga %s: step %s has no action", s.name, step.Name)
+		}
+	}
+
+	s.logger.Info("Executing saga", zap.Int("steps", len(s.steps)))
+
+	for i, step := range s.steps {
+		s.logger.Debug("Running saga step", zap.String("step", step.Name))
+		if err := step.Action(ctx); err != nil {
+			s.logger.Error("Saga step failed", zap.String("step", step.Name), zap.Error(err))
+
+			stepErr := fmt.Errorf("saga %s failed at step %s: %w", s.name, step.Name, err)
+			if compErr := s.compensate(ctx, s.steps[:i]); compErr != nil {
+				return errors.Join(stepErr, compErr)
+			}
+			return stepErr
+		}
+	}
+
+	s.logger.Info("Saga completed")
+	return nil
+}
+
+// compensate runs the compensations of the completed steps in reverse order
+func (s *Saga) compensate(ctx context.Context, completed []Step) error {
+	// Compensations must run even if the caller's context was cancelled
+	ctx = context.WithoutCancel(ctx)
+
+	var errs []error
+	for i := len(completed) - 1; i >= 0; i-- {
+		step := completed[i]
+		if step.Compensate == nil {
+			continue
+		}
+
+		if err := s.runCompensation(ctx, step); err != nil {
+			errs = append(errs, fmt.Errorf("compensation for step %s failed: %w", step.Name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// runCompensation attempts a single compensation, retrying on failure
+func (s *Saga) runCompensation(ctx context.Context, step Step) error {
+	var err error
+	for attempt := 1; attempt <= s.compensationAttempts; attempt++ {
+		if err = step.Compensate(ctx); err == nil {
+			s.logger.Info("Compensated saga step", zap.String("step", step.Name), zap.Int("attempt", attempt))
+			return nil
+		}
+
+		s.logger.Warn("Saga compensation attempt failed",
+			zap.String("step", step.Name),
+			zap.Int("attempt", attempt),
+			zap.Error(err))
+
+		if attempt < s.compensationAttempts {
+			time.Sleep(s.compensationBackoff)
+		}
+	}
+
+	s.logger.Error("Saga compensation gave up", zap.String("step", step.Name), zap.Error(err))
+	return err
+}
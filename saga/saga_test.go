@@ -0,0 +1,150 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestSaga_Execute(t *testing.T) {
+	ctx := context.Background()
+	logger := zaptest.NewLogger(t)
+
+	tests := []struct {
+		name          string
+		failAt        int
+		failComp      map[int]int // step index -> number of compensation failures
+		wantErr       bool
+		expectedErr   []string
+		wantCompOrder []int
+	}{
+		{
+			name:          "all steps succeed",
+			failAt:        -1,
+			wantErr:       false,
+			wantCompOrder: nil,
+		},
+		{
+			name:          "failure compensates completed steps in reverse",
+			failAt:        2,
+			wantErr:       true,
+			expectedErr:   []string{"saga order failed at step step-2: step error"},
+			wantCompOrder: []int{1, 0},
+		},
+		{
+			name:          "first step failure runs no compensation",
+			failAt:        0,
+			wantErr:       true,
+			expectedErr:   []string{"saga order failed at step step-0: step error"},
+			wantCompOrder: nil,
+		},
+		{
+			name:          "compensation retried until success",
+			failAt:        2,
+			failComp:      map[int]int{1: 2},
+			wantErr:       true,
+			expectedErr:   []string{"saga order failed at step step-2: step error"},
+			wantCompOrder: []int{1, 0},
+		},
+		{
+			name:          "compensation gives up after max attempts",
+			failAt:        2,
+			failComp:      map[int]int{1: 5},
+			wantErr:       true,
+			expectedErr:   []string{"saga order failed at step step-2: step error", "compensation for step step-1 failed: compensation error"},
+			wantCompOrder: []int{0},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			var compOrder []int
+			compFailures := map[int]int{}
+
+			s := New("order", logger).WithCompensationRetry(3, 0)
+			for i := 0; i < 3; i++ {
+				i := i
+				s.AddStep(
+					fmt.Sprintf("step-%d", i),
+					func(ctx context.Context) error {
+						if i == tt.failAt {
+							return errors.New("step error")
+						}
+						return nil
+					},
+					func(ctx context.Context) error {
+						if compFailures[i] < tt.failComp[i] {
+							compFailures[i]++
+							return errors.New("compensation error")
+						}
+						compOrder = append(compOrder, i)
+						return nil
+					},
+				)
+			}
+
+			err := s.Execute(ctx)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				for _, msg := range tt.expectedErr {
+					assert.Contains(t, err.Error(), msg)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantCompOrder, compOrder)
+		})
+	}
+}
+
+func TestSaga_ExecuteCancelledContextStillCompensates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	compensated := false
+	s := New("cancel", zaptest.NewLogger(t)).
+		AddStep("reserve", func(ctx context.Context) error { return nil }, func(ctx context.Context) error {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			compensated = true
+			return nil
+		}).
+		AddStep("charge", func(ctx context.Context) error {
+			cancel()
+			return ctx.Err()
+		}, nil)
+
+	err := s.Execute(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, compensated)
+}
+
+func TestSaga_ExecuteMissingAction(t *testing.T) {
+	s := New("broken", zaptest.NewLogger(t)).AddStep("noop", nil, nil)
+
+	err := s.Execute(context.Background())
+
+	assert.EqualError(t, err, "saga broken: step noop has no action")
+}
+
+func TestSaga_ExecuteMissingActionRunsNoStep(t *testing.T) {
+	ran := false
+	s := New("broken", zaptest.NewLogger(t)).
+		AddStep("reserve", func(ctx context.Context) error {
+			ran = true
+			return nil
+		}, nil).
+		AddStep("noop", nil, nil)
+
+	err := s.Execute(context.Background())
+
+	assert.EqualError(t, err, "saga broken: step noop has no action")
+	assert.False(t, ran, "no step must run when a later one has no action")
+}
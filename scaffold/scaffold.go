@@ -0,0 +1,186 @@
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime/debug"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templates embed.FS
+
+// modulePath is the module generated services depend on
+const modulePath = "github.com/jjmaturino/bootstrapper"
+
+// unversioned is the version required when the bootstrapper version is unknown, it only resolves
+// with Options.Replace
+const unversioned = "v0.0.0-00010101000000-000000000000"
+
+// Versions of the direct dependencies of generated services, used when the running binary does not
+// record them
+const (
+	ginVersion = "v1.10.0"
+	zapVersion = "v1.27.0"
+)
+
+// namePattern restricts service names to what works as a directory, binary and Go identifier part
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// Options describes the service to generate
+type Options struct {
+	// Module is the Go module path of the new service, e.g. "github.com/acme/orders"
+	Module string
+
+	// Name is the service name in lower kebab case, e.g. "orders" or "order-history"
+	Name string
+
+	// Version of bootstrapper required by the service, the version of the running binary's
+	// bootstrapper module when empty
+	Version string
+
+	// Replace is a local bootstrapper checkout replacing the required version, e.g. to try the
+	// service against unreleased changes
+	Replace string
+}
+
+// templateData is the data passed to the templates
+type templateData struct {
+	Module    string
+	Name      string
+	Type      string
+	EnvPrefix string
+
+	// Requires maps the required modules to their versions
+	Requires map[string]string
+	Replace  string
+}
+
+// Files renders the skeleton of a new HTTP service wired to the launcher, keyed by file name.
+// Go files are gofmt-ed.
+func Files(opts Options) (map[string][]byte, error) {
+	if opts.Module == "" {
+		return nil, errors.New("module path is required")
+	}
+	if !namePattern.MatchString(opts.Name) {
+		return nil, fmt.Errorf("invalid service name %q: use lower case letters, digits and dashes", opts.Name)
+	}
+
+	data := templateData{
+		Module:    opts.Module,
+		Name:      opts.Name,
+		Type:      typeName(opts.Name),
+		EnvPrefix: strings.ToUpper(strings.ReplaceAll(opts.Name, "-", "_")),
+		Requires:  requires(opts.Version),
+		Replace:   opts.Replace,
+	}
+
+	names, err := fs.Glob(templates, "templates/*.tmpl")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list templates: %w", err)
+	}
+
+	files := make(map[string][]byte, len(names))
+	for _, name := range names {
+		tmpl, err := template.ParseFS(templates, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+		}
+
+		file := strings.TrimSuffix(filepath.Base(name), ".tmpl")
+		content := buf.Bytes()
+		if strings.HasSuffix(file, ".go") {
+			if content, err = format.Source(content); err != nil {
+				return nil, fmt.Errorf("failed to format %s: %w", file, err)
+			}
+		}
+		files[file] = content
+	}
+
+	return files, nil
+}
+
+// Generate writes the skeleton of a new service into dir, creating it when needed. It refuses to
+// overwrite existing files and returns the names of the written files.
+func Generate(dir string, opts Options) ([]string, error) {
+	files, err := Files(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return nil, fmt.Errorf("refusing to overwrite existing file %s", filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(names)
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), files[name], 0o644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	return names, nil
+}
+
+// requires returns the versions of the modules generated services require, as recorded in the
+// running binary when it depends on them
+func requires(version string) map[string]string {
+	versions := map[string]string{
+		modulePath:                 unversioned,
+		"github.com/gin-gonic/gin": ginVersion,
+		"go.uber.org/zap":          zapVersion,
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if info.Main.Path == modulePath && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			versions[modulePath] = info.Main.Version
+		}
+		for _, dep := range info.Deps {
+			if _, required := versions[dep.Path]; required && dep.Version != "" && dep.Version != "(devel)" {
+				versions[dep.Path] = dep.Version
+			}
+		}
+	}
+
+	if version != "" {
+		versions[modulePath] = version
+	}
+
+	return versions
+}
+
+// typeName returns the Go type name of the service, e.g. "order-history" becomes "OrderHistoryService"
+func typeName(name string) string {
+	var b strings.Builder
+	for _, part := range strings.Split(name, "-") {
+		if part == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	b.WriteString("Service")
+
+	return b.String()
+}
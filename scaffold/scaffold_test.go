@@ -0,0 +1,106 @@
+package scaffold
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFiles(t *testing.T) {
+	files, err := Files(Options{Module: "github.com/acme/order-history", Name: "order-history"})
+	require.NoError(t, err)
+
+	for _, name := range []string{"main.go", "config.go", "routes.go", "routes_test.go", "go.mod", "Makefile"} {
+		require.Contains(t, files, name)
+	}
+
+	for name, content := range files {
+		if strings.HasSuffix(name, ".go") {
+			_, err := parser.ParseFile(token.NewFileSet(), name, content, parser.AllErrors)
+			assert.NoError(t, err, name)
+		}
+	}
+
+	assert.Contains(t, string(files["go.mod"]), "module github.com/acme/order-history")
+	assert.Contains(t, string(files["go.mod"]), "\ngo 1.22\n")
+	assert.Contains(t, string(files["go.mod"]), "\tgithub.com/gin-gonic/gin v1.")
+	assert.NotContains(t, string(files["go.mod"]), "replace")
+	assert.Contains(t, string(files["routes.go"]), "type OrderHistoryService struct")
+	assert.Contains(t, string(files["config.go"]), `os.Getenv("ORDER_HISTORY_ADDR")`)
+}
+
+func TestFiles_Version(t *testing.T) {
+	files, err := Files(Options{Module: "github.com/acme/orders", Name: "orders", Version: "v1.2.3", Replace: "../bootstrapper"})
+	require.NoError(t, err)
+
+	assert.Contains(t, string(files["go.mod"]), "\tgithub.com/jjmaturino/bootstrapper v1.2.3\n")
+	assert.Contains(t, string(files["go.mod"]), `replace github.com/jjmaturino/bootstrapper => "../bootstrapper"`)
+}
+
+func TestFiles_InvalidOptions(t *testing.T) {
+	_, err := Files(Options{Name: "orders"})
+	assert.EqualError(t, err, "module path is required")
+
+	_, err = Files(Options{Module: "github.com/acme/orders", Name: "Orders"})
+	assert.EqualError(t, err, `invalid service name "Orders": use lower case letters, digits and dashes`)
+}
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "orders")
+	opts := Options{Module: "github.com/acme/orders", Name: "orders"}
+
+	written, err := Generate(dir, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Makefile", "config.go", "go.mod", "main.go", "routes.go", "routes_test.go"}, written)
+
+	content, err := os.ReadFile(filepath.Join(dir, "routes.go"))
+	require.NoError(t, err)
+	assert.Contains(t, string(content), "type OrdersService struct")
+
+	// Existing files are never overwritten
+	_, err = Generate(dir, opts)
+	assert.ErrorContains(t, err, "refusing to overwrite existing file")
+}
+
+func TestGenerate_Builds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("building the generated service runs the go command")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+
+	// The generated service is built against this checkout, offline so the test neither hangs on
+	// the network nor depends on it: its dependencies must be in the module cache
+	root, err := filepath.Abs("..")
+	require.NoError(t, err)
+	dir := filepath.Join(t.TempDir(), "order-history")
+	_, err = Generate(dir, Options{Module: "github.com/acme/order-history", Name: "order-history", Replace: root})
+	require.NoError(t, err)
+
+	// The checksums of this checkout cover the dependencies, sparing the checksum database
+	sums, err := os.ReadFile(filepath.Join(root, "go.sum"))
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.sum"), sums, 0o644))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, goBin, "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOWORK=off", "GOPROXY=off")
+	output, err := cmd.CombinedOutput()
+	if err != nil && strings.Contains(string(output), "module lookup disabled by GOPROXY=off") {
+		t.Skipf("dependencies of the generated service are not in the module cache:\n%s", output)
+	}
+	assert.NoError(t, err, string(output))
+}
@@ -0,0 +1,333 @@
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Defaults applied to a zero Config
+const (
+	DefaultMaxBodyBytes = 64 << 10
+	DefaultTimeout      = 5 * time.Second
+	DefaultQueueSize    = 100
+	DefaultWorkers      = 4
+)
+
+// Redacted replaces the value of scrubbed headers, fields and query parameters
+const Redacted = "[REDACTED]"
+
+// ShadowHeader is set on mirrored requests so the shadow target can tell them apart
+const ShadowHeader = "X-Shadow-Request"
+
+// DefaultScrubHeaders are removed from mirrored requests unless ScrubHeaders is set
+var DefaultScrubHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+// Config configures request mirroring
+type Config struct {
+	// TargetURL is the base URL of the shadow service, the request path and query are appended
+	TargetURL string `json:"targetUrl" yaml:"targetUrl"`
+
+	// Percent is the share of requests mirrored, from 0 to 100
+	Percent float64 `json:"percent" yaml:"percent"`
+
+	// MaxBodyBytes caps the buffered request body, requests with larger bodies are not mirrored
+	MaxBodyBytes int64 `json:"maxBodyBytes" yaml:"maxBodyBytes"`
+
+	// ScrubHeaders are redacted on mirrored requests, it defaults to DefaultScrubHeaders
+	ScrubHeaders []string `json:"scrubHeaders" yaml:"scrubHeaders"`
+
+	// ScrubFields are redacted in mirrored requests: JSON object keys at any depth, form fields of
+	// URL-encoded bodies and query parameters. When set, requests with other bodies or invalid JSON
+	// are not mirrored.
+	ScrubFields []string `json:"scrubFields" yaml:"scrubFields"`
+
+	// Timeout bounds each mirrored request
+	Timeout time.Duration `json:"timeout" yaml:"timeout"`
+
+	// QueueSize bounds the mirrored requests waiting to be sent, requests are dropped when full
+	QueueSize int `json:"queueSize" yaml:"queueSize"`
+
+	// Workers is the number of goroutines sending mirrored requests
+	Workers int `json:"workers" yaml:"workers"`
+
+	// Client sends the mirrored requests, it defaults to http.DefaultClient
+	Client *http.Client `json:"-" yaml:"-"`
+}
+
+// Mirror asynchronously copies a sample of requests to a shadow target, the primary response
+// never waits on or depends on the shadow
+type Mirror struct {
+	cfg          Config
+	target       *url.URL
+	scrubHeaders []string
+	scrubFields  map[string]struct{}
+	logger       *zap.Logger
+
+	queue     chan *http.Request
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	// mu guards closed so no request is queued once the queue is closed
+	mu     sync.RWMutex
+	closed bool
+
+	// roll returns a number in [0, 100), it is replaced in tests
+	roll func() float64
+}
+
+// New creates a mirror and starts its workers, Close stops them
+func New(cfg Config, logger *zap.Logger) (*Mirror, error) {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	target, err := url.Parse(cfg.TargetURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid shadow target url: %w", err)
+	}
+	if target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid shadow target url: %q must be absolute", cfg.TargetURL)
+	}
+
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = DefaultQueueSize
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = DefaultWorkers
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+
+	scrubHeaders := cfg.ScrubHeaders
+	if scrubHeaders == nil {
+		scrubHeaders = DefaultScrubHeaders
+	}
+
+	scrubFields := make(map[string]struct{}, len(cfg.ScrubFields))
+	for _, field := range cfg.ScrubFields {
+		scrubFields[field] = struct{}{}
+	}
+
+	m := &Mirror{
+		cfg:          cfg,
+		target:       target,
+		scrubHeaders: scrubHeaders,
+		scrubFields:  scrubFields,
+		logger:       logger,
+		queue:        make(chan *http.Request, cfg.QueueSize),
+		roll:         func() float64 { return rand.Float64() * 100 },
+	}
+
+	for i := 0; i < cfg.Workers; i++ {
+		m.wg.Add(1)
+		go m.work()
+	}
+
+	return m, nil
+}
+
+// Middleware returns middleware mirroring a sample of requests to the shadow target
+func (m *Mirror) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if m.roll() < m.cfg.Percent {
+			m.capture(c.Request)
+		}
+		c.Next()
+	}
+}
+
+// Close stops accepting requests and waits for the queued ones to be sent
+func (m *Mirror) Close() {
+	m.closeOnce.Do(func() {
+		m.mu.Lock()
+		m.closed = true
+		close(m.queue)
+		m.mu.Unlock()
+	})
+	m.wg.Wait()
+}
+
+// capture buffers the request body, restoring it for the primary handler, and queues a scrubbed
+// copy of the request
+func (m *Mirror) capture(r *http.Request) {
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		buffered, err := io.ReadAll(io.LimitReader(r.Body, m.cfg.MaxBodyBytes+1))
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buffered), r.Body), Closer: r.Body}
+		if err != nil {
+			m.logger.Debug("Failed to buffer request body, not mirroring", zap.Error(err))
+			return
+		}
+		if int64(len(buffered)) > m.cfg.MaxBodyBytes {
+			m.logger.Debug("Request body exceeds shadow cap, not mirroring", zap.String("path", r.URL.Path))
+			return
+		}
+		var ok bool
+		if body, ok = m.scrubBody(r.Header.Get("Content-Type"), buffered); !ok {
+			m.logger.Debug("Request body cannot be scrubbed, not mirroring", zap.String("path", r.URL.Path))
+			return
+		}
+	}
+
+	target := *m.target
+	target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	target.RawQuery = m.scrubQuery(r.URL.RawQuery)
+
+	shadowReq, err := http.NewRequest(r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		m.logger.Debug("Failed to create shadow request", zap.Error(err))
+		return
+	}
+
+	shadowReq.Header = r.Header.Clone()
+	for _, header := range m.scrubHeaders {
+		if shadowReq.Header.Get(header) != "" {
+			shadowReq.Header.Set(header, Redacted)
+		}
+	}
+	shadowReq.Header.Set(ShadowHeader, "true")
+	shadowReq.Header.Del("Content-Length")
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.closed {
+		return
+	}
+
+	select {
+	case m.queue <- shadowReq:
+	default:
+		m.logger.Warn("Shadow queue full, dropping mirrored request", zap.String("path", r.URL.Path))
+	}
+}
+
+// work sends queued requests until the queue is closed
+func (m *Mirror) work() {
+	defer m.wg.Done()
+
+	for req := range m.queue {
+		m.send(req)
+	}
+}
+
+// send sends a mirrored request and discards the response
+func (m *Mirror) send(req *http.Request) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.cfg.Timeout)
+	defer cancel()
+
+	resp, err := m.cfg.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			m.logger.Debug("Shadow request failed", zap.String("url", req.URL.String()), zap.Error(err))
+		}
+		return
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	m.logger.Debug("Shadow request sent", zap.String("url", req.URL.String()), zap.Int("status", resp.StatusCode))
+}
+
+// scrubBody redacts the configured fields in JSON and URL-encoded form bodies. When fields are
+// configured, other bodies and JSON that fails to parse cannot be scrubbed and are not mirrored,
+// false is returned.
+func (m *Mirror) scrubBody(contentType string, body []byte) ([]byte, bool) {
+	if len(m.scrubFields) == 0 || len(body) == 0 {
+		return body, true
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		return []byte(m.scrubQuery(string(body))), true
+	}
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		return nil, false
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, false
+	}
+
+	scrubbed, err := json.Marshal(m.scrubValue(doc))
+	if err != nil {
+		return nil, false
+	}
+
+	return scrubbed, true
+}
+
+// scrubQuery redacts the configured fields in a URL-encoded query or form. Queries without them
+// are kept as is, others are re-encoded without the pairs that fail to parse, as they could hide
+// a field.
+func (m *Mirror) scrubQuery(query string) string {
+	if len(m.scrubFields) == 0 || query == "" {
+		return query
+	}
+
+	values, err := url.ParseQuery(query)
+	scrubbed := false
+	for key := range values {
+		if _, ok := m.scrubFields[key]; ok {
+			values[key] = []string{Redacted}
+			scrubbed = true
+		}
+	}
+	if !scrubbed && err == nil {
+		return query
+	}
+
+	return values.Encode()
+}
+
+// scrubValue redacts the configured keys in a decoded JSON value
+func (m *Mirror) scrubValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, field := range value {
+			if _, ok := m.scrubFields[key]; ok {
+				value[key] = Redacted
+				continue
+			}
+			value[key] = m.scrubValue(field)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = m.scrubValue(item)
+		}
+	}
+
+	return v
+}
+
+// readCloser restores a partially read body while closing the original one
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,175 @@
+package shadow
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type capturedRequest struct {
+	method string
+	path   string
+	header http.Header
+	body   string
+}
+
+func newShadowTarget(t *testing.T) (*httptest.Server, chan capturedRequest) {
+	captured := make(chan capturedRequest, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		captured <- capturedRequest{method: r.Method, path: r.URL.RequestURI(), header: r.Header, body: string(body)}
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	t.Cleanup(server.Close)
+	return server, captured
+}
+
+func serve(t *testing.T, mirror *Mirror, req *http.Request) (*httptest.ResponseRecorder, string) {
+	gin.SetMode(gin.TestMode)
+
+	var primaryBody string
+	engine := gin.New()
+	engine.Use(mirror.Middleware())
+	engine.POST("/orders", func(c *gin.Context) {
+		body, err := io.ReadAll(c.Request.Body)
+		require.NoError(t, err)
+		primaryBody = string(body)
+		c.Status(http.StatusCreated)
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec, primaryBody
+}
+
+func TestMirror_Middleware(t *testing.T) {
+	target, captured := newShadowTarget(t)
+
+	mirror, err := New(Config{
+		TargetURL:   target.URL + "/shadow",
+		Percent:     100,
+		ScrubFields: []string{"cardNumber"},
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	body := `{"item":"book","payment":{"cardNumber":"4111111111111111"}}`
+	req := httptest.NewRequest(http.MethodPost, "/orders?trace=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	rec, primaryBody := serve(t, mirror, req)
+	mirror.Close()
+
+	// The primary handler sees the original request and response
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, body, primaryBody)
+
+	select {
+	case got := <-captured:
+		assert.Equal(t, http.MethodPost, got.method)
+		assert.Equal(t, "/shadow/orders?trace=1", got.path)
+		assert.Equal(t, Redacted, got.header.Get("Authorization"))
+		assert.Equal(t, "true", got.header.Get(ShadowHeader))
+		assert.JSONEq(t, `{"item":"book","payment":{"cardNumber":"[REDACTED]"}}`, got.body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestMirror_ScrubsFormAndQuery(t *testing.T) {
+	target, captured := newShadowTarget(t)
+
+	mirror, err := New(Config{
+		TargetURL:   target.URL,
+		Percent:     100,
+		ScrubFields: []string{"cardNumber", "token"},
+	}, zaptest.NewLogger(t))
+	require.NoError(t, err)
+
+	body := "item=book&cardNumber=4111111111111111"
+	req := httptest.NewRequest(http.MethodPost, "/orders?token=secret&trace=1", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	rec, primaryBody := serve(t, mirror, req)
+	mirror.Close()
+
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, body, primaryBody)
+
+	select {
+	case got := <-captured:
+		assert.Equal(t, "/orders?token=%5BREDACTED%5D&trace=1", got.path)
+		assert.Equal(t, "cardNumber=%5BREDACTED%5D&item=book", got.body)
+	case <-time.After(5 * time.Second):
+		t.Fatal("request was not mirrored")
+	}
+}
+
+func TestMirror_ScrubQuery(t *testing.T) {
+	mirror := &Mirror{scrubFields: map[string]struct{}{"token": {}}}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "", want: ""},
+		{query: "b=2&a=1", want: "b=2&a=1"},
+		{query: "token=secret", want: "token=%5BREDACTED%5D"},
+		{query: "token=a&token=b", want: "token=%5BREDACTED%5D"},
+		{query: "a=%zz&token=secret", want: "token=%5BREDACTED%5D"},
+		{query: "a=%zz&b=1", want: "b=1"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, mirror.scrubQuery(tt.query), tt.query)
+	}
+}
+
+func TestMirror_NotMirrored(t *testing.T) {
+	tests := []struct {
+		name        string
+		percent     float64
+		roll        float64
+		body        string
+		contentType string
+		scrubFields []string
+	}{
+		{name: "outside sample", percent: 10, roll: 50, body: `{}`},
+		{name: "body over cap", percent: 100, body: strings.Repeat("x", 32)},
+		{name: "invalid json with scrub fields", percent: 100, body: `{"token":`, contentType: "application/json", scrubFields: []string{"token"}},
+		{name: "unknown type with scrub fields", percent: 100, body: "token=secret", contentType: "text/plain", scrubFields: []string{"token"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			target, captured := newShadowTarget(t)
+
+			mirror, err := New(Config{TargetURL: target.URL, Percent: tt.percent, MaxBodyBytes: 16, ScrubFields: tt.scrubFields}, zaptest.NewLogger(t))
+			require.NoError(t, err)
+			mirror.roll = func() float64 { return tt.roll }
+
+			req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec, primaryBody := serve(t, mirror, req)
+			mirror.Close()
+
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, tt.body, primaryBody)
+			assert.Empty(t, captured)
+		})
+	}
+}
+
+func TestNew_InvalidTarget(t *testing.T) {
+	_, err := New(Config{TargetURL: "/relative"}, zaptest.NewLogger(t))
+	assert.EqualError(t, err, `invalid shadow target url: "/relative" must be absolute`)
+}
@@ -0,0 +1,37 @@
+package signedurl
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/jjmaturino/bootstrapper/problem"
+)
+
+// Problem types returned by the signed URL middleware
+const (
+	// InvalidSignedURLProblemType is returned when the URL is not signed or its signature is invalid
+	InvalidSignedURLProblemType = "urn:bootstrapper:problem:invalid-signed-url"
+
+	// ExpiredSignedURLProblemType is returned when the signed URL has expired
+	ExpiredSignedURLProblemType = "urn:bootstrapper:problem:expired-signed-url"
+)
+
+// ErrorProblem returns the problem for a verification error. Invalid URLs are not found rather
+// than forbidden, so they do not reveal which resources exist.
+func ErrorProblem(err error) problem.Problem {
+	if errors.Is(err, ErrExpired) {
+		return problem.Problem{
+			Type:   ExpiredSignedURLProblemType,
+			Title:  "Signed URL expired",
+			Status: http.StatusGone,
+			Detail: "the link has expired, request a new one",
+		}
+	}
+
+	return problem.Problem{
+		Type:   InvalidSignedURLProblemType,
+		Title:  "Invalid signed URL",
+		Status: http.StatusNotFound,
+		Detail: err.Error(),
+	}
+}
@@ -0,0 +1,194 @@
+// Package signedurl mints and verifies time-limited signed URLs, for protected downloads and
+// webhook callbacks that cannot carry credentials. The signature is an HMAC-SHA256 over the path,
+// the expiry, the key ID and the claims carried in the query:
+//
+//	signer := signedurl.New("2024-06", key)
+//	link, err := signer.Sign("/downloads/report.pdf", 15*time.Minute, url.Values{"user": {"42"}})
+//
+//	engine.GET("/downloads/:name", signer.Middleware(), serveDownload)
+package signedurl
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+)
+
+// Query parameters added to signed URLs
+const (
+	ExpiresParam   = "expires"
+	KeyIDParam     = "kid"
+	SignatureParam = "signature"
+)
+
+// claimsKey is the gin context key of the verified claims
+const claimsKey = "signedurl.claims"
+
+// Verification errors
+var (
+	ErrMissingSignature = errors.New("missing url signature")
+	ErrInvalidSignature = errors.New("invalid url signature")
+	ErrUnknownKey       = errors.New("unknown url signing key")
+	ErrExpired          = errors.New("signed url expired")
+)
+
+// Signer signs URLs with its current key and verifies them with any of its keys, so keys can be
+// rotated without invalidating the URLs already handed out
+type Signer struct {
+	// mu protects keys
+	mu      sync.RWMutex
+	keys    map[string][]byte
+	current string
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// New creates a signer signing with the key, identified by keyID in the signed URLs
+func New(keyID string, key []byte) *Signer {
+	return &Signer{
+		keys:    map[string][]byte{keyID: key},
+		current: keyID,
+		now:     time.Now,
+	}
+}
+
+// Rotate makes the key the signing key, previous keys still verify until removed with Retire
+func (s *Signer) Rotate(keyID string, key []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[keyID] = key
+	s.current = keyID
+}
+
+// Retire removes a previous key, URLs signed with it no longer verify. The signing key cannot be
+// retired.
+func (s *Signer) Retire(keyID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if keyID != s.current {
+		delete(s.keys, keyID)
+	}
+}
+
+// Sign returns the URL with the claims and a signature valid for ttl added to its query. The URL
+// may be absolute or a path, its existing query parameters are signed as claims.
+func (s *Signer) Sign(rawURL string, ttl time.Duration, claims url.Values) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid url: %w", err)
+	}
+
+	query := u.Query()
+	for _, param := range []string{ExpiresParam, KeyIDParam, SignatureParam} {
+		if query.Has(param) || claims.Has(param) {
+			return "", fmt.Errorf("url already has the reserved %q parameter", param)
+		}
+	}
+	for name, values := range claims {
+		query[name] = append(query[name], values...)
+	}
+
+	s.mu.RLock()
+	keyID, key := s.current, s.keys[s.current]
+	s.mu.RUnlock()
+
+	query.Set(ExpiresParam, strconv.FormatInt(s.now().Add(ttl).Unix(), 10))
+	query.Set(KeyIDParam, keyID)
+	query.Set(SignatureParam, sign(key, u.EscapedPath(), query))
+	u.RawQuery = query.Encode()
+
+	return u.String(), nil
+}
+
+// Verify checks the signature and expiry of a signed URL and returns its claims, the query
+// without the signing parameters
+func (s *Signer) Verify(u *url.URL) (url.Values, error) {
+	query := u.Query()
+
+	signature := query.Get(SignatureParam)
+	if signature == "" {
+		return nil, ErrMissingSignature
+	}
+
+	s.mu.RLock()
+	key, ok := s.keys[query.Get(KeyIDParam)]
+	s.mu.RUnlock()
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	expected := sign(key, u.EscapedPath(), query)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrInvalidSignature
+	}
+
+	// The expiry is signed, only check it once the signature is known to be valid
+	expires, err := strconv.ParseInt(query.Get(ExpiresParam), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !s.now().Before(time.Unix(expires, 0)) {
+		return nil, ErrExpired
+	}
+
+	claims := url.Values{}
+	for name, values := range query {
+		switch name {
+		case ExpiresParam, KeyIDParam, SignatureParam:
+		default:
+			claims[name] = values
+		}
+	}
+
+	return claims, nil
+}
+
+// Middleware returns middleware rejecting requests whose URL is not validly signed, handlers read
+// the verified claims with Claims
+func (s *Signer) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims, err := s.Verify(c.Request.URL)
+		if err != nil {
+			problem.Abort(c, ErrorProblem(err))
+			return
+		}
+
+		c.Set(claimsKey, claims)
+		c.Next()
+	}
+}
+
+// Claims returns the claims of the signed URL verified by the middleware
+func Claims(c *gin.Context) url.Values {
+	claims, _ := c.Get(claimsKey)
+	values, _ := claims.(url.Values)
+	return values
+}
+
+// sign returns the signature of the path and the query without its signature. The query is
+// encoded with its keys sorted, so the order of the parameters does not matter.
+func sign(key []byte, path string, query url.Values) string {
+	unsigned := url.Values{}
+	for name, values := range query {
+		if name != SignatureParam {
+			unsigned[name] = values
+		}
+	}
+
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(path))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(unsigned.Encode()))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
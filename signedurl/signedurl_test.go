@@ -0,0 +1,158 @@
+package signedurl
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newSigner returns a signer whose clock is at the returned pointer
+func newSigner(keyID string, key []byte) (*Signer, *time.Time) {
+	s := New(keyID, key)
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func mustParse(t *testing.T, raw string) *url.URL {
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
+
+func TestSigner_SignAndVerify(t *testing.T) {
+	s, _ := newSigner("k1", []byte("secret"))
+
+	link, err := s.Sign("https://files.example.com/downloads/report.pdf?disposition=inline", time.Minute, url.Values{"user": {"42"}})
+	require.NoError(t, err)
+
+	u := mustParse(t, link)
+	assert.Equal(t, "k1", u.Query().Get(KeyIDParam))
+	assert.Equal(t, "1700000060", u.Query().Get(ExpiresParam))
+
+	claims, err := s.Verify(u)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{"user": {"42"}, "disposition": {"inline"}}, claims)
+}
+
+func TestSigner_VerifyErrors(t *testing.T) {
+	s, now := newSigner("k1", []byte("secret"))
+	link, err := s.Sign("/downloads/report.pdf", time.Minute, url.Values{"user": {"42"}})
+	require.NoError(t, err)
+
+	tamper := func(fn func(u *url.URL, q url.Values)) *url.URL {
+		u := mustParse(t, link)
+		q := u.Query()
+		fn(u, q)
+		u.RawQuery = q.Encode()
+		return u
+	}
+
+	tests := []struct {
+		name string
+		url  *url.URL
+		err  error
+	}{
+		{name: "unsigned", url: mustParse(t, "/downloads/report.pdf"), err: ErrMissingSignature},
+		{name: "other claim", url: tamper(func(u *url.URL, q url.Values) { q.Set("user", "43") }), err: ErrInvalidSignature},
+		{name: "added claim", url: tamper(func(u *url.URL, q url.Values) { q.Set("admin", "true") }), err: ErrInvalidSignature},
+		{name: "extended expiry", url: tamper(func(u *url.URL, q url.Values) { q.Set(ExpiresParam, "1800000000") }), err: ErrInvalidSignature},
+		{name: "other path", url: tamper(func(u *url.URL, q url.Values) { u.Path = "/downloads/salaries.pdf" }), err: ErrInvalidSignature},
+		{name: "unknown key", url: tamper(func(u *url.URL, q url.Values) { q.Set(KeyIDParam, "k0") }), err: ErrUnknownKey},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.Verify(tt.url)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	*now = now.Add(time.Minute)
+	_, err = s.Verify(mustParse(t, link))
+	assert.ErrorIs(t, err, ErrExpired)
+}
+
+func TestSigner_ReservedParams(t *testing.T) {
+	s, _ := newSigner("k1", []byte("secret"))
+
+	_, err := s.Sign("/downloads/report.pdf?signature=x", time.Minute, nil)
+	assert.Error(t, err)
+
+	_, err = s.Sign("/downloads/report.pdf", time.Minute, url.Values{ExpiresParam: {"0"}})
+	assert.Error(t, err)
+}
+
+func TestSigner_Rotate(t *testing.T) {
+	s, _ := newSigner("k1", []byte("old"))
+	old, err := s.Sign("/downloads/report.pdf", time.Minute, nil)
+	require.NoError(t, err)
+
+	s.Rotate("k2", []byte("new"))
+	current, err := s.Sign("/downloads/report.pdf", time.Minute, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "k2", mustParse(t, current).Query().Get(KeyIDParam))
+
+	_, err = s.Verify(mustParse(t, old))
+	assert.NoError(t, err)
+
+	s.Retire("k1")
+	_, err = s.Verify(mustParse(t, old))
+	assert.ErrorIs(t, err, ErrUnknownKey)
+
+	// The signing key stays
+	s.Retire("k2")
+	_, err = s.Verify(mustParse(t, current))
+	assert.NoError(t, err)
+}
+
+func TestSigner_Middleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	s, now := newSigner("k1", []byte("secret"))
+	engine := gin.New()
+	engine.GET("/downloads/:name", s.Middleware(), func(c *gin.Context) {
+		c.String(http.StatusOK, "%s for %s", c.Param("name"), Claims(c).Get("user"))
+	})
+
+	link, err := s.Sign("/downloads/report.pdf", time.Minute, url.Values{"user": {"42"}})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		target string
+		after  time.Duration
+		status int
+		body   string
+	}{
+		{name: "valid", target: link, status: http.StatusOK, body: "report.pdf for 42"},
+		{name: "unsigned", target: "/downloads/report.pdf", status: http.StatusNotFound, body: InvalidSignedURLProblemType},
+		{name: "expired", target: link, after: time.Hour, status: http.StatusGone, body: ExpiredSignedURLProblemType},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			start := *now
+			*now = start.Add(tt.after)
+			defer func() { *now = start }()
+
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), tt.body)
+			if tt.status != http.StatusOK {
+				assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
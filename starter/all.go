@@ -0,0 +1,76 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap"
+)
+
+// ServiceSpec describes a service started by StartAll
+type ServiceSpec struct {
+	// Name identifies the service in logs and errors, its type when empty
+	Name string
+
+	// Service to start
+	Service platform.Service
+
+	// Platform to start the service on
+	Platform platform.Type
+
+	// Deps are passed to the service in addition to the launcher's defaults
+	Deps []interface{}
+}
+
+// name returns the name of the service in logs and errors
+func (s ServiceSpec) name() string {
+	if s.Name != "" {
+		return s.Name
+	}
+	return string(s.Service.Type())
+}
+
+// StartAll runs the services concurrently in one process, like an HTTP API next to a queue
+// consumer. When one of them returns, failing or not, the others are stopped through their
+// context, and StartAll returns once all have stopped with the failures of every service joined.
+func (l *ServiceLauncher) StartAll(ctx context.Context, specs []ServiceSpec) error {
+	if len(specs) == 0 {
+		return errors.New("no services to start")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// A single watcher reloads the components once, the starters reload the config of each service
+	stopReload := l.watchReloadSignals(ctx)
+	defer stopReload()
+
+	errs := make([]error, len(specs))
+	var wg sync.WaitGroup
+	for i, spec := range specs {
+		wg.Add(1)
+		go func(i int, spec ServiceSpec) {
+			defer wg.Done()
+			// Stop the other services once this one returns
+			defer cancel()
+
+			err := l.start(ctx, spec.Service, spec.Platform, spec.Deps)
+			if err != nil {
+				l.logger.Error("Service failed, stopping the other services",
+					zap.String("service", spec.name()),
+					zap.Error(err))
+				errs[i] = fmt.Errorf("%s: %w", spec.name(), err)
+				return
+			}
+			if ctx.Err() == nil {
+				l.logger.Info("Service stopped, stopping the other services", zap.String("service", spec.name()))
+			}
+		}(i, spec)
+	}
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,108 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+)
+
+const (
+	apiPlatform    platform.Type = "api"
+	workerPlatform platform.Type = "worker"
+)
+
+// untilCancelled runs until the context is cancelled
+func untilCancelled(ctx context.Context, service platform.Service, deps ...interface{}) error {
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-time.After(5 * time.Second):
+		return errors.New("service was not stopped")
+	}
+}
+
+func TestServiceLauncher_StartAllStopsOthersOnFailure(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	launcher.RegisterPlatform(ctx, apiPlatform, &mockServiceStarter{startServiceFunc: untilCancelled})
+	launcher.RegisterPlatform(ctx, workerPlatform, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			return errors.New("queue unreachable")
+		},
+	})
+
+	err := launcher.StartAll(ctx, []ServiceSpec{
+		{Name: "api", Service: &mockService{}, Platform: apiPlatform},
+		{Name: "consumer", Service: &mockService{}, Platform: workerPlatform},
+	})
+
+	expectedErrMsg := "consumer: queue unreachable"
+	if err == nil || err.Error() != expectedErrMsg {
+		t.Errorf("Expected error '%s', but got: %v", expectedErrMsg, err)
+	}
+}
+
+func TestServiceLauncher_StartAllJoinsFailures(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	launcher.RegisterPlatform(ctx, apiPlatform, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			<-ctx.Done()
+			return errors.New("drain timed out")
+		},
+	})
+	launcher.RegisterPlatform(ctx, workerPlatform, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			return errors.New("queue unreachable")
+		},
+	})
+
+	// Unnamed services are named after their type
+	err := launcher.StartAll(ctx, []ServiceSpec{
+		{Service: &mockService{}, Platform: apiPlatform},
+		{Name: "consumer", Service: &mockService{}, Platform: workerPlatform},
+	})
+
+	expectedErrMsg := "mock-service: drain timed out\nconsumer: queue unreachable"
+	if err == nil || err.Error() != expectedErrMsg {
+		t.Errorf("Expected error '%s', but got: %v", expectedErrMsg, err)
+	}
+}
+
+func TestServiceLauncher_StartAllStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	launcher.RegisterPlatform(ctx, apiPlatform, &mockServiceStarter{startServiceFunc: untilCancelled})
+	launcher.RegisterPlatform(ctx, workerPlatform, &mockServiceStarter{startServiceFunc: untilCancelled})
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	err := launcher.StartAll(ctx, []ServiceSpec{
+		{Name: "api", Service: &mockService{}, Platform: apiPlatform},
+		{Name: "consumer", Service: &mockService{}, Platform: workerPlatform},
+	})
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+}
+
+func TestServiceLauncher_StartAllInvalid(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	if err := launcher.StartAll(ctx, nil); err == nil {
+		t.Error("Expected an error without services")
+	}
+
+	err := launcher.StartAll(ctx, []ServiceSpec{{Name: "api", Service: &mockService{}, Platform: "unknown"}})
+	if err == nil || !strings.Contains(err.Error(), "api: unsupported platform type: unknown") {
+		t.Errorf("Expected an unsupported platform error, but got: %v", err)
+	}
+}
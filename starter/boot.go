@@ -3,9 +3,15 @@ package starter
 import (
 	"context"
 	"fmt"
+	"github.com/jjmaturino/bootstrapper/config"
+	"github.com/jjmaturino/bootstrapper/gdpr"
+	"github.com/jjmaturino/bootstrapper/health"
 	"github.com/jjmaturino/bootstrapper/platform"
 	"go.uber.org/zap"
+	"os"
+	"reflect"
 	"sync"
+	"time"
 )
 
 // ServiceLauncher manages the registration and launching of services on different platforms
@@ -16,6 +22,34 @@ type ServiceLauncher struct {
 	// registryMu protects the registry
 	registryMu sync.RWMutex
 
+	// reloadables are reloaded on SIGHUP while a service runs
+	reloadables []reloadable
+
+	// reloadMu protects the reloadables
+	reloadMu sync.RWMutex
+
+	// configLoader reloads config, delivered to services implementing platform.ReloadableService
+	configLoader   *config.Loader
+	config         interface{}
+	configInterval time.Duration
+	configModTime  time.Time
+
+	// configMu protects the watched config
+	configMu sync.RWMutex
+
+	// resolver provides dependencies from a container when set
+	resolver platform.DependencyResolver
+
+	// initTimeout bounds dependency initialization, DefaultInitTimeout when zero
+	initTimeout time.Duration
+
+	// maxProcs overrides GOMAXPROCS, applied once by maxProcsOnce
+	maxProcs     int
+	maxProcsOnce sync.Once
+
+	// restartPolicy supervises the started services
+	restartPolicy RestartPolicy
+
 	// logger for the launcher
 	logger *zap.Logger
 }
@@ -29,6 +63,10 @@ func NewServiceLauncher(ctx context.Context, logger *zap.Logger) *ServiceLaunche
 
 	// Register builtin platform starters
 	launcher.RegisterPlatform(ctx, platform.VM, platform.NewVMServiceStarter(logger))
+	launcher.RegisterPlatform(ctx, platform.CloudFunction, platform.NewCloudFunctionStarter(logger))
+	launcher.RegisterPlatform(ctx, platform.Fly, platform.NewFlyServiceStarter(logger))
+	launcher.RegisterPlatform(ctx, platform.ECS, platform.NewECSServiceStarter(logger))
+	launcher.registerOSPlatforms(ctx)
 	// Other platforms would be registered here
 
 	return launcher
@@ -40,6 +78,20 @@ func (l *ServiceLauncher) Start(
 	service platform.Service,
 	platformType platform.Type,
 	deps ...interface{},
+) error {
+	// Reload the registered components on SIGHUP for as long as the service runs
+	stopReload := l.watchReloadSignals(ctx)
+	defer stopReload()
+
+	return l.start(ctx, service, platformType, deps)
+}
+
+// start launches a service on the specified platform without watching for reloads
+func (l *ServiceLauncher) start(
+	ctx context.Context,
+	service platform.Service,
+	platformType platform.Type,
+	deps []interface{},
 ) error {
 	// Get the appropriate service starter for the platform
 	l.registryMu.RLock()
@@ -55,7 +107,138 @@ func (l *ServiceLauncher) Start(
 		zap.String("platform", string(platformType)),
 		zap.String("serviceType", string(service.Type())))
 
-	return starter.Start(ctx, service, deps...)
+	// Size GOMAXPROCS to the CPU quota before anything starts scheduling work
+	l.applyMaxProcs()
+
+	// Record the boot sequence, starters log it once the service is ready, otherwise it is logged
+	// when Start returns
+	timeline := platform.NewTimeline(l.logger)
+	defer timeline.Finish()
+	ctx = platform.WithTimeline(ctx, timeline)
+
+	// Add the dependencies provided by the resolver, released once the service stops
+	if l.resolver != nil {
+		endResolve := timeline.Span("resolve dependencies")
+		resolved, release, err := l.resolver.Resolve(ctx, service)
+		endResolve(err)
+		if err != nil {
+			l.logger.Error("Failed to resolve service dependencies", zap.Error(err))
+			return fmt.Errorf("failed to resolve dependencies: %w", err)
+		}
+		defer func() {
+			if err := release(context.WithoutCancel(ctx)); err != nil {
+				l.logger.Error("Failed to release service dependencies", zap.Error(err))
+			}
+		}()
+		deps = append(deps, resolved...)
+	}
+
+	deps = l.defaultDeps(service, platformType, deps)
+
+	// The starter's signal handling goroutine reloads the watched config
+	if reloader := l.configReloader(service); reloader != nil {
+		ctx = platform.WithConfigReloader(ctx, reloader)
+	}
+
+	// Fail fast when the service declares dependencies that were not provided
+	endCheck := timeline.Span("check dependencies")
+	missingOptional, err := platform.CheckDependencies(service, deps...)
+	endCheck(err)
+	if err != nil {
+		l.logger.Error("Missing service dependencies", zap.Error(err))
+		return err
+	}
+	for _, key := range missingOptional {
+		l.logger.Info("Optional dependency not provided", zap.String("dependency", key.Name))
+	}
+
+	// Initialize independent dependencies in parallel before the service uses them
+	if err := l.initDeps(ctx, deps); err != nil {
+		return err
+	}
+
+	// Restarted services get fresh engines, the previous run registered its routes on the others
+	renewDeps := func() []interface{} { return deps }
+	if l.restartPolicy.Mode != "" && l.restartPolicy.Mode != RestartNever {
+		renewDeps, err = platform.RestartDeps(deps...)
+		if err != nil {
+			l.logger.Error("Service cannot be restarted", zap.Error(err))
+			return fmt.Errorf("restart policy %s not supported: %w", l.restartPolicy.Mode, err)
+		}
+	}
+
+	// A service stopped by a shutdown signal cancels the context supervise checks, so it is not
+	// restarted
+	ctx, shutdown := context.WithCancel(ctx)
+	defer shutdown()
+	ctx = platform.WithShutdown(ctx, shutdown)
+
+	// Recover panics and restart the service according to the restart policy
+	attemptDeps := deps
+	return l.supervise(ctx, string(service.Type()), func() error {
+		defer func() { attemptDeps = renewDeps() }()
+		return starter.Start(ctx, service, attemptDeps...)
+	})
+}
+
+// UseResolver sets the resolver providing dependencies to the services started by the launcher,
+// they are added after the dependencies passed to Start
+func (l *ServiceLauncher) UseResolver(resolver platform.DependencyResolver) {
+	l.resolver = resolver
+}
+
+// defaultDeps appends the launcher's logger, the service metadata, a health registry, a data
+// subject registry and the watched config to deps, unless the caller already provided them
+func (l *ServiceLauncher) defaultDeps(service platform.Service, platformType platform.Type, deps []interface{}) []interface{} {
+	l.configMu.RLock()
+	cfg := l.config
+	l.configMu.RUnlock()
+
+	var hasLogger, hasMetadata, hasHealth, hasDataSubjects, hasConfig bool
+	for _, dep := range deps {
+		if cfg != nil && reflect.TypeOf(dep) == reflect.TypeOf(cfg) {
+			hasConfig = true
+		}
+
+		switch dep.(type) {
+		case *zap.Logger:
+			hasLogger = true
+		case platform.ServiceMetadata:
+			hasMetadata = true
+		case *health.Registry:
+			hasHealth = true
+		case *gdpr.Registry:
+			hasDataSubjects = true
+		}
+	}
+
+	if !hasLogger && l.logger != nil {
+		deps = append(deps, l.logger)
+	}
+
+	if !hasMetadata {
+		hostname, _ := os.Hostname()
+		deps = append(deps, platform.ServiceMetadata{
+			Platform:    platformType,
+			ServiceType: service.Type(),
+			Hostname:    hostname,
+			StartedAt:   time.Now().UTC(),
+		})
+	}
+
+	if !hasHealth {
+		deps = append(deps, health.NewRegistry())
+	}
+
+	if !hasDataSubjects {
+		deps = append(deps, gdpr.NewRegistry(nil, l.logger))
+	}
+
+	if !hasConfig && cfg != nil {
+		deps = append(deps, cfg)
+	}
+
+	return deps
 }
 
 // GetPlatformStarter retrieves a registered platform service starter
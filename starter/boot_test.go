@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"github.com/jjmaturino/bootstrapper/gdpr"
+	"github.com/jjmaturino/bootstrapper/health"
 	"github.com/jjmaturino/bootstrapper/platform"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -233,3 +235,93 @@ func (m *mockService) Type() platform.ServiceType {
 func (m *mockService) Initialize(ctx context.Context, deps ...interface{}) error {
 	return nil
 }
+
+func TestServiceLauncher_StartDefaultDeps(t *testing.T) {
+	ctx := context.Background()
+	testLogger := zaptest.NewLogger(t)
+
+	launcher := NewServiceLauncher(ctx, testLogger)
+
+	var received []interface{}
+	launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			received = deps
+			return nil
+		},
+	})
+
+	// Test Case 1: Logger, metadata, health and data subject registries are added to the caller's
+	// deps
+	if err := launcher.Start(ctx, &mockService{}, platform.VM, "engine"); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if engine, ok := platform.DepOf[string](received...); !ok || engine != "engine" {
+		t.Errorf("Expected the caller's deps to be kept, but got: %v", received)
+	}
+	if logger, ok := platform.DepOf[*zap.Logger](received...); !ok || logger != testLogger {
+		t.Errorf("Expected the launcher logger, but got: %v", received)
+	}
+	metadata, ok := platform.DepOf[platform.ServiceMetadata](received...)
+	if !ok || metadata.Platform != platform.VM || metadata.ServiceType != "mock-service" {
+		t.Errorf("Unexpected service metadata: %+v", metadata)
+	}
+	if _, ok := platform.DepOf[*health.Registry](received...); !ok {
+		t.Errorf("Expected a health registry, but got: %v", received)
+	}
+	if _, ok := platform.DepOf[*gdpr.Registry](received...); !ok {
+		t.Errorf("Expected a data subject registry, but got: %v", received)
+	}
+
+	// Test Case 2: Deps provided by the caller are not overridden
+	callerLogger := zap.NewNop()
+	callerMetadata := platform.ServiceMetadata{Platform: "custom"}
+	callerHealth := health.NewRegistry()
+	callerDataSubjects := gdpr.NewRegistry(nil, callerLogger)
+	if err := launcher.Start(ctx, &mockService{}, platform.VM, callerLogger, callerMetadata, callerHealth, callerDataSubjects); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if len(received) != 4 {
+		t.Errorf("Expected only the caller's deps, but got: %v", received)
+	}
+	if logger, _ := platform.DepOf[*zap.Logger](received...); logger != callerLogger {
+		t.Errorf("Expected the caller's logger, but got: %v", logger)
+	}
+	if metadata, _ := platform.DepOf[platform.ServiceMetadata](received...); metadata != callerMetadata {
+		t.Errorf("Expected the caller's metadata, but got: %+v", metadata)
+	}
+	if registry, _ := platform.DepOf[*health.Registry](received...); registry != callerHealth {
+		t.Errorf("Expected the caller's health registry, but got: %v", registry)
+	}
+	if registry, _ := platform.DepOf[*gdpr.Registry](received...); registry != callerDataSubjects {
+		t.Errorf("Expected the caller's data subject registry, but got: %v", registry)
+	}
+}
+
+type dependentMockService struct {
+	mockService
+}
+
+func (m *dependentMockService) Requires() []platform.DependencyKey {
+	return []platform.DependencyKey{platform.Require[platform.Engine]()}
+}
+
+func TestServiceLauncher_StartMissingDependency(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	mockStarter := &mockServiceStarter{}
+	launcher.RegisterPlatform(ctx, platform.VM, mockStarter)
+
+	err := launcher.Start(ctx, &dependentMockService{}, platform.VM)
+
+	expectedErrMsg := "missing dependency platform.Engine required by service *starter.dependentMockService"
+	if err == nil || err.Error() != expectedErrMsg {
+		t.Errorf("Expected error '%s', but got: %v", expectedErrMsg, err)
+	}
+
+	if mockStarter.startServiceCalled {
+		t.Errorf("Expected the service not to be started when a dependency is missing")
+	}
+}
@@ -0,0 +1,160 @@
+package starter
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/config"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap"
+)
+
+// DefaultConfigWatchInterval is how often the config file is checked for changes
+const DefaultConfigWatchInterval = 5 * time.Second
+
+// WatchConfig loads cfg, a pointer to a struct, with the loader and passes it to the services
+// started by the launcher. While a service runs, the config is loaded again on SIGHUP and when its
+// file changes, checked every interval (DefaultConfigWatchInterval when zero), and delivered to
+// services implementing platform.ReloadableService. Reloads are driven by the signal handling
+// goroutine of the platform starter, through the platform.ConfigReloader carried by the context.
+// Reloaded configs are new values, cfg keeps the config the service started with.
+func (l *ServiceLauncher) WatchConfig(loader *config.Loader, cfg interface{}, interval time.Duration) error {
+	if err := loader.Load(cfg); err != nil {
+		return err
+	}
+
+	if interval <= 0 {
+		interval = DefaultConfigWatchInterval
+	}
+
+	modTime, _ := configFileModTime(loader)
+
+	l.configMu.Lock()
+	defer l.configMu.Unlock()
+	l.configLoader = loader
+	l.config = cfg
+	l.configInterval = interval
+	l.configModTime = modTime
+
+	return nil
+}
+
+// reloadConfig loads a new config and delivers it to the service, the service keeps its current
+// config when loading fails
+func (l *ServiceLauncher) reloadConfig(ctx context.Context, service platform.Service) error {
+	l.configMu.RLock()
+	loader, cfg := l.configLoader, l.config
+	l.configMu.RUnlock()
+
+	if loader == nil {
+		return nil
+	}
+
+	reloadable, ok := service.(platform.ReloadableService)
+	if !ok {
+		l.logger.Info("Service does not implement ReloadableService, config change ignored")
+		return nil
+	}
+
+	newCfg := reflect.New(reflect.TypeOf(cfg).Elem()).Interface()
+	if err := loader.Load(newCfg); err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if err := reloadable.OnConfigChange(ctx, newCfg); err != nil {
+		return fmt.Errorf("service rejected config change: %w", err)
+	}
+
+	l.logger.Info("Delivered config change to service")
+	return nil
+}
+
+// configReloader returns the reloader of the service's config, nil when no config is watched
+func (l *ServiceLauncher) configReloader(service platform.Service) *configReloader {
+	l.configMu.RLock()
+	defer l.configMu.RUnlock()
+
+	if l.configLoader == nil {
+		return nil
+	}
+
+	var interval time.Duration
+	if l.configLoader.File() != "" {
+		interval = l.configInterval
+	}
+
+	return &configReloader{
+		launcher: l,
+		service:  service,
+		file:     l.configLoader.File(),
+		interval: interval,
+		modTime:  l.configModTime,
+	}
+}
+
+// configReloader reloads the config of one service, each service tracks the file's modification
+// time so a change is delivered to all of them
+type configReloader struct {
+	launcher *ServiceLauncher
+	service  platform.Service
+	file     string
+	interval time.Duration
+
+	// mu protects modTime
+	mu      sync.Mutex
+	modTime time.Time
+}
+
+// ReloadConfig implements platform.ConfigReloader
+func (r *configReloader) ReloadConfig(ctx context.Context) {
+	if err := r.launcher.reloadConfig(ctx, r.service); err != nil {
+		r.launcher.logger.Error("Failed to reload config, keeping the current one",
+			zap.String("serviceType", string(r.service.Type())),
+			zap.Error(err))
+	}
+}
+
+// PollInterval implements platform.ConfigReloader
+func (r *configReloader) PollInterval() time.Duration {
+	return r.interval
+}
+
+// ReloadIfChanged implements platform.ConfigReloader
+func (r *configReloader) ReloadIfChanged(ctx context.Context) {
+	if !r.changed() {
+		return
+	}
+
+	r.launcher.logger.Info("Config file changed, reloading config", zap.String("file", r.file))
+	r.ReloadConfig(ctx)
+}
+
+// changed reports whether the config file was modified since it was last seen
+func (r *configReloader) changed() bool {
+	info, err := os.Stat(r.file)
+	if err != nil {
+		r.launcher.logger.Warn("Failed to check config file", zap.Error(err))
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !info.ModTime().After(r.modTime) {
+		return false
+	}
+	r.modTime = info.ModTime()
+	return true
+}
+
+// configFileModTime returns the modification time of the loader's config file
+func configFileModTime(loader *config.Loader) (time.Time, error) {
+	info, err := os.Stat(loader.File())
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
@@ -0,0 +1,253 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/config"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+)
+
+type testConfig struct {
+	Greeting string `yaml:"greeting" default:"hello"`
+}
+
+// reloadableService records the configs delivered to it
+type reloadableService struct {
+	mockService
+
+	mu      sync.Mutex
+	configs []*testConfig
+	err     error
+}
+
+func (s *reloadableService) OnConfigChange(ctx context.Context, newCfg interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return s.err
+	}
+	s.configs = append(s.configs, newCfg.(*testConfig))
+	return nil
+}
+
+func (s *reloadableService) last() *testConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.configs) == 0 {
+		return nil
+	}
+	return s.configs[len(s.configs)-1]
+}
+
+func writeConfig(t *testing.T, path, greeting string) {
+	if err := os.WriteFile(path, []byte("greeting: "+greeting+"\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+}
+
+func TestServiceLauncher_WatchConfig(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "hi")
+
+	var cfg testConfig
+	if err := launcher.WatchConfig(config.New(config.WithFile(path)), &cfg, 10*time.Millisecond); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if cfg.Greeting != "hi" {
+		t.Errorf("Expected greeting 'hi', got %q", cfg.Greeting)
+	}
+
+	service := &reloadableService{}
+	launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, svc platform.Service, deps ...interface{}) error {
+			var received *testConfig
+			for _, dep := range deps {
+				if c, ok := dep.(*testConfig); ok {
+					received = c
+				}
+			}
+			if received != &cfg {
+				return errors.New("config was not passed to the service")
+			}
+
+			reloader, ok := platform.ConfigReloaderFromContext(ctx)
+			if !ok {
+				return errors.New("config reloader was not passed to the starter")
+			}
+			if got := reloader.PollInterval(); got != 10*time.Millisecond {
+				return fmt.Errorf("expected the config to be polled every 10ms, got %s", got)
+			}
+
+			// Nothing is delivered while the file is unchanged
+			reloader.ReloadIfChanged(ctx)
+			if service.last() != nil {
+				return errors.New("config was delivered without a change")
+			}
+
+			// Make sure the modification time moves past the one seen by WatchConfig
+			writeConfig(t, path, "bonjour")
+			later := time.Now().Add(time.Second)
+			if err := os.Chtimes(path, later, later); err != nil {
+				return err
+			}
+
+			reloader.ReloadIfChanged(ctx)
+			if service.last() == nil {
+				return errors.New("config change was not delivered")
+			}
+			return nil
+		},
+	})
+
+	if err := launcher.Start(ctx, service, platform.VM); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	if got := service.last().Greeting; got != "bonjour" {
+		t.Errorf("Expected greeting 'bonjour', got %q", got)
+	}
+	if cfg.Greeting != "hi" {
+		t.Errorf("Expected the initial config to be kept, got %q", cfg.Greeting)
+	}
+}
+
+func TestServiceLauncher_WatchConfigInvalid(t *testing.T) {
+	launcher := NewServiceLauncher(context.Background(), zaptest.NewLogger(t))
+
+	var cfg testConfig
+	err := launcher.WatchConfig(config.New(config.WithFile("missing.yaml")), &cfg, 0)
+	if err == nil {
+		t.Error("Expected an error for a missing config file")
+	}
+}
+
+func TestServiceLauncher_ReloadConfig(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "hi")
+
+	var cfg testConfig
+	if err := launcher.WatchConfig(config.New(config.WithFile(path)), &cfg, 0); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Services that do not reload ignore changes
+	if err := launcher.reloadConfig(ctx, &mockService{}); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+
+	rejecting := &reloadableService{err: errors.New("greeting not allowed")}
+	expectedErrMsg := "service rejected config change: greeting not allowed"
+	if err := launcher.reloadConfig(ctx, rejecting); err == nil || err.Error() != expectedErrMsg {
+		t.Errorf("Expected error '%s', but got: %v", expectedErrMsg, err)
+	}
+
+	if err := os.WriteFile(path, []byte("greeting: [\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write config: %v", err)
+	}
+	service := &reloadableService{}
+	if err := launcher.reloadConfig(ctx, service); err == nil {
+		t.Error("Expected an error for an invalid config file")
+	}
+	if service.last() != nil {
+		t.Error("Expected no config to be delivered when loading fails")
+	}
+}
+
+func TestServiceLauncher_ReloadConfigWithoutFile(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	// Without a file the config is only reloaded on SIGHUP
+	var cfg testConfig
+	if err := launcher.WatchConfig(config.New(), &cfg, 0); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	service := &reloadableService{}
+	launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, svc platform.Service, deps ...interface{}) error {
+			reloader, ok := platform.ConfigReloaderFromContext(ctx)
+			if !ok {
+				return errors.New("config reloader was not passed to the starter")
+			}
+			if got := reloader.PollInterval(); got != 0 {
+				return fmt.Errorf("expected no config file polling, got %s", got)
+			}
+
+			reloader.ReloadConfig(ctx)
+			return nil
+		},
+	})
+
+	if err := launcher.Start(ctx, service, platform.VM); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if got := service.last().Greeting; got != "hello" {
+		t.Errorf("Expected greeting 'hello', got %q", got)
+	}
+}
+
+func TestServiceLauncher_StartAllReloadsEveryServiceConfig(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "hi")
+
+	var cfg testConfig
+	if err := launcher.WatchConfig(config.New(config.WithFile(path)), &cfg, 10*time.Millisecond); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	// Each service polls the file concurrently, the change is delivered to both
+	var wg sync.WaitGroup
+	wg.Add(2)
+	pollUntilDelivered := func(ctx context.Context, svc platform.Service, deps ...interface{}) error {
+		reloader, _ := platform.ConfigReloaderFromContext(ctx)
+		wg.Done()
+		wg.Wait()
+
+		for svc.(*reloadableService).last() == nil {
+			reloader.ReloadIfChanged(ctx)
+			time.Sleep(time.Millisecond)
+		}
+		return nil
+	}
+	launcher.RegisterPlatform(ctx, "first", &mockServiceStarter{startServiceFunc: pollUntilDelivered})
+	launcher.RegisterPlatform(ctx, "second", &mockServiceStarter{startServiceFunc: pollUntilDelivered})
+
+	first, second := &reloadableService{}, &reloadableService{}
+	go func() {
+		wg.Wait()
+		writeConfig(t, path, "bonjour")
+		later := time.Now().Add(time.Second)
+		_ = os.Chtimes(path, later, later)
+	}()
+
+	err := launcher.StartAll(ctx, []ServiceSpec{
+		{Name: "first", Service: first, Platform: "first"},
+		{Name: "second", Service: second, Platform: "second"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	for _, service := range []*reloadableService{first, second} {
+		if got := service.last().Greeting; got != "bonjour" {
+			t.Errorf("Expected greeting 'bonjour', got %q", got)
+		}
+	}
+}
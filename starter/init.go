@@ -0,0 +1,114 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap"
+)
+
+// DefaultInitTimeout bounds the initialization of all dependencies of a service
+const DefaultInitTimeout = 30 * time.Second
+
+// SetInitTimeout sets the combined deadline for initializing the dependencies of a service
+func (l *ServiceLauncher) SetInitTimeout(timeout time.Duration) {
+	l.initTimeout = timeout
+}
+
+// initDeps initializes the dependencies implementing platform.Initializer in parallel, under a
+// combined deadline. Each initialization is logged with its duration, and the returned error
+// joins the failures of every dependency. Once the deadline passes it returns without waiting
+// for dependencies whose Init ignores its context, reporting them as still pending.
+func (l *ServiceLauncher) initDeps(ctx context.Context, deps []interface{}) error {
+	var initializers []platform.Initializer
+	for _, dep := range deps {
+		if initializer, ok := dep.(platform.Initializer); ok {
+			initializers = append(initializers, initializer)
+		}
+		if _, ok := dep.(platform.LazyDependency); ok {
+			l.logger.Info("Deferring lazy dependency initialization to first use", zap.String("dependency", fmt.Sprintf("%T", dep)))
+		}
+	}
+	if len(initializers) == 0 {
+		return nil
+	}
+
+	timeout := l.initTimeout
+	if timeout <= 0 {
+		timeout = DefaultInitTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	l.logger.Info("Initializing dependencies", zap.Int("count", len(initializers)), zap.Duration("timeout", timeout))
+	start := time.Now()
+
+	// Results are recorded under a lock, so that the dependencies still pending when the deadline
+	// passes can be reported without waiting for an Init ignoring its context
+	var mu sync.Mutex
+	errs := make([]error, len(initializers))
+	pending := make(map[int]string, len(initializers))
+	var wg sync.WaitGroup
+	for i, initializer := range initializers {
+		pending[i] = fmt.Sprintf("%T", initializer)
+		wg.Add(1)
+		go func(i int, initializer platform.Initializer) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("%T", initializer)
+			depStart := time.Now()
+			endInit := platform.TimelineFromContext(ctx).Span("initialize " + name)
+			err := initializer.Init(ctx)
+			endInit(err)
+			duration := time.Since(depStart)
+
+			mu.Lock()
+			defer mu.Unlock()
+			delete(pending, i)
+			if err != nil {
+				l.logger.Error("Failed to initialize dependency",
+					zap.String("dependency", name), zap.Duration("duration", duration), zap.Error(err))
+				errs[i] = fmt.Errorf("failed to initialize %s: %w", name, err)
+				return
+			}
+			l.logger.Info("Initialized dependency", zap.String("dependency", name), zap.Duration("duration", duration))
+		}(i, initializer)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		mu.Lock()
+		var names []string
+		for i := range initializers {
+			if name, ok := pending[i]; ok {
+				names = append(names, name)
+				errs[i] = fmt.Errorf("failed to initialize %s: %w", name, ctx.Err())
+			}
+		}
+		err := errors.Join(errs...)
+		mu.Unlock()
+
+		if len(names) > 0 {
+			l.logger.Error("Dependencies did not initialize before the deadline",
+				zap.Strings("pending", names), zap.Duration("duration", time.Since(start)))
+			return err
+		}
+	}
+
+	l.logger.Info("Dependencies initialized", zap.Duration("duration", time.Since(start)))
+
+	mu.Lock()
+	defer mu.Unlock()
+	return errors.Join(errs...)
+}
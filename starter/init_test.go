@@ -0,0 +1,172 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+)
+
+type slowDependency struct {
+	delay time.Duration
+	err   error
+	done  atomic.Bool
+}
+
+func (d *slowDependency) Init(ctx context.Context) error {
+	select {
+	case <-time.After(d.delay):
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	d.done.Store(true)
+	return d.err
+}
+
+type slowCache struct {
+	slowDependency
+}
+
+// stuckDependency ignores the context passed to Init and blocks until released
+type stuckDependency struct {
+	release chan struct{}
+}
+
+func (d *stuckDependency) Init(ctx context.Context) error {
+	<-d.release
+	return nil
+}
+
+func TestServiceLauncher_StartInitializesDependencies(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("initializes in parallel before starting", func(t *testing.T) {
+		launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+		db := &slowDependency{delay: 100 * time.Millisecond}
+		cache := &slowCache{slowDependency{delay: 100 * time.Millisecond}}
+
+		launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+			startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+				if !db.done.Load() || !cache.done.Load() {
+					return errors.New("service started before its dependencies were initialized")
+				}
+				return nil
+			},
+		})
+
+		start := time.Now()
+		if err := launcher.Start(ctx, &mockService{}, platform.VM, db, cache); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+		if elapsed := time.Since(start); elapsed >= 200*time.Millisecond {
+			t.Errorf("Expected dependencies to initialize in parallel, took %s", elapsed)
+		}
+	})
+
+	t.Run("reports every failing dependency", func(t *testing.T) {
+		launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+		launcher.SetInitTimeout(50 * time.Millisecond)
+
+		mockStarter := &mockServiceStarter{}
+		launcher.RegisterPlatform(ctx, platform.VM, mockStarter)
+
+		db := &slowDependency{err: errors.New("connection refused")}
+		cache := &slowCache{slowDependency{delay: time.Second}}
+
+		err := launcher.Start(ctx, &mockService{}, platform.VM, db, cache)
+		if err == nil {
+			t.Fatalf("Expected an error")
+		}
+
+		for _, want := range []string{
+			"failed to initialize *starter.slowDependency: connection refused",
+			"failed to initialize *starter.slowCache: context deadline exceeded",
+		} {
+			if !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to contain '%s', but got: %v", want, err)
+			}
+		}
+
+		if mockStarter.startServiceCalled {
+			t.Errorf("Expected the service not to be started when a dependency fails to initialize")
+		}
+	})
+	t.Run("reports dependencies still pending at the deadline", func(t *testing.T) {
+		launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+		launcher.SetInitTimeout(50 * time.Millisecond)
+
+		mockStarter := &mockServiceStarter{}
+		launcher.RegisterPlatform(ctx, platform.VM, mockStarter)
+
+		stuck := &stuckDependency{release: make(chan struct{})}
+		defer close(stuck.release)
+
+		returned := make(chan error, 1)
+		go func() {
+			returned <- launcher.Start(ctx, &mockService{}, platform.VM, &slowDependency{}, stuck)
+		}()
+
+		select {
+		case err := <-returned:
+			want := "failed to initialize *starter.stuckDependency: context deadline exceeded"
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Expected error to contain '%s', but got: %v", want, err)
+			}
+			if strings.Contains(err.Error(), "slowDependency") {
+				t.Errorf("Expected only the pending dependency to be reported, but got: %v", err)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("Expected Start to return once the init deadline passed")
+		}
+
+		if mockStarter.startServiceCalled {
+			t.Errorf("Expected the service not to be started when a dependency is still initializing")
+		}
+	})
+	t.Run("defers lazy dependencies to first use", func(t *testing.T) {
+		launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+		search := platform.Lazy(&slowDependency{})
+		launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+			startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+				if state := search.State(); state != platform.LazyPending {
+					return errors.New("lazy dependency initialized at boot: " + state.String())
+				}
+				return nil
+			},
+		})
+
+		if err := launcher.Start(ctx, &mockService{}, platform.VM, search); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+	})
+	t.Run("records dependency initialization on the timeline", func(t *testing.T) {
+		launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+		var spans []platform.TimelineSpan
+		launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+			startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+				spans = platform.TimelineFromContext(ctx).Spans()
+				return nil
+			},
+		})
+
+		if err := launcher.Start(ctx, &mockService{}, platform.VM, &slowDependency{}); err != nil {
+			t.Fatalf("Expected no error, but got: %v", err)
+		}
+
+		var names []string
+		for _, span := range spans {
+			names = append(names, span.Name)
+		}
+		if want := "check dependencies,initialize *starter.slowDependency"; strings.Join(names, ",") != want {
+			t.Errorf("Expected timeline spans '%s', but got: %v", want, names)
+		}
+	})
+}
@@ -0,0 +1,24 @@
+package starter
+
+import (
+	"github.com/jjmaturino/bootstrapper/maxprocs"
+	"go.uber.org/zap"
+)
+
+// SetMaxProcs overrides GOMAXPROCS for the services started by the launcher. By default (0) it
+// is sized from the CPU quota of the container, a negative value keeps the runtime default.
+func (l *ServiceLauncher) SetMaxProcs(procs int) {
+	l.maxProcs = procs
+}
+
+// applyMaxProcs sets GOMAXPROCS once per launcher, before the first service starts
+func (l *ServiceLauncher) applyMaxProcs() {
+	l.maxProcsOnce.Do(func() {
+		if l.maxProcs < 0 {
+			return
+		}
+		if _, err := maxprocs.Apply(l.maxProcs, l.logger); err != nil {
+			l.logger.Warn("Failed to size GOMAXPROCS from the CPU quota", zap.Error(err))
+		}
+	})
+}
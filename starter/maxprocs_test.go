@@ -0,0 +1,44 @@
+package starter
+
+import (
+	"context"
+	"runtime"
+	"testing"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestServiceLauncher_StartSetsMaxProcs(t *testing.T) {
+	ctx := context.Background()
+	procs := runtime.GOMAXPROCS(0)
+	t.Cleanup(func() { runtime.GOMAXPROCS(procs) })
+
+	tests := []struct {
+		name     string
+		maxProcs int
+		expected int
+	}{
+		{name: "override", maxProcs: 2, expected: 2},
+		{name: "runtime default", maxProcs: -1, expected: 3},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			runtime.GOMAXPROCS(3)
+
+			launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+			launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{})
+			launcher.SetMaxProcs(tt.maxProcs)
+
+			if err := launcher.Start(ctx, &mockService{}, platform.VM); err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+
+			if got := runtime.GOMAXPROCS(0); got != tt.expected {
+				t.Errorf("Expected GOMAXPROCS %d, but got %d", tt.expected, got)
+			}
+		})
+	}
+}
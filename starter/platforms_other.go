@@ -0,0 +1,8 @@
+//go:build !windows
+
+package starter
+
+import "context"
+
+// registerOSPlatforms registers the platform starters specific to the operating system
+func (l *ServiceLauncher) registerOSPlatforms(ctx context.Context) {}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package starter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+)
+
+// registerOSPlatforms registers the platform starters only available on Windows, the SCM service
+// name defaults to the executable name
+func (l *ServiceLauncher) registerOSPlatforms(ctx context.Context) {
+	name := filepath.Base(os.Args[0])
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+
+	l.RegisterPlatform(ctx, platform.WindowsService, platform.NewWindowsServiceStarter(name, l.logger))
+}
@@ -0,0 +1,77 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap"
+)
+
+// reloadable is a component registered for reload under a name used in logs and errors
+type reloadable struct {
+	name      string
+	component platform.Reloadable
+}
+
+// RegisterReloadable registers a component to be reloaded on SIGHUP, components are reloaded in
+// registration order
+func (l *ServiceLauncher) RegisterReloadable(name string, component platform.Reloadable) {
+	l.reloadMu.Lock()
+	defer l.reloadMu.Unlock()
+
+	l.reloadables = append(l.reloadables, reloadable{name: name, component: component})
+	l.logger.Info("Registered reloadable component", zap.String("component", name))
+}
+
+// Reload reloads every registered component. A failing component does not prevent the others
+// from reloading, the returned error joins the failures of all components.
+func (l *ServiceLauncher) Reload(ctx context.Context) error {
+	l.reloadMu.RLock()
+	components := make([]reloadable, len(l.reloadables))
+	copy(components, l.reloadables)
+	l.reloadMu.RUnlock()
+
+	var errs []error
+	for _, r := range components {
+		if err := r.component.Reload(ctx); err != nil {
+			l.logger.Error("Failed to reload component", zap.String("component", r.name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("failed to reload %s: %w", r.name, err))
+			continue
+		}
+		l.logger.Info("Reloaded component", zap.String("component", r.name))
+	}
+
+	return errors.Join(errs...)
+}
+
+// watchReloadSignals reloads the registered components on every SIGHUP until the returned stop
+// function is called. The config is reloaded by the platform starter, see WatchConfig.
+func (l *ServiceLauncher) watchReloadSignals(ctx context.Context) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	// Register before returning so a SIGHUP sent once the service starts is never missed
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-sigChan:
+				l.logger.Info("Received SIGHUP, reloading components")
+				if err := l.Reload(ctx); err != nil {
+					l.logger.Warn("Reload completed with errors", zap.Error(err))
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return cancel
+}
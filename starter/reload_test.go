@@ -0,0 +1,90 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap/zaptest"
+)
+
+type mockReloadable struct {
+	calls atomic.Int32
+	err   error
+}
+
+func (m *mockReloadable) Reload(ctx context.Context) error {
+	m.calls.Add(1)
+	return m.err
+}
+
+func TestServiceLauncher_Reload(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	certs := &mockReloadable{err: errors.New("certificate expired")}
+	config := &mockReloadable{}
+	templates := &mockReloadable{err: errors.New("parse error")}
+
+	launcher.RegisterReloadable("certs", certs)
+	launcher.RegisterReloadable("config", config)
+	launcher.RegisterReloadable("templates", templates)
+
+	err := launcher.Reload(ctx)
+
+	// Every component is reloaded even when an earlier one fails
+	for name, component := range map[string]*mockReloadable{"certs": certs, "config": config, "templates": templates} {
+		if got := component.calls.Load(); got != 1 {
+			t.Errorf("Expected %s to be reloaded once, got %d", name, got)
+		}
+	}
+
+	expectedErrMsg := "failed to reload certs: certificate expired\nfailed to reload templates: parse error"
+	if err == nil || err.Error() != expectedErrMsg {
+		t.Errorf("Expected error '%s', but got: %v", expectedErrMsg, err)
+	}
+}
+
+func TestServiceLauncher_StartReloadsOnSIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGHUP is not delivered on Windows")
+	}
+
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zaptest.NewLogger(t))
+
+	config := &mockReloadable{}
+	launcher.RegisterReloadable("config", config)
+
+	// The service runs until the component has been reloaded
+	launcher.RegisterPlatform(ctx, platform.VM, &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			deadline := time.Now().Add(5 * time.Second)
+			for config.calls.Load() == 0 {
+				if time.Now().After(deadline) {
+					return errors.New("component was not reloaded")
+				}
+
+				process, err := os.FindProcess(os.Getpid())
+				if err != nil {
+					return err
+				}
+				if err := process.Signal(syscall.SIGHUP); err != nil {
+					return err
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			return nil
+		},
+	})
+
+	if err := launcher.Start(ctx, &mockService{}, platform.VM); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Restart modes of a RestartPolicy
+const (
+	// RestartNever returns the first error or panic of the service
+	RestartNever RestartMode = "never"
+
+	// RestartOnFailure starts the service again when it fails or panics
+	RestartOnFailure RestartMode = "on-failure"
+
+	// RestartAlways starts the service again whenever it returns before the launcher's context is
+	// done or the starter received a shutdown signal
+	RestartAlways RestartMode = "always"
+)
+
+// Defaults of RestartPolicy
+const (
+	DefaultRestartBackoff    = time.Second
+	DefaultRestartMaxBackoff = 30 * time.Second
+)
+
+// ErrServicePanicked is wrapped by the error returned for a service that panicked
+var ErrServicePanicked = errors.New("service panicked")
+
+// RestartMode selects when a supervised service is started again
+type RestartMode string
+
+// RestartPolicy configures how the launcher supervises a service
+type RestartPolicy struct {
+	// Mode selects when the service is restarted, RestartNever when empty
+	Mode RestartMode
+
+	// MaxAttempts bounds the restarts, unlimited when zero
+	MaxAttempts int
+
+	// Backoff is the delay before the first restart, doubled on each restart up to MaxBackoff
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// SetRestartPolicy sets how the services started by the launcher are restarted. Panics of the
+// goroutine starting a service, which runs Initialize and the starter's serve loop, are always
+// recovered and logged with their stack; panics of goroutines spawned by the service are not.
+// Restarted services get fresh engines, see platform.RestartDeps, services started with deps
+// that cannot be renewed fail to start under a restart policy.
+func (l *ServiceLauncher) SetRestartPolicy(policy RestartPolicy) {
+	l.restartPolicy = policy
+}
+
+// supervise runs the service with run, recovering its panics and restarting it according to the
+// restart policy
+func (l *ServiceLauncher) supervise(ctx context.Context, name string, run func() error) error {
+	policy := l.restartPolicy
+	backoff := policy.Backoff
+	if backoff <= 0 {
+		backoff = DefaultRestartBackoff
+	}
+	maxBackoff := policy.MaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = DefaultRestartMaxBackoff
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := l.runRecovered(name, run)
+
+		// Services stopping because the launcher is shutting down are not restarted
+		if ctx.Err() != nil {
+			return err
+		}
+
+		switch {
+		case policy.Mode == RestartAlways:
+		case policy.Mode == RestartOnFailure && err != nil:
+		default:
+			return err
+		}
+
+		if policy.MaxAttempts > 0 && attempt > policy.MaxAttempts {
+			l.logger.Error("Service restart attempts exhausted",
+				zap.String("service", name),
+				zap.Int("maxAttempts", policy.MaxAttempts),
+				zap.Error(err))
+			return err
+		}
+
+		l.logger.Warn("Restarting service",
+			zap.String("service", name),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", backoff),
+			zap.Error(err))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+		backoff = min(2*backoff, maxBackoff)
+	}
+}
+
+// runRecovered runs the service, turning a panic into an error logged with its stack
+func (l *ServiceLauncher) runRecovered(name string, run func() error) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			l.logger.Error("Service panicked",
+				zap.String("service", name),
+				zap.Any("panic", recovered),
+				zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("%w: %v", ErrServicePanicked, recovered)
+		}
+	}()
+
+	return run()
+}
@@ -0,0 +1,325 @@
+package starter
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+)
+
+// countingStarter runs fn with the number of the start, from 1
+func countingStarter(fn func(ctx context.Context, start int32) error) *mockServiceStarter {
+	var starts atomic.Int32
+	return &mockServiceStarter{
+		startServiceFunc: func(ctx context.Context, service platform.Service, deps ...interface{}) error {
+			return fn(ctx, starts.Add(1))
+		},
+	}
+}
+
+func TestServiceLauncher_RecoversPanics(t *testing.T) {
+	ctx := context.Background()
+	core, logs := observer.New(zap.ErrorLevel)
+	launcher := NewServiceLauncher(ctx, zap.New(core))
+
+	launcher.RegisterPlatform(ctx, platform.VM, countingStarter(func(ctx context.Context, start int32) error {
+		panic("nil map write")
+	}))
+
+	err := launcher.Start(ctx, &mockService{}, platform.VM)
+
+	if !errors.Is(err, ErrServicePanicked) || err.Error() != "service panicked: nil map write" {
+		t.Errorf("Expected a panic error, but got: %v", err)
+	}
+
+	entries := logs.FilterMessage("Service panicked").All()
+	if len(entries) != 1 {
+		t.Fatalf("Expected the panic to be logged once, got %d", len(entries))
+	}
+	if stack, ok := entries[0].ContextMap()["stack"].(string); !ok || stack == "" {
+		t.Error("Expected the panic to be logged with its stack")
+	}
+}
+
+func TestServiceLauncher_RestartPolicy(t *testing.T) {
+	failure := errors.New("connection lost")
+
+	tests := []struct {
+		name           string
+		policy         RestartPolicy
+		run            func(ctx context.Context, start int32) error
+		expectedStarts int32
+		expectedErr    error
+	}{
+		{
+			name:           "never",
+			policy:         RestartPolicy{Mode: RestartNever},
+			run:            func(ctx context.Context, start int32) error { return failure },
+			expectedStarts: 1,
+			expectedErr:    failure,
+		},
+		{
+			name:   "on failure until success",
+			policy: RestartPolicy{Mode: RestartOnFailure},
+			run: func(ctx context.Context, start int32) error {
+				if start < 3 {
+					panic("flaky")
+				}
+				return nil
+			},
+			expectedStarts: 3,
+		},
+		{
+			name:           "on failure with max attempts",
+			policy:         RestartPolicy{Mode: RestartOnFailure, MaxAttempts: 2},
+			run:            func(ctx context.Context, start int32) error { return failure },
+			expectedStarts: 3,
+			expectedErr:    failure,
+		},
+		{
+			name:   "always restarts clean exits",
+			policy: RestartPolicy{Mode: RestartAlways, MaxAttempts: 1},
+			run: func(ctx context.Context, start int32) error {
+				if start == 1 {
+					return nil
+				}
+				return failure
+			},
+			expectedStarts: 2,
+			expectedErr:    failure,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			launcher := NewServiceLauncher(ctx, zap.NewNop())
+
+			tt.policy.Backoff = time.Millisecond
+			launcher.SetRestartPolicy(tt.policy)
+
+			var starts int32
+			launcher.RegisterPlatform(ctx, platform.VM, countingStarter(func(ctx context.Context, start int32) error {
+				starts = start
+				return tt.run(ctx, start)
+			}))
+
+			err := launcher.Start(ctx, &mockService{}, platform.VM)
+
+			if !errors.Is(err, tt.expectedErr) || (tt.expectedErr == nil && err != nil) {
+				t.Errorf("Expected error %v, but got: %v", tt.expectedErr, err)
+			}
+			if starts != tt.expectedStarts {
+				t.Errorf("Expected %d starts, got %d", tt.expectedStarts, starts)
+			}
+		})
+	}
+}
+
+func TestServiceLauncher_NoRestartOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	launcher := NewServiceLauncher(ctx, zap.NewNop())
+	launcher.SetRestartPolicy(RestartPolicy{Mode: RestartAlways, Backoff: time.Millisecond})
+
+	var starts int32
+	launcher.RegisterPlatform(ctx, platform.VM, countingStarter(func(ctx context.Context, start int32) error {
+		starts = start
+		cancel()
+		return nil
+	}))
+
+	if err := launcher.Start(ctx, &mockService{}, platform.VM); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if starts != 1 {
+		t.Errorf("Expected the service to stop with the launcher, got %d starts", starts)
+	}
+}
+
+func TestServiceLauncher_NoRestartOnShutdownSignal(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zap.NewNop())
+	launcher.SetRestartPolicy(RestartPolicy{Mode: RestartAlways, MaxAttempts: 2, Backoff: time.Millisecond})
+
+	// The starter received a shutdown signal, the launcher's own context is still running
+	var starts int32
+	launcher.RegisterPlatform(ctx, platform.VM, countingStarter(func(ctx context.Context, start int32) error {
+		starts = start
+		platform.NotifyShutdown(ctx)
+		return nil
+	}))
+
+	if err := launcher.Start(ctx, &mockService{}, platform.VM); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if starts != 1 {
+		t.Errorf("Expected the service to stop on the shutdown signal, got %d starts", starts)
+	}
+}
+
+// initCountingService is an HTTP service counting its initializations
+type initCountingService struct {
+	routedService
+	inits atomic.Int32
+}
+
+func (s *initCountingService) Initialize(ctx context.Context, deps ...interface{}) error {
+	s.inits.Add(1)
+	return nil
+}
+
+func TestServiceLauncher_SIGTERMStopsSupervisedHTTPService(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGTERM is not delivered on Windows")
+	}
+	gin.SetMode(gin.TestMode)
+
+	ctx := context.Background()
+	core, logs := observer.New(zap.InfoLevel)
+	launcher := NewServiceLauncher(ctx, zap.New(core))
+	launcher.SetRestartPolicy(RestartPolicy{Mode: RestartAlways, Backoff: time.Millisecond})
+
+	service := &initCountingService{}
+	done := make(chan error, 1)
+	go func() {
+		done <- launcher.Start(ctx, service, platform.VM, gin.New(), platform.HTTPConfig{Addr: "127.0.0.1:0"})
+	}()
+
+	// The starter handles SIGTERM once the server is starting
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("Starting HTTP server").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the HTTP server to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatalf("Failed to find the process: %v", err)
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		t.Fatalf("Failed to send SIGTERM: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Expected no error, but got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Start to return after SIGTERM")
+	}
+	if inits := service.inits.Load(); inits != 1 {
+		t.Errorf("Expected the service to be initialized once, got %d", inits)
+	}
+}
+
+// routedService is an HTTP service registering a single route
+type routedService struct {
+	mockService
+}
+
+func (s *routedService) ConfigureRoutes(ctx context.Context, engine platform.Engine) error {
+	engine.Handle(http.MethodGet, "/x", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+	return nil
+}
+
+func (s *routedService) Type() platform.ServiceType {
+	return platform.HTTPServiceType
+}
+
+func TestServiceLauncher_RestartsHTTPService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The first start fails to listen on the taken address
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	addr := taken.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	core, logs := observer.New(zap.WarnLevel)
+	launcher := NewServiceLauncher(ctx, zap.New(core))
+	launcher.SetRestartPolicy(RestartPolicy{Mode: RestartOnFailure, Backoff: 50 * time.Millisecond})
+
+	engine := gin.New()
+	engine.Use(func(c *gin.Context) {
+		c.Header("X-Middleware", "kept")
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- launcher.Start(ctx, &routedService{}, platform.VM, engine, platform.HTTPConfig{Addr: addr})
+	}()
+
+	// Free the address once the first start failed
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.FilterMessage("Restarting service").Len() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the service to be restarted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	taken.Close()
+
+	var resp *http.Response
+	for {
+		resp, err = http.Get("http://" + addr + "/x")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the restarted service to serve, got: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("X-Middleware") != "kept" {
+		t.Errorf("Expected the route and middleware on the restarted engine, got %d %v", resp.StatusCode, resp.Header)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if logs.FilterMessage("Service panicked").Len() != 0 {
+		t.Error("Expected the restart not to panic")
+	}
+}
+
+func TestServiceLauncher_RestartPolicyUnsupportedDeps(t *testing.T) {
+	ctx := context.Background()
+	launcher := NewServiceLauncher(ctx, zap.NewNop())
+	launcher.SetRestartPolicy(RestartPolicy{Mode: RestartAlways})
+
+	var started bool
+	launcher.RegisterPlatform(ctx, platform.VM, countingStarter(func(ctx context.Context, start int32) error {
+		started = true
+		return nil
+	}))
+
+	err := launcher.Start(ctx, &mockService{}, platform.VM, grpc.NewServer())
+	if err == nil {
+		t.Error("Expected a restart policy with a gRPC server to be refused")
+	}
+	if started {
+		t.Error("Expected the service not to start")
+	}
+}
@@ -0,0 +1,142 @@
+package systemd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Notification states understood by the service manager
+const (
+	Ready     = "READY=1"
+	Stopping  = "STOPPING=1"
+	Reloading = "RELOADING=1"
+	Watchdog  = "WATCHDOG=1"
+)
+
+// listenFDsStart is the first file descriptor passed by socket activation
+const listenFDsStart = 3
+
+// Notify sends a state notification to the service manager. It returns false without an error
+// when the process is not running under systemd with Type=notify.
+func Notify(state string) (bool, error) {
+	socketAddr := os.Getenv("NOTIFY_SOCKET")
+	if socketAddr == "" {
+		return false, nil
+	}
+
+	// Abstract namespace sockets are given with a leading @
+	if strings.HasPrefix(socketAddr, "@") {
+		socketAddr = "\x00" + socketAddr[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketAddr, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to send notification: %w", err)
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns how often the service must ping the watchdog, 0 when the watchdog is
+// not enabled for this process
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC: %q", usec)
+	}
+
+	return time.Duration(n) * time.Microsecond, nil
+}
+
+// RunWatchdog pings the watchdog at half its interval until the context is done, it returns
+// immediately when the watchdog is not enabled
+func RunWatchdog(ctx context.Context, logger *zap.Logger) {
+	interval, err := WatchdogInterval()
+	if err != nil {
+		logger.Warn("Ignoring systemd watchdog", zap.Error(err))
+		return
+	}
+	if interval == 0 {
+		return
+	}
+
+	logger.Info("Starting systemd watchdog", zap.Duration("interval", interval))
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := Notify(Watchdog); err != nil {
+				logger.Warn("Failed to ping systemd watchdog", zap.Error(err))
+			}
+		}
+	}
+}
+
+// Listeners returns the sockets passed by systemd socket activation, in the order of the socket
+// unit's Listen directives. It returns nil when the process was not socket activated. The
+// activation environment is cleared so child processes do not inherit it.
+func Listeners() ([]net.Listener, error) {
+	pid := os.Getenv("LISTEN_PID")
+	fds := os.Getenv("LISTEN_FDS")
+	if pid == "" || fds == "" || pid != strconv.Itoa(os.Getpid()) {
+		return nil, nil
+	}
+
+	defer func() {
+		os.Unsetenv("LISTEN_PID")
+		os.Unsetenv("LISTEN_FDS")
+		os.Unsetenv("LISTEN_FDNAMES")
+	}()
+
+	n, err := strconv.Atoi(fds)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid LISTEN_FDS: %q", fds)
+	}
+
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	listeners := make([]net.Listener, 0, n)
+	for i := 0; i < n; i++ {
+		name := fmt.Sprintf("LISTEN_FD_%d", listenFDsStart+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+
+		file := os.NewFile(uintptr(listenFDsStart+i), name)
+		ln, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			for _, l := range listeners {
+				l.Close()
+			}
+			return nil, fmt.Errorf("failed to use activated socket %s: %w", name, err)
+		}
+		listeners = append(listeners, ln)
+	}
+
+	return listeners, nil
+}
@@ -0,0 +1,139 @@
+package systemd
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+// listenNotifySocket creates a notify socket and points NOTIFY_SOCKET at it
+func listenNotifySocket(t *testing.T) *net.UnixConn {
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+	return conn
+}
+
+func readNotification(t *testing.T, conn *net.UnixConn) string {
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	return string(buf[:n])
+}
+
+func TestNotify(t *testing.T) {
+	t.Run("not running under systemd", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", "")
+
+		sent, err := Notify(Ready)
+
+		assert.NoError(t, err)
+		assert.False(t, sent)
+	})
+
+	t.Run("sends state", func(t *testing.T) {
+		conn := listenNotifySocket(t)
+
+		sent, err := Notify(Ready)
+
+		assert.NoError(t, err)
+		assert.True(t, sent)
+		assert.Equal(t, Ready, readNotification(t, conn))
+	})
+
+	t.Run("socket unreachable", func(t *testing.T) {
+		t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+
+		sent, err := Notify(Stopping)
+
+		assert.Error(t, err)
+		assert.False(t, sent)
+	})
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name    string
+		usec    string
+		pid     string
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "disabled", usec: "", want: 0},
+		{name: "enabled", usec: "30000000", want: 30 * time.Second},
+		{name: "enabled for this process", usec: "2000000", pid: strconv.Itoa(os.Getpid()), want: 2 * time.Second},
+		{name: "other process", usec: "2000000", pid: "1", want: 0},
+		{name: "invalid", usec: "soon", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+
+			got, err := WatchdogInterval()
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRunWatchdog(t *testing.T) {
+	conn := listenNotifySocket(t)
+	t.Setenv("WATCHDOG_USEC", "20000")
+	t.Setenv("WATCHDOG_PID", "")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		RunWatchdog(ctx, zaptest.NewLogger(t))
+		close(done)
+	}()
+
+	assert.Equal(t, Watchdog, readNotification(t, conn))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("watchdog did not stop when the context was cancelled")
+	}
+}
+
+func TestListenersNotActivated(t *testing.T) {
+	t.Setenv("LISTEN_PID", "1")
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := Listeners()
+
+	assert.NoError(t, err)
+	assert.Nil(t, listeners)
+}
+
+func TestListenersInvalidCount(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	t.Setenv("LISTEN_FDS", "many")
+
+	listeners, err := Listeners()
+
+	assert.EqualError(t, err, `invalid LISTEN_FDS: "many"`)
+	assert.Nil(t, listeners)
+	assert.Empty(t, os.Getenv("LISTEN_PID"))
+}
@@ -0,0 +1,45 @@
+package tokenize
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryVault keeps token values in memory, for tests and development
+type MemoryVault struct {
+	// mu protects values
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+// NewMemoryVault creates an empty vault
+func NewMemoryVault() *MemoryVault {
+	return &MemoryVault{values: make(map[string]string)}
+}
+
+// Put stores the value of a token
+func (v *MemoryVault) Put(ctx context.Context, domain, token, value string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	key := domain + "\x00" + token
+	if existing, ok := v.values[key]; ok && existing != value {
+		return ErrCollision
+	}
+	v.values[key] = value
+	return nil
+}
+
+// Get returns the value of a token
+func (v *MemoryVault) Get(ctx context.Context, domain, token string) (string, error) {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	value, ok := v.values[domain+"\x00"+token]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return value, nil
+}
+
+var _ Vault = (*MemoryVault)(nil)
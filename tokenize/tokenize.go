@@ -0,0 +1,155 @@
+// Package tokenize replaces sensitive values, like emails or card numbers, with deterministic
+// tokens. The same value always yields the same token within a domain, so tokens can still be
+// joined and counted, and a Vault maps tokens back to values for the few callers allowed to.
+package tokenize
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// opaquePrefix starts opaque tokens so they are recognizable in data and logs
+const opaquePrefix = "tok_"
+
+// Errors of tokenization and detokenization
+var (
+	ErrNoVault   = errors.New("tokenizer has no vault")
+	ErrNotFound  = errors.New("token not found")
+	ErrCollision = errors.New("token collision")
+)
+
+// Format selects the shape of tokens
+type Format struct {
+	// Digits replaces each digit with a digit and keeps other characters, so the token passes
+	// the same format validation as the value. Other tokens, and tokens of values without
+	// digits, are opaque "tok_" strings.
+	Digits bool
+
+	// KeepLast keeps the last digits of the value, like the last four of a card number. Values
+	// without more digits than that are replaced entirely.
+	KeepLast int
+}
+
+// Formats of common values
+var (
+	Opaque     = Format{}
+	Digits     = Format{Digits: true}
+	CardNumber = Format{Digits: true, KeepLast: 4}
+)
+
+// Vault stores the values of tokens for detokenization, it should encrypt them at rest
+type Vault interface {
+	// Put stores the value of a token, ErrCollision when the token holds another value
+	Put(ctx context.Context, domain, token, value string) error
+
+	// Get returns the value of a token, ErrNotFound when it is unknown
+	Get(ctx context.Context, domain, token string) (string, error)
+}
+
+// Tokenizer derives tokens from values with a secret key
+type Tokenizer struct {
+	key   []byte
+	vault Vault
+}
+
+// New creates a tokenizer, without a vault tokens cannot be reversed and only pseudonymize
+func New(key []byte, vault Vault) *Tokenizer {
+	return &Tokenizer{key: key, vault: vault}
+}
+
+// Token returns the token of the value within the domain, like "email" or "card". Domains keep
+// equal values of different kinds from sharing tokens.
+func (t *Tokenizer) Token(domain, value string, format Format) string {
+	// Count the digits to replace, the last KeepLast digits are kept
+	digits := 0
+	for _, r := range value {
+		if r >= '0' && r <= '9' {
+			digits++
+		}
+	}
+
+	// Values without digits to replace would be returned as is
+	if !format.Digits || digits == 0 {
+		return opaquePrefix + hex.EncodeToString(t.mac(domain, value, 0)[:16])
+	}
+	replace := digits - format.KeepLast
+	if replace <= 0 {
+		replace = digits
+	}
+
+	stream := newDigitStream(t, domain, value)
+	token := []byte(value)
+	for i, seen := 0, 0; i < len(token) && seen < replace; i++ {
+		if token[i] < '0' || token[i] > '9' {
+			continue
+		}
+		token[i] = stream.next()
+		seen++
+	}
+
+	return string(token)
+}
+
+// Tokenize returns the token of the value and stores the value in the vault
+func (t *Tokenizer) Tokenize(ctx context.Context, domain, value string, format Format) (string, error) {
+	if t.vault == nil {
+		return "", ErrNoVault
+	}
+
+	token := t.Token(domain, value, format)
+	if err := t.vault.Put(ctx, domain, token, value); err != nil {
+		return "", fmt.Errorf("failed to store token: %w", err)
+	}
+	return token, nil
+}
+
+// Detokenize returns the value of a token stored by Tokenize
+func (t *Tokenizer) Detokenize(ctx context.Context, domain, token string) (string, error) {
+	if t.vault == nil {
+		return "", ErrNoVault
+	}
+	return t.vault.Get(ctx, domain, token)
+}
+
+// mac returns the HMAC-SHA256 of the domain, the value and a block counter
+func (t *Tokenizer) mac(domain, value string, block uint32) []byte {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(domain))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	mac.Write(binary.BigEndian.AppendUint32(nil, block))
+	return mac.Sum(nil)
+}
+
+// digitStream derives uniformly distributed digits from the HMAC of a value
+type digitStream struct {
+	t      *Tokenizer
+	domain string
+	value  string
+	block  uint32
+	buf    []byte
+}
+
+func newDigitStream(t *Tokenizer, domain, value string) *digitStream {
+	return &digitStream{t: t, domain: domain, value: value}
+}
+
+// next returns the next digit, bytes of 250 and above are skipped to avoid a modulo bias
+func (s *digitStream) next() byte {
+	for {
+		if len(s.buf) == 0 {
+			s.buf = s.t.mac(s.domain, s.value, s.block)
+			s.block++
+		}
+		b := s.buf[0]
+		s.buf = s.buf[1:]
+		if b < 250 {
+			return '0' + b%10
+		}
+	}
+}
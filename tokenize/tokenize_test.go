@@ -0,0 +1,80 @@
+package tokenize
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenizer_Token(t *testing.T) {
+	tokenizer := New([]byte("secret"), nil)
+
+	tests := []struct {
+		name    string
+		value   string
+		format  Format
+		pattern string
+	}{
+		{name: "opaque", value: "alice@example.com", format: Opaque, pattern: `^tok_[0-9a-f]{32}$`},
+		{name: "digits keep separators", value: "+1 (555) 010-9999", format: Digits, pattern: `^\+\d \(\d{3}\) \d{3}-\d{4}$`},
+		{name: "card keeps last four", value: "4111-1111-1111-1234", format: CardNumber, pattern: `^\d{4}-\d{4}-\d{4}-1234$`},
+		{name: "short value replaced entirely", value: "123", format: CardNumber, pattern: `^\d{3}$`},
+		{name: "digits without digits", value: "n/a", format: Digits, pattern: `^tok_[0-9a-f]{32}$`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			token := tokenizer.Token("domain", tt.value, tt.format)
+
+			assert.Regexp(t, regexp.MustCompile(tt.pattern), token)
+			assert.NotEqual(t, tt.value, token)
+
+			// Tokens are deterministic
+			assert.Equal(t, token, tokenizer.Token("domain", tt.value, tt.format))
+		})
+	}
+}
+
+func TestTokenizer_TokenSeparation(t *testing.T) {
+	tokenizer := New([]byte("secret"), nil)
+	token := tokenizer.Token("email", "alice@example.com", Opaque)
+
+	assert.NotEqual(t, token, tokenizer.Token("username", "alice@example.com", Opaque))
+	assert.NotEqual(t, token, tokenizer.Token("email", "bob@example.com", Opaque))
+	assert.NotEqual(t, token, New([]byte("other"), nil).Token("email", "alice@example.com", Opaque))
+}
+
+func TestTokenizer_Vault(t *testing.T) {
+	ctx := context.Background()
+	tokenizer := New([]byte("secret"), NewMemoryVault())
+
+	token, err := tokenizer.Tokenize(ctx, "card", "4111111111111234", CardNumber)
+	require.NoError(t, err)
+	assert.Equal(t, tokenizer.Token("card", "4111111111111234", CardNumber), token)
+
+	value, err := tokenizer.Detokenize(ctx, "card", token)
+	require.NoError(t, err)
+	assert.Equal(t, "4111111111111234", value)
+
+	_, err = tokenizer.Detokenize(ctx, "email", token)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	pseudonymizer := New([]byte("secret"), nil)
+	_, err = pseudonymizer.Tokenize(ctx, "card", "4111111111111234", CardNumber)
+	assert.ErrorIs(t, err, ErrNoVault)
+	_, err = pseudonymizer.Detokenize(ctx, "card", token)
+	assert.ErrorIs(t, err, ErrNoVault)
+}
+
+func TestMemoryVault_Collision(t *testing.T) {
+	ctx := context.Background()
+	vault := NewMemoryVault()
+
+	require.NoError(t, vault.Put(ctx, "card", "1234", "5678"))
+	require.NoError(t, vault.Put(ctx, "card", "1234", "5678"))
+	assert.ErrorIs(t, vault.Put(ctx, "card", "1234", "9999"), ErrCollision)
+}
@@ -0,0 +1,116 @@
+package tokenize
+
+import (
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Redacted is logged for sensitive keys whose value has no string form to tokenize, like objects,
+// arrays, floats and times
+const Redacted = "[redacted]"
+
+// scrubCore tokenizes the fields of sensitive keys before writing them
+type scrubCore struct {
+	zapcore.Core
+	tokenizer *Tokenizer
+	keys      map[string]Format
+}
+
+// ScrubCore wraps a zap core so the fields with the keys are logged as their tokens, the key being
+// the domain. Strings, byte strings, Stringers, errors, integers and reflected values are
+// tokenized from their string form, other values are logged as Redacted. Logs stay correlatable
+// without holding the values:
+//
+//	logger := zap.New(tokenize.ScrubCore(core, tokenizer, map[string]tokenize.Format{
+//		"email": tokenize.Opaque,
+//		"card":  tokenize.CardNumber,
+//	}))
+func ScrubCore(core zapcore.Core, tokenizer *Tokenizer, keys map[string]Format) zapcore.Core {
+	return &scrubCore{Core: core, tokenizer: tokenizer, keys: keys}
+}
+
+// With adds scrubbed fields to the core
+func (c *scrubCore) With(fields []zapcore.Field) zapcore.Core {
+	return &scrubCore{Core: c.Core.With(c.scrub(fields)), tokenizer: c.tokenizer, keys: c.keys}
+}
+
+// Check adds the core to the entry when its level is enabled, so Write scrubs the entry
+func (c *scrubCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write writes the entry with scrubbed fields
+func (c *scrubCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.scrub(fields))
+}
+
+// scrub returns the fields with the values of sensitive keys tokenized, fields are copied so the
+// caller's are not modified
+func (c *scrubCore) scrub(fields []zapcore.Field) []zapcore.Field {
+	var scrubbed []zapcore.Field
+	for i, field := range fields {
+		format, ok := c.keys[field.Key]
+		if !ok || field.Type == zapcore.NamespaceType || field.Type == zapcore.SkipType {
+			continue
+		}
+
+		if scrubbed == nil {
+			scrubbed = append([]zapcore.Field(nil), fields...)
+		}
+		value, ok := fieldString(field)
+		if !ok {
+			scrubbed[i] = zap.String(field.Key, Redacted)
+			continue
+		}
+		scrubbed[i] = zap.String(field.Key, c.tokenizer.Token(field.Key, value, format))
+	}
+
+	if scrubbed == nil {
+		return fields
+	}
+	return scrubbed
+}
+
+// fieldString returns the string form of the field's value, false when it has none
+func fieldString(field zapcore.Field) (value string, ok bool) {
+	// Stringers and errors may panic, zap logs the panic in place of the value
+	defer func() {
+		if recover() != nil {
+			value, ok = "", false
+		}
+	}()
+
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String, true
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		b, ok := field.Interface.([]byte)
+		return string(b), ok
+	case zapcore.StringerType:
+		stringer, ok := field.Interface.(fmt.Stringer)
+		if !ok {
+			return "", false
+		}
+		return stringer.String(), true
+	case zapcore.ErrorType:
+		err, ok := field.Interface.(error)
+		if !ok {
+			return "", false
+		}
+		return err.Error(), true
+	case zapcore.Int64Type, zapcore.Int32Type, zapcore.Int16Type, zapcore.Int8Type:
+		return strconv.FormatInt(field.Integer, 10), true
+	case zapcore.Uint64Type, zapcore.Uint32Type, zapcore.Uint16Type, zapcore.Uint8Type, zapcore.UintptrType:
+		return strconv.FormatUint(uint64(field.Integer), 10), true
+	case zapcore.ReflectType:
+		return fmt.Sprint(field.Interface), true
+	default:
+		return "", false
+	}
+}
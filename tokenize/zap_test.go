@@ -0,0 +1,77 @@
+package tokenize
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestScrubCore(t *testing.T) {
+	tokenizer := New([]byte("secret"), nil)
+	core, logs := observer.New(zap.InfoLevel)
+	logger := zap.New(ScrubCore(core, tokenizer, map[string]Format{
+		"email": Opaque,
+		"card":  CardNumber,
+	}))
+
+	fields := []zap.Field{zap.String("card", "4111111111111234"), zap.Int("amount", 42)}
+	logger.With(zap.String("email", "alice@example.com")).Info("Payment accepted", fields...)
+	logger.Debug("Filtered", zap.String("email", "alice@example.com"))
+
+	entries := logs.All()
+	require.Len(t, entries, 1)
+	context := entries[0].ContextMap()
+
+	assert.Equal(t, tokenizer.Token("email", "alice@example.com", Opaque), context["email"])
+	assert.Equal(t, tokenizer.Token("card", "4111111111111234", CardNumber), context["card"])
+	assert.Equal(t, int64(42), context["amount"])
+
+	// The caller's fields are left untouched
+	assert.Equal(t, "4111111111111234", fields[0].String)
+}
+
+func TestScrubCore_FieldTypes(t *testing.T) {
+	tokenizer := New([]byte("secret"), nil)
+	token := func(value string) string { return tokenizer.Token("card", value, CardNumber) }
+
+	tests := []struct {
+		name  string
+		field zap.Field
+		want  string
+	}{
+		{name: "string", field: zap.String("card", "4111111111111234"), want: token("4111111111111234")},
+		{name: "any string", field: zap.Any("card", "4111111111111234"), want: token("4111111111111234")},
+		{name: "byte string", field: zap.ByteString("card", []byte("4111111111111234")), want: token("4111111111111234")},
+		{name: "binary", field: zap.Binary("card", []byte("4111111111111234")), want: token("4111111111111234")},
+		{name: "stringer", field: zap.Stringer("card", net.IPv4(10, 0, 0, 1)), want: token("10.0.0.1")},
+		{name: "error", field: zap.NamedError("card", errors.New("card 4111111111111234 declined")), want: token("card 4111111111111234 declined")},
+		{name: "int", field: zap.Int64("card", 4111111111111234), want: token("4111111111111234")},
+		{name: "uint", field: zap.Uint64("card", 4111111111111234), want: token("4111111111111234")},
+		{name: "reflect", field: zap.Reflect("card", struct{ Number string }{"4111111111111234"}), want: token("{4111111111111234}")},
+		{name: "any struct", field: zap.Any("card", struct{ Number string }{"4111111111111234"}), want: token("{4111111111111234}")},
+		{name: "array", field: zap.Strings("card", []string{"4111111111111234"}), want: Redacted},
+		{name: "float", field: zap.Float64("card", 4111111111111234), want: Redacted},
+		{name: "time", field: zap.Time("card", time.Unix(1700000000, 0)), want: Redacted},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			logger := zap.New(ScrubCore(core, tokenizer, map[string]Format{"card": CardNumber}))
+
+			logger.Info("Payment accepted", tt.field)
+
+			entries := logs.All()
+			require.Len(t, entries, 1)
+			assert.Equal(t, tt.want, entries[0].ContextMap()["card"])
+			assert.NotContains(t, tt.want, "4111111111111234")
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package totp
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/jjmaturino/bootstrapper/platform"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"go.uber.org/zap"
+)
+
+// Routes of the handlers mounted by Mount
+const (
+	EnrollPath  = "/mfa/totp/enroll"
+	ConfirmPath = "/mfa/totp/confirm"
+	VerifyPath  = "/mfa/totp/verify"
+)
+
+// Problem types returned by the handlers
+const (
+	// InvalidCodeProblemType is returned when a code does not verify
+	InvalidCodeProblemType = "urn:bootstrapper:problem:invalid-totp-code"
+
+	// NotEnrolledProblemType is returned when the subject has no confirmed enrollment
+	NotEnrolledProblemType = "urn:bootstrapper:problem:totp-not-enrolled"
+
+	// AlreadyEnrolledProblemType is returned when provisioning a subject already enrolled
+	AlreadyEnrolledProblemType = "urn:bootstrapper:problem:totp-already-enrolled"
+
+	// LockedOutProblemType is returned when codes are rejected after repeated failures
+	LockedOutProblemType = "urn:bootstrapper:problem:totp-locked-out"
+)
+
+// codeRequest is the body of the confirm and verify requests
+type codeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// Mount registers the enroll, confirm and verify handlers on the engine. They act on the subject
+// set by the authentication middleware with authz.SetSubject, which must run before them.
+func (a *Authenticator) Mount(engine platform.Engine, middleware ...gin.HandlerFunc) {
+	chain := func(handler gin.HandlerFunc) []gin.HandlerFunc {
+		return append(append([]gin.HandlerFunc(nil), middleware...), handler)
+	}
+
+	engine.Handle(http.MethodPost, EnrollPath, chain(a.EnrollHandler())...)
+	engine.Handle(http.MethodPost, ConfirmPath, chain(a.ConfirmHandler())...)
+	engine.Handle(http.MethodPost, VerifyPath, chain(a.VerifyHandler())...)
+}
+
+// EnrollHandler provisions a secret for the subject and responds with its Key
+func (a *Authenticator) EnrollHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, ok := authz.Subject(c)
+		if !ok {
+			authz.AbortWithProblem(c, authz.Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		key, err := a.Provision(c.Request.Context(), subject)
+		if err != nil {
+			a.abort(c, err)
+			return
+		}
+
+		c.Header("Cache-Control", "no-store")
+		c.JSON(http.StatusOK, key)
+	}
+}
+
+// ConfirmHandler confirms the enrollment of the subject with the code of the request body
+func (a *Authenticator) ConfirmHandler() gin.HandlerFunc {
+	return a.codeHandler(a.Confirm)
+}
+
+// VerifyHandler verifies the code of the request body, it responds 204 when it is valid
+func (a *Authenticator) VerifyHandler() gin.HandlerFunc {
+	return a.codeHandler(a.Verify)
+}
+
+// codeHandler checks the code of the request body with check
+func (a *Authenticator) codeHandler(check func(ctx context.Context, subject, code string) error) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		subject, ok := authz.Subject(c)
+		if !ok {
+			authz.AbortWithProblem(c, authz.Unauthenticated("no authenticated subject"))
+			return
+		}
+
+		var req codeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			a.abort(c, ErrInvalidCode)
+			return
+		}
+
+		if err := check(c.Request.Context(), subject, req.Code); err != nil {
+			a.abort(c, err)
+			return
+		}
+
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// abort responds with the problem of the error
+func (a *Authenticator) abort(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, ErrInvalidCode):
+		problem.Abort(c, problem.Problem{
+			Type:   InvalidCodeProblemType,
+			Title:  "Invalid code",
+			Status: http.StatusUnauthorized,
+			Detail: "the one-time code is invalid or was already used",
+		})
+	case errors.Is(err, ErrNotEnrolled):
+		problem.Abort(c, problem.Problem{
+			Type:   NotEnrolledProblemType,
+			Title:  "Not enrolled",
+			Status: http.StatusConflict,
+			Detail: "no confirmed one-time code enrollment",
+		})
+	case errors.Is(err, ErrAlreadyEnrolled):
+		problem.Abort(c, problem.Problem{
+			Type:   AlreadyEnrolledProblemType,
+			Title:  "Already enrolled",
+			Status: http.StatusConflict,
+			Detail: "one-time codes are already enrolled",
+		})
+	case errors.Is(err, ErrLockedOut):
+		var lockedOut *LockedOutError
+		if errors.As(err, &lockedOut) {
+			retryAfter := math.Ceil(lockedOut.Until.Sub(a.now()).Seconds())
+			c.Header("Retry-After", strconv.Itoa(int(math.Max(retryAfter, 1))))
+		}
+		problem.Abort(c, problem.Problem{
+			Type:   LockedOutProblemType,
+			Title:  "Locked out",
+			Status: http.StatusTooManyRequests,
+			Detail: "too many invalid one-time codes, retry later",
+		})
+	default:
+		a.logger.Error("TOTP request failed", zap.Error(err))
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}
+}
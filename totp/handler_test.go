@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/authz"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthenticator_Mount(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	a, now := newAuthenticator(t, Config{Issuer: "Acme"})
+	engine := gin.New()
+	a.Mount(engine, func(c *gin.Context) {
+		if subject := c.GetHeader("X-Subject"); subject != "" {
+			authz.SetSubject(c, subject)
+		}
+	})
+
+	post := func(path, subject, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if subject != "" {
+			req.Header.Set("X-Subject", subject)
+		}
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(EnrollPath, "", "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = post(EnrollPath, "alice", "")
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+	var key Key
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &key))
+
+	rec = post(ConfirmPath, "alice", `{"code":"000000"}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), InvalidCodeProblemType)
+
+	rec = post(ConfirmPath, "alice", `{"code":"`+codeAt(t, key.Secret, *now)+`"}`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = post(EnrollPath, "alice", "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), AlreadyEnrolledProblemType)
+
+	*now = now.Add(DefaultPeriod)
+	rec = post(VerifyPath, "alice", `{"code":"`+codeAt(t, key.Secret, *now)+`"}`)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = post(VerifyPath, "bob", `{"code":"123456"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), NotEnrolledProblemType)
+
+	rec = post(VerifyPath, "alice", `{}`)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	// Repeated failures lock the enrollment out
+	for i := 0; i < DefaultMaxFailures; i++ {
+		post(VerifyPath, "alice", `{"code":"`+codeAt(t, key.Secret, now.Add(time.Hour))+`"}`)
+	}
+	rec = post(VerifyPath, "alice", `{"code":"`+codeAt(t, key.Secret, *now)+`"}`)
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Contains(t, rec.Body.String(), LockedOutProblemType)
+	assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+}
@@ -0,0 +1,56 @@
+package totp
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryStore keeps enrollments in memory, for tests and development
+type MemoryStore struct {
+	// mu protects enrollments
+	mu          sync.Mutex
+	enrollments map[string]Enrollment
+}
+
+// NewMemoryStore creates an empty store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{enrollments: make(map[string]Enrollment)}
+}
+
+// Get returns the enrollment of the subject
+func (s *MemoryStore) Get(ctx context.Context, subject string) (Enrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollment, ok := s.enrollments[subject]
+	if !ok {
+		return Enrollment{}, ErrNotFound
+	}
+	return enrollment, nil
+}
+
+// Save creates or replaces the enrollment of the subject
+func (s *MemoryStore) Save(ctx context.Context, subject string, enrollment Enrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enrollments[subject] = enrollment
+	return nil
+}
+
+// Update applies fn to the enrollment of the subject under the store's lock
+func (s *MemoryStore) Update(ctx context.Context, subject string, fn func(*Enrollment) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	enrollment, ok := s.enrollments[subject]
+	if !ok {
+		return ErrNotFound
+	}
+	if err := fn(&enrollment); err != nil {
+		return err
+	}
+	s.enrollments[subject] = enrollment
+	return nil
+}
+
+var _ Store = (*MemoryStore)(nil)
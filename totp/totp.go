@@ -0,0 +1,379 @@
+// Package totp provides time-based one-time passwords (RFC 6238) as a second authentication
+// factor: secret provisioning with an otpauth:// URI for authenticator apps, enrollment
+// confirmation and code verification with replay protection and a lockout after repeated
+// failures.
+package totp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Defaults of Config, the values authenticator apps assume
+const (
+	DefaultDigits = 6
+	DefaultPeriod = 30 * time.Second
+	DefaultSkew   = 1
+
+	DefaultMaxFailures = 5
+	DefaultLockout     = time.Minute
+)
+
+// maxLockout caps the lockout, which doubles with each failure past MaxFailures
+const maxLockout = 24 * time.Hour
+
+// secretSize is the size of generated secrets, the HMAC-SHA1 block recommended by RFC 4226
+const secretSize = 20
+
+// Verification errors
+var (
+	ErrNotEnrolled     = errors.New("totp not enrolled")
+	ErrAlreadyEnrolled = errors.New("totp already enrolled")
+	ErrInvalidCode     = errors.New("invalid totp code")
+	ErrLockedOut       = errors.New("totp locked out")
+)
+
+// LockedOutError is returned while an enrollment is locked out, it matches ErrLockedOut
+type LockedOutError struct {
+	// Until is when codes are checked again
+	Until time.Time
+}
+
+// Error describes the lockout
+func (e *LockedOutError) Error() string {
+	return fmt.Sprintf("totp locked out until %s", e.Until.Format(time.RFC3339))
+}
+
+// Is reports whether target is ErrLockedOut
+func (e *LockedOutError) Is(target error) bool {
+	return target == ErrLockedOut
+}
+
+// ErrNotFound is returned by stores for subjects without enrollment
+var ErrNotFound = errors.New("totp enrollment not found")
+
+// encoding is the unpadded base32 used by authenticator apps
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Config configures an Authenticator, zero values take the defaults
+type Config struct {
+	// Issuer names the service in authenticator apps
+	Issuer string
+
+	// Digits of the codes
+	Digits int
+
+	// Period during which a code is valid
+	Period time.Duration
+
+	// Skew is the number of periods accepted before and after the current one, for clock drift,
+	// none when negative
+	Skew int
+
+	// MaxFailures is the number of consecutive invalid codes after which the enrollment is
+	// locked out, as RFC 6238 section 5.2 requires to limit brute forcing
+	MaxFailures int
+
+	// Lockout is how long codes are rejected after MaxFailures, it doubles with each further
+	// failure up to a day
+	Lockout time.Duration
+}
+
+// Enrollment is the stored TOTP state of a subject
+type Enrollment struct {
+	// Secret is the base32 encoded shared secret
+	Secret string
+
+	// Confirmed is set once the subject proved their app generates valid codes
+	Confirmed bool
+
+	// LastCounter is the time step of the last accepted code, codes of earlier or equal steps are
+	// rejected so a code cannot be replayed
+	LastCounter int64
+
+	// Failures counts the consecutive invalid codes, reset by a valid one
+	Failures int
+
+	// LockedUntil is when codes are checked again after too many failures
+	LockedUntil time.Time
+}
+
+// Store persists enrollments, secrets should be encrypted at rest
+type Store interface {
+	// Get returns the enrollment of the subject, ErrNotFound when there is none
+	Get(ctx context.Context, subject string) (Enrollment, error)
+
+	// Save creates or replaces the enrollment of the subject
+	Save(ctx context.Context, subject string, enrollment Enrollment) error
+
+	// Update atomically applies fn to the enrollment of the subject and saves the result, no other
+	// update or save of the subject may interleave. Nothing is saved when fn fails, its error is
+	// returned. It returns ErrNotFound when there is no enrollment.
+	Update(ctx context.Context, subject string, fn func(*Enrollment) error) error
+}
+
+// Key is a provisioned secret to show to the subject, as a QR code of the URI or as text
+type Key struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// Authenticator provisions and verifies TOTP codes
+type Authenticator struct {
+	cfg    Config
+	store  Store
+	logger *zap.Logger
+
+	// now returns the current time, it is replaced in tests
+	now func() time.Time
+}
+
+// New creates an authenticator storing enrollments in the store
+func New(cfg Config, store Store, logger *zap.Logger) *Authenticator {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if cfg.Digits <= 0 {
+		cfg.Digits = DefaultDigits
+	}
+	if cfg.Period <= 0 {
+		cfg.Period = DefaultPeriod
+	}
+	if cfg.Skew < 0 {
+		cfg.Skew = 0
+	} else if cfg.Skew == 0 {
+		cfg.Skew = DefaultSkew
+	}
+	if cfg.MaxFailures <= 0 {
+		cfg.MaxFailures = DefaultMaxFailures
+	}
+	if cfg.Lockout <= 0 {
+		cfg.Lockout = DefaultLockout
+	}
+
+	return &Authenticator{cfg: cfg, store: store, logger: logger, now: time.Now}
+}
+
+// Provision generates a new secret for a subject without a confirmed enrollment, replacing an
+// unconfirmed one. The secret is only used for verification after Confirm.
+func (a *Authenticator) Provision(ctx context.Context, subject string) (Key, error) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		return Key{}, err
+	}
+
+	// Replace an unconfirmed enrollment atomically, so a concurrent Confirm is never overwritten
+	err = a.store.Update(ctx, subject, func(enrollment *Enrollment) error {
+		if enrollment.Confirmed {
+			// A stolen session must not be able to replace the second factor
+			return ErrAlreadyEnrolled
+		}
+		*enrollment = Enrollment{Secret: secret}
+		return nil
+	})
+	if errors.Is(err, ErrNotFound) {
+		err = a.store.Save(ctx, subject, Enrollment{Secret: secret})
+	}
+	if errors.Is(err, ErrAlreadyEnrolled) {
+		return Key{}, err
+	}
+	if err != nil {
+		return Key{}, fmt.Errorf("failed to save totp enrollment: %w", err)
+	}
+
+	return Key{Secret: secret, URI: a.uri(subject, secret)}, nil
+}
+
+// Confirm completes the enrollment of the subject with a code from their app
+func (a *Authenticator) Confirm(ctx context.Context, subject, code string) error {
+	err := a.update(ctx, subject, func(enrollment *Enrollment) error {
+		if err := a.check(subject, enrollment, code); err != nil {
+			return err
+		}
+		enrollment.Confirmed = true
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	a.logger.Info("Confirmed TOTP enrollment", zap.String("subject", subject))
+	return nil
+}
+
+// Verify checks a code of a confirmed enrollment, each code is accepted once. After MaxFailures
+// consecutive invalid codes it returns a LockedOutError without checking the code.
+func (a *Authenticator) Verify(ctx context.Context, subject, code string) error {
+	return a.update(ctx, subject, func(enrollment *Enrollment) error {
+		if !enrollment.Confirmed {
+			return ErrNotEnrolled
+		}
+		return a.check(subject, enrollment, code)
+	})
+}
+
+// update applies fn to the enrollment of the subject in one atomic store update, so concurrent
+// checks cannot accept the same code twice or lose failures. The enrollment is saved when fn
+// succeeds or the code was invalid, as the failure must be recorded.
+func (a *Authenticator) update(ctx context.Context, subject string, fn func(*Enrollment) error) error {
+	var result error
+	err := a.store.Update(ctx, subject, func(enrollment *Enrollment) error {
+		result = fn(enrollment)
+		if errors.Is(result, ErrInvalidCode) {
+			return nil
+		}
+		return result
+	})
+	if errors.Is(err, ErrNotFound) {
+		return ErrNotEnrolled
+	}
+	if err != nil && (result == nil || errors.Is(result, ErrInvalidCode)) {
+		// The store failed, not the check
+		return fmt.Errorf("failed to update totp enrollment: %w", err)
+	}
+
+	return result
+}
+
+// check validates the code unless the enrollment is locked out. A valid code moves the last
+// counter and resets the failures, an invalid one is recorded as a failure and locks the
+// enrollment out once MaxFailures is reached.
+func (a *Authenticator) check(subject string, enrollment *Enrollment, code string) error {
+	now := a.now()
+	if now.Before(enrollment.LockedUntil) {
+		return &LockedOutError{Until: enrollment.LockedUntil}
+	}
+
+	if counter, ok := a.validate(*enrollment, code); ok {
+		enrollment.LastCounter = counter
+		enrollment.Failures = 0
+		enrollment.LockedUntil = time.Time{}
+		return nil
+	}
+
+	enrollment.Failures++
+	if excess := enrollment.Failures - a.cfg.MaxFailures; excess >= 0 {
+		lockout := a.cfg.Lockout
+		for i := 0; i < excess && lockout < maxLockout; i++ {
+			lockout *= 2
+		}
+		if lockout > maxLockout {
+			lockout = maxLockout
+		}
+		enrollment.LockedUntil = now.Add(lockout)
+		a.logger.Warn("Locked out TOTP enrollment after repeated failures",
+			zap.String("subject", subject),
+			zap.Int("failures", enrollment.Failures),
+			zap.Time("until", enrollment.LockedUntil))
+	}
+
+	return ErrInvalidCode
+}
+
+// validate returns the time step matching the code within the skew, codes of steps already used
+// are rejected
+func (a *Authenticator) validate(enrollment Enrollment, code string) (int64, bool) {
+	secret, err := decodeSecret(enrollment.Secret)
+	if err != nil || len(code) != a.cfg.Digits {
+		return 0, false
+	}
+
+	current := a.now().Unix() / int64(a.cfg.Period/time.Second)
+	for step := current - int64(a.cfg.Skew); step <= current+int64(a.cfg.Skew); step++ {
+		if step <= enrollment.LastCounter {
+			continue
+		}
+		expected := hotp(secret, step, a.cfg.Digits)
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// uri returns the otpauth:// URI of the key, understood by authenticator apps
+func (a *Authenticator) uri(subject, secret string) string {
+	label := subject
+	if a.cfg.Issuer != "" {
+		label = a.cfg.Issuer + ":" + subject
+	}
+
+	query := url.Values{}
+	query.Set("secret", secret)
+	if a.cfg.Issuer != "" {
+		query.Set("issuer", a.cfg.Issuer)
+	}
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(a.cfg.Digits))
+	query.Set("period", fmt.Sprint(int(a.cfg.Period/time.Second)))
+
+	u := url.URL{Scheme: "otpauth", Host: "totp", Path: "/" + label, RawQuery: query.Encode()}
+	return u.String()
+}
+
+// GenerateSecret returns a random base32 encoded secret
+func GenerateSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return encoding.EncodeToString(secret), nil
+}
+
+// Code returns the code of the secret at t, for tests and tooling
+func Code(secret string, t time.Time, digits int, period time.Duration) (string, error) {
+	key, err := decodeSecret(secret)
+	if err != nil {
+		return "", err
+	}
+	return hotp(key, t.Unix()/int64(period/time.Second), digits), nil
+}
+
+// decodeSecret decodes a base32 secret, ignoring case, spaces and padding
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.ReplaceAll(secret, " ", ""))
+	key, err := encoding.DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return nil, fmt.Errorf("invalid totp secret: %w", err)
+	}
+	return key, nil
+}
+
+// hotp computes the RFC 4226 code of the counter
+func hotp(key []byte, counter int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(counter))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
@@ -0,0 +1,272 @@
+package totp
+
+import (
+	"context"
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "94287082"},
+		{unix: 1111111109, code: "07081804"},
+		{unix: 1234567890, code: "89005924"},
+		{unix: 20000000000, code: "65353130"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.code, func(t *testing.T) {
+			code, err := Code(secret, time.Unix(tt.unix, 0), 8, 30*time.Second)
+			require.NoError(t, err)
+			assert.Equal(t, tt.code, code)
+		})
+	}
+}
+
+// newAuthenticator returns an authenticator whose clock is at the returned pointer
+func newAuthenticator(t *testing.T, cfg Config) (*Authenticator, *time.Time) {
+	a := New(cfg, NewMemoryStore(), zaptest.NewLogger(t))
+	now := time.Unix(1700000000, 0)
+	a.now = func() time.Time { return now }
+	return a, &now
+}
+
+func codeAt(t *testing.T, secret string, at time.Time) string {
+	code, err := Code(secret, at, DefaultDigits, DefaultPeriod)
+	require.NoError(t, err)
+	return code
+}
+
+func TestAuthenticator_Enrollment(t *testing.T) {
+	ctx := context.Background()
+	a, now := newAuthenticator(t, Config{Issuer: "Acme"})
+
+	key, err := a.Provision(ctx, "alice@example.com")
+	require.NoError(t, err)
+
+	uri, err := url.Parse(key.URI)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Acme:alice@example.com", uri.Path)
+	assert.Equal(t, key.Secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Acme", uri.Query().Get("issuer"))
+
+	// Codes are not accepted before the enrollment is confirmed
+	assert.ErrorIs(t, a.Verify(ctx, "alice@example.com", codeAt(t, key.Secret, *now)), ErrNotEnrolled)
+
+	assert.ErrorIs(t, a.Confirm(ctx, "alice@example.com", "000000"), ErrInvalidCode)
+	require.NoError(t, a.Confirm(ctx, "alice@example.com", codeAt(t, key.Secret, *now)))
+
+	_, err = a.Provision(ctx, "alice@example.com")
+	assert.ErrorIs(t, err, ErrAlreadyEnrolled)
+
+	*now = now.Add(DefaultPeriod)
+	assert.NoError(t, a.Verify(ctx, "alice@example.com", codeAt(t, key.Secret, *now)))
+
+	assert.ErrorIs(t, a.Verify(ctx, "bob@example.com", "123456"), ErrNotEnrolled)
+}
+
+func TestAuthenticator_VerifyRejectsReplay(t *testing.T) {
+	ctx := context.Background()
+	a, now := newAuthenticator(t, Config{})
+
+	key, err := a.Provision(ctx, "alice")
+	require.NoError(t, err)
+	require.NoError(t, a.Confirm(ctx, "alice", codeAt(t, key.Secret, *now)))
+
+	*now = now.Add(2 * DefaultPeriod)
+	code := codeAt(t, key.Secret, *now)
+	require.NoError(t, a.Verify(ctx, "alice", code))
+	assert.ErrorIs(t, a.Verify(ctx, "alice", code), ErrInvalidCode)
+
+	// A code of an earlier step is rejected once a later one was used
+	assert.ErrorIs(t, a.Verify(ctx, "alice", codeAt(t, key.Secret, now.Add(-DefaultPeriod))), ErrInvalidCode)
+}
+
+func TestAuthenticator_Lockout(t *testing.T) {
+	ctx := context.Background()
+	a, now := newAuthenticator(t, Config{MaxFailures: 3, Lockout: time.Minute})
+
+	key, err := a.Provision(ctx, "alice")
+	require.NoError(t, err)
+	require.NoError(t, a.Confirm(ctx, "alice", codeAt(t, key.Secret, *now)))
+
+	// A code far outside the skew is invalid
+	wrong := func() string { return codeAt(t, key.Secret, now.Add(time.Hour)) }
+
+	for i := 0; i < 3; i++ {
+		assert.ErrorIs(t, a.Verify(ctx, "alice", wrong()), ErrInvalidCode)
+	}
+
+	// Valid codes are rejected during the lockout
+	*now = now.Add(DefaultPeriod)
+	err = a.Verify(ctx, "alice", codeAt(t, key.Secret, *now))
+	var lockedOut *LockedOutError
+	require.ErrorAs(t, err, &lockedOut)
+	assert.ErrorIs(t, err, ErrLockedOut)
+	assert.Equal(t, now.Add(-DefaultPeriod).Add(time.Minute), lockedOut.Until)
+
+	// Each failure past the maximum doubles the lockout
+	*now = lockedOut.Until
+	assert.ErrorIs(t, a.Verify(ctx, "alice", wrong()), ErrInvalidCode)
+	enrollment, err := a.store.Get(ctx, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, 4, enrollment.Failures)
+	assert.Equal(t, now.Add(2*time.Minute), enrollment.LockedUntil)
+
+	// A valid code after the lockout resets the failures
+	*now = enrollment.LockedUntil
+	require.NoError(t, a.Verify(ctx, "alice", codeAt(t, key.Secret, *now)))
+	enrollment, err = a.store.Get(ctx, "alice")
+	require.NoError(t, err)
+	assert.Zero(t, enrollment.Failures)
+	assert.True(t, enrollment.LockedUntil.IsZero())
+}
+
+// slowStore widens the window between reading and saving an enrollment
+type slowStore struct {
+	*MemoryStore
+}
+
+func (s slowStore) Get(ctx context.Context, subject string) (Enrollment, error) {
+	enrollment, err := s.MemoryStore.Get(ctx, subject)
+	time.Sleep(time.Millisecond)
+	return enrollment, err
+}
+
+func TestAuthenticator_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	a, now := newAuthenticator(t, Config{MaxFailures: 5})
+	a.store = slowStore{NewMemoryStore()}
+
+	keys := make(map[string]Key)
+	for _, subject := range []string{"alice", "bob"} {
+		key, err := a.Provision(ctx, subject)
+		require.NoError(t, err)
+		require.NoError(t, a.Confirm(ctx, subject, codeAt(t, key.Secret, *now)))
+		keys[subject] = key
+	}
+	*now = now.Add(DefaultPeriod)
+
+	// run verifies the code of the subject from parallel requests and returns their errors
+	run := func(subject, code string) []error {
+		errs := make([]error, 20)
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = a.Verify(ctx, subject, code)
+			}(i)
+		}
+		wg.Wait()
+		return errs
+	}
+
+	// A code is accepted once however many requests race with it
+	var accepted int
+	for _, err := range run("alice", codeAt(t, keys["alice"].Secret, *now)) {
+		if err == nil {
+			accepted++
+		} else if !errors.Is(err, ErrLockedOut) {
+			// Replays count as failures, enough of them lock the enrollment out
+			assert.ErrorIs(t, err, ErrInvalidCode)
+		}
+	}
+	assert.Equal(t, 1, accepted)
+
+	// Parallel guesses are all counted, past the maximum they are rejected unchecked
+	var invalid, lockedOut int
+	for _, err := range run("bob", codeAt(t, keys["bob"].Secret, now.Add(time.Hour))) {
+		switch {
+		case errors.Is(err, ErrInvalidCode):
+			invalid++
+		case errors.Is(err, ErrLockedOut):
+			lockedOut++
+		}
+	}
+	assert.Equal(t, 5, invalid)
+	assert.Equal(t, 15, lockedOut)
+
+	enrollment, err := a.store.Get(ctx, "bob")
+	require.NoError(t, err)
+	assert.Equal(t, 5, enrollment.Failures)
+}
+
+func TestAuthenticator_ConfirmLockout(t *testing.T) {
+	ctx := context.Background()
+	a, now := newAuthenticator(t, Config{MaxFailures: 1})
+
+	key, err := a.Provision(ctx, "alice")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, a.Confirm(ctx, "alice", codeAt(t, key.Secret, now.Add(time.Hour))), ErrInvalidCode)
+	assert.ErrorIs(t, a.Confirm(ctx, "alice", codeAt(t, key.Secret, *now)), ErrLockedOut)
+
+	*now = now.Add(DefaultLockout)
+	assert.NoError(t, a.Confirm(ctx, "alice", codeAt(t, key.Secret, *now)))
+}
+
+func TestAuthenticator_Skew(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		skew   int
+		offset time.Duration
+		valid  bool
+	}{
+		{name: "previous step within default skew", offset: -DefaultPeriod, valid: true},
+		{name: "next step within default skew", offset: DefaultPeriod, valid: true},
+		{name: "outside default skew", offset: 2 * DefaultPeriod, valid: false},
+		{name: "no skew", skew: -1, offset: DefaultPeriod, valid: false},
+		{name: "wider skew", skew: 2, offset: -2 * DefaultPeriod, valid: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			a, now := newAuthenticator(t, Config{Skew: tt.skew})
+			key, err := a.Provision(ctx, "alice")
+			require.NoError(t, err)
+
+			err = a.Confirm(ctx, "alice", codeAt(t, key.Secret, now.Add(tt.offset)))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrInvalidCode)
+			}
+		})
+	}
+}
+
+func TestDecodeSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+
+	key, err := decodeSecret(secret)
+	require.NoError(t, err)
+	assert.Len(t, key, secretSize)
+
+	// Secrets typed by hand are lower case and grouped
+	_, err = decodeSecret("jbsw y3dp ehpk 3pxp")
+	assert.NoError(t, err)
+
+	_, err = decodeSecret("not base32!")
+	assert.Error(t, err)
+}
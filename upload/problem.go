@@ -0,0 +1,59 @@
+package upload
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jjmaturino/bootstrapper/problem"
+)
+
+// Problem types returned by the upload middleware
+const (
+	// UnsupportedMediaTypeProblemType is returned when an upload's media type is rejected
+	UnsupportedMediaTypeProblemType = "urn:bootstrapper:problem:unsupported-media-type"
+
+	// UploadTooLargeProblemType is returned when an upload exceeds the route's size limit
+	UploadTooLargeProblemType = "urn:bootstrapper:problem:upload-too-large"
+
+	// InvalidUploadProblemType is returned when an upload cannot be read
+	InvalidUploadProblemType = "urn:bootstrapper:problem:invalid-upload"
+)
+
+// Problem is an RFC 7807 problem details body describing a rejected upload
+type Problem struct {
+	problem.Problem
+
+	// Field is the multipart field of the rejected file, empty for non-multipart bodies
+	Field string `json:"field,omitempty"`
+
+	// DeclaredType is the media type announced by the client
+	DeclaredType string `json:"declaredType,omitempty"`
+
+	// DetectedType is the media type detected from the content
+	DetectedType string `json:"detectedType,omitempty"`
+}
+
+// UnsupportedMediaType creates the problem returned when an upload's media type is rejected
+func UnsupportedMediaType(field, declared, detected, detail string) Problem {
+	return Problem{
+		Problem: problem.Problem{
+			Type:   UnsupportedMediaTypeProblemType,
+			Title:  "Unsupported media type",
+			Status: http.StatusUnsupportedMediaType,
+			Detail: detail,
+		},
+		Field:        field,
+		DeclaredType: declared,
+		DetectedType: detected,
+	}
+}
+
+// TooLarge creates the problem returned when an upload exceeds the size limit
+func TooLarge(limit int64) Problem {
+	return Problem{Problem: problem.Problem{
+		Type:   UploadTooLargeProblemType,
+		Title:  "Upload too large",
+		Status: http.StatusRequestEntityTooLarge,
+		Detail: fmt.Sprintf("upload exceeds %d bytes", limit),
+	}}
+}
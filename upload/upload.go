@@ -0,0 +1,210 @@
+package upload
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+)
+
+// sniffLen is the number of leading bytes used to detect the content type
+const sniffLen = 512
+
+// DefaultMaxMemory is the part of a multipart body kept in memory when parsing, the rest is
+// stored in temporary files
+const DefaultMaxMemory = 32 << 20
+
+// Policy describes the uploads accepted by a route
+type Policy struct {
+	// AllowedTypes lists accepted media types, a "type/*" entry accepts every subtype
+	AllowedTypes []string `json:"allowedTypes" yaml:"allowedTypes"`
+
+	// MaxBytes caps the request body when non-zero
+	MaxBytes int64 `json:"maxBytes" yaml:"maxBytes"`
+
+	// MaxMemory is the multipart memory budget, it defaults to DefaultMaxMemory
+	MaxMemory int64 `json:"maxMemory" yaml:"maxMemory"`
+
+	// DetectedAs maps a declared media type to the types its content may be detected as, in
+	// addition to DefaultDetectedAs
+	DetectedAs map[string][]string `json:"detectedAs" yaml:"detectedAs"`
+}
+
+// DefaultDetectedAs maps media types that http.DetectContentType does not recognise to the types
+// their content is detected as, so uploads declaring them are not rejected as mismatched
+var DefaultDetectedAs = map[string][]string{
+	"text/csv":         {"text/plain"},
+	"text/markdown":    {"text/plain"},
+	"application/json": {"text/plain"},
+	"application/xml":  {"text/xml", "text/plain"},
+	"image/svg+xml":    {"text/xml", "text/plain"},
+	"application/vnd.openxmlformats-officedocument.wordprocessingml.document":   {"application/zip"},
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet":         {"application/zip"},
+	"application/vnd.openxmlformats-officedocument.presentationml.presentation": {"application/zip"},
+}
+
+// Allows reports whether the media type is accepted by the policy
+func (p Policy) Allows(mediaType string) bool {
+	for _, allowed := range p.AllowedTypes {
+		if allowed == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(allowed, "/*"); ok && strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Matches reports whether content detected as the detected media type matches the declared one
+func (p Policy) Matches(declared, detected string) bool {
+	if declared == detected {
+		return true
+	}
+
+	for _, equivalents := range [][]string{p.DetectedAs[declared], DefaultDetectedAs[declared]} {
+		for _, equivalent := range equivalents {
+			if equivalent == detected {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Middleware returns middleware rejecting uploads whose declared or detected media type is not
+// allowed, or whose content does not match the declared type. Multipart requests are checked
+// file by file and remain available to handlers through c.FormFile; other requests are checked
+// on their body. Install it on the routes accepting uploads, each with its own policy.
+func Middleware(policy Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if policy.MaxBytes > 0 {
+			c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, policy.MaxBytes)
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err != nil {
+			problem.Abort(c, UnsupportedMediaType("", "", "", "missing or invalid Content-Type"))
+			return
+		}
+
+		if mediaType == "multipart/form-data" {
+			validateMultipart(c, policy)
+			return
+		}
+
+		validateBody(c, policy, mediaType)
+	}
+}
+
+// validateMultipart checks every file part of a multipart request
+func validateMultipart(c *gin.Context, policy Policy) {
+	maxMemory := policy.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+
+	if err := c.Request.ParseMultipartForm(maxMemory); err != nil {
+		abortWithBodyError(c, err)
+		return
+	}
+
+	for field, files := range c.Request.MultipartForm.File {
+		for _, file := range files {
+			if rejection, ok := checkFile(policy, field, file); !ok {
+				problem.Abort(c, rejection)
+				return
+			}
+		}
+	}
+
+	c.Next()
+}
+
+// checkFile verifies a multipart file against the policy
+func checkFile(policy Policy, field string, file *multipart.FileHeader) (Problem, bool) {
+	f, err := file.Open()
+	if err != nil {
+		return UnsupportedMediaType(field, "", "", "file could not be read"), false
+	}
+	defer f.Close()
+
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return UnsupportedMediaType(field, "", "", "file could not be read"), false
+	}
+
+	declared, _, _ := mime.ParseMediaType(file.Header.Get("Content-Type"))
+	return check(policy, field, declared, head[:n])
+}
+
+// validateBody checks a non-multipart body, restoring it for the handler
+func validateBody(c *gin.Context, policy Policy, declared string) {
+	head := make([]byte, sniffLen)
+	n, err := io.ReadFull(c.Request.Body, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		abortWithBodyError(c, err)
+		return
+	}
+	head = head[:n]
+
+	if rejection, ok := check(policy, "", declared, head); !ok {
+		problem.Abort(c, rejection)
+		return
+	}
+
+	c.Request.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(head), c.Request.Body), Closer: c.Request.Body}
+	c.Next()
+}
+
+// check verifies the declared type is allowed and matches the type detected from the content,
+// or one of its equivalents
+func check(policy Policy, field, declared string, head []byte) (Problem, bool) {
+	detected, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+
+	// Content detected as an equivalent of the declared type, like a CSV detected as text/plain,
+	// is checked against the declared type only
+	switch {
+	case !policy.Allows(declared):
+		return UnsupportedMediaType(field, declared, detected, fmt.Sprintf("media type %q is not allowed", declared)), false
+	case policy.Matches(declared, detected):
+	case !policy.Allows(detected):
+		return UnsupportedMediaType(field, declared, detected, fmt.Sprintf("content detected as %q is not allowed", detected)), false
+	default:
+		return UnsupportedMediaType(field, declared, detected, fmt.Sprintf("content detected as %q does not match declared %q", detected, declared)), false
+	}
+
+	return Problem{}, true
+}
+
+// abortWithBodyError rejects a body that could not be read, with 413 when it exceeds the limit
+func abortWithBodyError(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		problem.Abort(c, TooLarge(maxBytesErr.Limit))
+		return
+	}
+
+	problem.Abort(c, Problem{Problem: problem.Problem{
+		Type:   InvalidUploadProblemType,
+		Title:  "Invalid upload",
+		Status: http.StatusBadRequest,
+		Detail: "request body could not be read",
+	}})
+}
+
+// readCloser restores a partially read body while closing the original one
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,191 @@
+package upload
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jjmaturino/bootstrapper/problem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pngBytes = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01")
+
+var pdfBytes = []byte("%PDF-1.7\n1 0 obj\n")
+
+var imagePolicy = Policy{AllowedTypes: []string{"image/*"}, MaxBytes: 1 << 10}
+
+func newTestEngine(policy Policy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.POST("/raw", Middleware(policy), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.Data(http.StatusOK, "application/octet-stream", body)
+	})
+	engine.POST("/form", Middleware(policy), func(c *gin.Context) {
+		file, err := c.FormFile("avatar")
+		if err != nil {
+			c.Status(http.StatusBadRequest)
+			return
+		}
+		c.String(http.StatusOK, file.Filename)
+	})
+	return engine
+}
+
+func multipartBody(t *testing.T, contentType string, content []byte) (*bytes.Buffer, string) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	header := textproto.MIMEHeader{}
+	header.Set("Content-Disposition", `form-data; name="avatar"; filename="avatar.png"`)
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.NoError(t, err)
+	_, err = part.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	return &body, writer.FormDataContentType()
+}
+
+func TestMiddleware_Raw(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+		wantProblem string
+	}{
+		{name: "allowed image", contentType: "image/png", body: pngBytes, wantStatus: http.StatusOK},
+		{name: "declared type not allowed", contentType: "application/pdf", body: pdfBytes, wantStatus: http.StatusUnsupportedMediaType, wantProblem: UnsupportedMediaTypeProblemType},
+		{name: "content not allowed", contentType: "image/png", body: pdfBytes, wantStatus: http.StatusUnsupportedMediaType, wantProblem: UnsupportedMediaTypeProblemType},
+		{name: "content does not match", contentType: "image/gif", body: pngBytes, wantStatus: http.StatusUnsupportedMediaType, wantProblem: UnsupportedMediaTypeProblemType},
+		{name: "missing content type", body: pngBytes, wantStatus: http.StatusUnsupportedMediaType, wantProblem: UnsupportedMediaTypeProblemType},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/raw", bytes.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			newTestEngine(imagePolicy).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantProblem != "" {
+				assert.Equal(t, problem.ContentType, rec.Header().Get("Content-Type"))
+
+				var problem Problem
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+				assert.Equal(t, tt.wantProblem, problem.Type)
+				return
+			}
+
+			// The handler reads the whole body, including the sniffed bytes
+			assert.Equal(t, tt.body, rec.Body.Bytes())
+		})
+	}
+}
+
+func TestMiddleware_Multipart(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		content      []byte
+		wantStatus   int
+		wantProblem  string
+		wantDetected string
+	}{
+		{name: "allowed image", contentType: "image/png", content: pngBytes, wantStatus: http.StatusOK},
+		{name: "spoofed image", contentType: "image/png", content: pdfBytes, wantStatus: http.StatusUnsupportedMediaType, wantProblem: UnsupportedMediaTypeProblemType, wantDetected: "application/pdf"},
+		{name: "too large", contentType: "image/png", content: append(append([]byte(nil), pngBytes...), make([]byte, 2<<10)...), wantStatus: http.StatusRequestEntityTooLarge, wantProblem: UploadTooLargeProblemType},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			body, contentType := multipartBody(t, tt.contentType, tt.content)
+			req := httptest.NewRequest(http.MethodPost, "/form", body)
+			req.Header.Set("Content-Type", contentType)
+			rec := httptest.NewRecorder()
+			newTestEngine(imagePolicy).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			if tt.wantProblem == "" {
+				assert.Equal(t, "avatar.png", rec.Body.String())
+				return
+			}
+
+			var problem Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantProblem, problem.Type)
+			assert.Equal(t, tt.wantDetected, problem.DetectedType)
+		})
+	}
+}
+
+func TestPolicy_Allows(t *testing.T) {
+	policy := Policy{AllowedTypes: []string{"image/*", "application/pdf"}}
+
+	assert.True(t, policy.Allows("image/png"))
+	assert.True(t, policy.Allows("application/pdf"))
+	assert.False(t, policy.Allows("application/zip"))
+	assert.False(t, policy.Allows("imagex/png"))
+}
+
+func TestMiddleware_DetectedAs(t *testing.T) {
+	zipBytes := []byte("PK\x03\x04\x14\x00\x06\x00\x08\x00\x00\x00!\x00")
+	const docx = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+
+	policy := Policy{
+		AllowedTypes: []string{"text/csv", "image/svg+xml", docx, "application/x-ndjson"},
+		DetectedAs:   map[string][]string{"application/x-ndjson": {"text/plain"}},
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		wantStatus  int
+	}{
+		{name: "csv detected as text", contentType: "text/csv", body: []byte("id,name\n1,ada\n"), wantStatus: http.StatusOK},
+		{name: "svg detected as xml", contentType: "image/svg+xml", body: []byte(`<?xml version="1.0"?><svg xmlns="http://www.w3.org/2000/svg"/>`), wantStatus: http.StatusOK},
+		{name: "docx detected as zip", contentType: docx, body: zipBytes, wantStatus: http.StatusOK},
+		{name: "policy equivalent", contentType: "application/x-ndjson", body: []byte(`{"id":1}` + "\n"), wantStatus: http.StatusOK},
+		{name: "csv with binary content", contentType: "text/csv", body: pdfBytes, wantStatus: http.StatusUnsupportedMediaType},
+	}
+
+	engine := newTestEngine(policy)
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/raw", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestPolicy_Matches(t *testing.T) {
+	policy := Policy{DetectedAs: map[string][]string{"application/x-ndjson": {"text/plain"}}}
+
+	assert.True(t, policy.Matches("image/png", "image/png"))
+	assert.True(t, policy.Matches("text/csv", "text/plain"))
+	assert.True(t, policy.Matches("application/x-ndjson", "text/plain"))
+	assert.False(t, policy.Matches("text/csv", "application/pdf"))
+	assert.False(t, policy.Matches("image/gif", "image/png"))
+}
@@ -0,0 +1,226 @@
+// Package watermark monitors heap, goroutine and file descriptor usage against thresholds, so
+// slow leaks in long-running services are reported, and optionally restarted gracefully, before
+// they exhaust the host.
+package watermark
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"runtime"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// DefaultInterval is how often usage is checked when Config.Interval is zero
+const DefaultInterval = 15 * time.Second
+
+// Resource is a monitored resource
+type Resource string
+
+// Monitored resources
+const (
+	HeapBytes  Resource = "heap_bytes"
+	Goroutines Resource = "goroutines"
+	FDs        Resource = "fds"
+)
+
+// Config sets the thresholds of the monitor, a zero threshold disables its check
+type Config struct {
+	// HeapBytes is the threshold of allocated heap bytes
+	HeapBytes uint64
+
+	// Goroutines is the threshold of running goroutines
+	Goroutines int
+
+	// FDs is the threshold of open file descriptors, it is only checked on Linux
+	FDs int
+
+	// Interval is how often usage is checked, DefaultInterval when zero
+	Interval time.Duration
+
+	// Restart sends SIGTERM to the process on the first breach, so the starter drains it and the
+	// supervisor (systemd, the orchestrator) starts a fresh one
+	Restart bool
+}
+
+// Breach is a threshold exceeded by a check
+type Breach struct {
+	Resource  Resource `json:"resource"`
+	Value     uint64   `json:"value"`
+	Threshold uint64   `json:"threshold"`
+}
+
+// Stats are the readings of the last check and the breach counts since the monitor started
+type Stats struct {
+	HeapBytes  uint64           `json:"heapBytes"`
+	Goroutines int              `json:"goroutines"`
+	FDs        int              `json:"fds"`
+	Breaches   map[Resource]int `json:"breaches"`
+	CheckedAt  time.Time        `json:"checkedAt"`
+}
+
+// Monitor periodically checks resource usage against the configured thresholds. A breach is
+// logged and counted once when the usage crosses its threshold, and again only after the usage
+// went back under it.
+type Monitor struct {
+	config Config
+	logger *zap.Logger
+
+	// mu protects stats, breached and restarted
+	mu        sync.Mutex
+	stats     Stats
+	breached  map[Resource]bool
+	restarted bool
+
+	// sample reads the current usage and restart stops the process, they are replaced in tests
+	sample  func() Stats
+	restart func() error
+}
+
+// New creates a monitor with the thresholds of the config
+func New(config Config, logger *zap.Logger) *Monitor {
+	if logger == nil {
+		var err error
+		logger, err = zap.NewProduction()
+		if err != nil {
+			log.Printf("Failed to create logger: %v", err)
+		}
+	}
+
+	if config.Interval <= 0 {
+		config.Interval = DefaultInterval
+	}
+
+	return &Monitor{
+		config:   config,
+		logger:   logger,
+		stats:    Stats{Breaches: make(map[Resource]int)},
+		breached: make(map[Resource]bool),
+		sample:   sample,
+		restart:  terminateSelf,
+	}
+}
+
+// Run checks usage every interval until the context is done
+func (m *Monitor) Run(ctx context.Context) {
+	m.logger.Info("Starting watermark monitor",
+		zap.Uint64("heapBytes", m.config.HeapBytes),
+		zap.Int("goroutines", m.config.Goroutines),
+		zap.Int("fds", m.config.FDs),
+		zap.Duration("interval", m.config.Interval))
+
+	ticker := time.NewTicker(m.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		m.Check()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reads the current usage and returns the thresholds it newly breaches
+func (m *Monitor) Check() []Breach {
+	current := m.sample()
+
+	var breaches []Breach
+	m.mu.Lock()
+	current.Breaches = m.stats.Breaches
+	m.stats = current
+
+	for _, reading := range []Breach{
+		{Resource: HeapBytes, Value: current.HeapBytes, Threshold: m.config.HeapBytes},
+		{Resource: Goroutines, Value: uint64(current.Goroutines), Threshold: uint64(m.config.Goroutines)},
+		{Resource: FDs, Value: uint64(max(current.FDs, 0)), Threshold: uint64(m.config.FDs)},
+	} {
+		if reading.Threshold == 0 {
+			continue
+		}
+
+		over := reading.Value > reading.Threshold
+		if over && !m.breached[reading.Resource] {
+			m.stats.Breaches[reading.Resource]++
+			breaches = append(breaches, reading)
+		}
+		m.breached[reading.Resource] = over
+	}
+
+	restart := len(breaches) > 0 && m.config.Restart && !m.restarted
+	if restart {
+		m.restarted = true
+	}
+	m.mu.Unlock()
+
+	for _, breach := range breaches {
+		m.logger.Warn("Resource watermark exceeded",
+			zap.String("resource", string(breach.Resource)),
+			zap.Uint64("value", breach.Value),
+			zap.Uint64("threshold", breach.Threshold))
+	}
+
+	if restart {
+		m.logger.Warn("Restarting service after watermark breach")
+		if err := m.restart(); err != nil {
+			m.logger.Error("Failed to restart service", zap.Error(err))
+		}
+	}
+
+	return breaches
+}
+
+// Stats returns the readings of the last check and the breach counts
+func (m *Monitor) Stats() Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := m.stats
+	stats.Breaches = make(map[Resource]int, len(m.stats.Breaches))
+	for resource, count := range m.stats.Breaches {
+		stats.Breaches[resource] = count
+	}
+	return stats
+}
+
+// Handler returns a handler reporting the stats as JSON, mount it on an internal route
+func (m *Monitor) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, m.Stats())
+	}
+}
+
+// sample reads the current usage of the process, FDs is -1 when it cannot be counted
+func sample() Stats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fds := -1
+	if entries, err := os.ReadDir("/proc/self/fd"); err == nil {
+		fds = len(entries)
+	}
+
+	return Stats{
+		HeapBytes:  mem.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+		FDs:        fds,
+		CheckedAt:  time.Now().UTC(),
+	}
+}
+
+// terminateSelf sends SIGTERM to the process, the VM starter drains it like any other shutdown
+func terminateSelf() error {
+	process, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		return err
+	}
+	return process.Signal(syscall.SIGTERM)
+}
@@ -0,0 +1,106 @@
+package watermark
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestMonitor_Check(t *testing.T) {
+	current := Stats{HeapBytes: 100, Goroutines: 10, FDs: 5}
+	restarts := 0
+
+	monitor := New(Config{HeapBytes: 200, Goroutines: 20, FDs: 10, Restart: true}, zaptest.NewLogger(t))
+	monitor.sample = func() Stats { return current }
+	monitor.restart = func() error {
+		restarts++
+		return nil
+	}
+
+	assert.Empty(t, monitor.Check())
+
+	// Crossing a threshold is reported once
+	current.HeapBytes = 300
+	current.Goroutines = 30
+	assert.Equal(t, []Breach{
+		{Resource: HeapBytes, Value: 300, Threshold: 200},
+		{Resource: Goroutines, Value: 30, Threshold: 20},
+	}, monitor.Check())
+	assert.Empty(t, monitor.Check())
+
+	// It is reported again after going back under the threshold
+	current.HeapBytes = 100
+	assert.Empty(t, monitor.Check())
+	current.HeapBytes = 300
+	assert.Equal(t, []Breach{{Resource: HeapBytes, Value: 300, Threshold: 200}}, monitor.Check())
+
+	// Uncountable file descriptors never breach
+	current.FDs = -1
+	assert.Empty(t, monitor.Check())
+
+	stats := monitor.Stats()
+	assert.Equal(t, map[Resource]int{HeapBytes: 2, Goroutines: 1}, stats.Breaches)
+	assert.Equal(t, uint64(300), stats.HeapBytes)
+
+	// The process is restarted once
+	assert.Equal(t, 1, restarts)
+}
+
+func TestMonitor_DisabledThresholds(t *testing.T) {
+	monitor := New(Config{}, zaptest.NewLogger(t))
+	monitor.sample = func() Stats { return Stats{HeapBytes: 1 << 40, Goroutines: 1 << 20, FDs: 1 << 20} }
+	monitor.restart = func() error {
+		t.Error("Expected no restart")
+		return nil
+	}
+
+	assert.Empty(t, monitor.Check())
+}
+
+func TestMonitor_Run(t *testing.T) {
+	monitor := New(Config{Interval: 10 * time.Millisecond}, zaptest.NewLogger(t))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		monitor.Run(ctx)
+		close(done)
+	}()
+
+	assert.Eventually(t, func() bool {
+		return !monitor.Stats().CheckedAt.IsZero()
+	}, time.Second, 10*time.Millisecond)
+	assert.Positive(t, monitor.Stats().Goroutines)
+
+	cancel()
+	<-done
+}
+
+func TestMonitor_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	monitor := New(Config{Goroutines: 1}, zaptest.NewLogger(t))
+	monitor.sample = func() Stats { return Stats{Goroutines: 2, FDs: -1} }
+	monitor.restart = func() error { return nil }
+	monitor.Check()
+
+	engine := gin.New()
+	engine.GET("/debug/watermarks", monitor.Handler())
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/watermarks", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var stats Stats
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+	assert.Equal(t, 2, stats.Goroutines)
+	assert.Equal(t, map[Resource]int{Goroutines: 1}, stats.Breaches)
+}